@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a new password entry",
+	Long: `Add a new password entry to your vault.
+Run without flags for interactive mode, or provide the entry details with flags.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runAdd(cmd, args)
+	},
+}
+
+var (
+	addTitle    string
+	addUsername string
+	addPassword string
+	addURL      string
+	addNotes    string
+	addGenerate bool
+	addLength   int
+	addOTP      string
+
+	addPolicy     policyFlags
+	addPassphrase passphraseFlags
+)
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+	addCmd.Flags().StringVarP(&addTitle, "title", "t", "", "Title of the entry")
+	addCmd.Flags().StringVarP(&addUsername, "username", "u", "", "Username for the entry")
+	addCmd.Flags().StringVarP(&addPassword, "password", "p", "", "Password for the entry")
+	addCmd.Flags().StringVar(&addURL, "url", "", "URL for the entry")
+	addCmd.Flags().StringVar(&addNotes, "notes", "", "Notes for the entry")
+	addCmd.Flags().BoolVarP(&addGenerate, "generate", "g", false, "Generate a random password")
+	addCmd.Flags().IntVarP(&addLength, "length", "l", 16, "Length of generated password")
+	addPolicy.register(addCmd)
+	addPassphrase.register(addCmd)
+	addCmd.Flags().StringVar(&addOTP, "otp", "", "otpauth:// URI or base32 secret for two-factor codes")
+}
+
+func runAdd(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	// Collect entry details before unlocking so validation errors fail fast
+	var title, username, password, url, notes, otpURI string
+	generate := addGenerate
+
+	hasFlags := addTitle != "" || addUsername != "" || addPassword != "" ||
+		addURL != "" || addNotes != "" || addGenerate || addOTP != ""
+
+	if hasFlags {
+		if addTitle == "" {
+			display.Error("Title is required. Use --title to set it")
+			os.Exit(1)
+		}
+		if addPassword == "" && !addGenerate {
+			display.Error("Password is required. Use --password or --generate")
+			os.Exit(1)
+		}
+		if addPassword != "" && addGenerate {
+			display.Error("Use either --password or --generate, not both")
+			os.Exit(1)
+		}
+		if (addPassphrase.enabled() || addPolicy.changed(cmd)) && !addGenerate {
+			display.Error("Passphrase and password policy options require --generate")
+			os.Exit(1)
+		}
+
+		title = addTitle
+		username = addUsername
+		password = addPassword
+		url = addURL
+		notes = addNotes
+		if addOTP != "" {
+			otpURI = parseOTPFlag(addOTP, title, username)
+		}
+	} else {
+		// Interactive mode
+		if !input.CheckTTY() {
+			display.Error("Interactive mode requires a terminal. Use flags instead")
+			os.Exit(1)
+		}
+
+		display.Title("Add Password Entry")
+
+		var err error
+		title, username, password, url, notes, err = input.PromptEntryDetails()
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to read entry details: %v", err))
+			os.Exit(1)
+		}
+
+		// PromptEntryDetails uses a placeholder when the user asks for a generated password
+		if password == "[GENERATED]" {
+			generate = true
+			password = ""
+		}
+	}
+
+	// Generate password if requested
+	var entropy float64
+	var policy *models.PasswordPolicy
+	if generate {
+		password, entropy, policy = generateSecret(cmd, nil, addLength, &addPassphrase, &addPolicy)
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	// Create entry
+	entry := models.NewEntry(title, username, password)
+	entry.URL = url
+	entry.Notes = notes
+	entry.OTP = otpURI
+	entry.Policy = policy
+
+	// Add entry to session
+	if err := session.AddEntry(entry); err != nil {
+		display.Error(fmt.Sprintf("Failed to add entry: %v", err))
+		os.Exit(1)
+	}
+
+	// Save vault
+	if err := vault.SaveCurrentSession(); err != nil {
+		display.Error(fmt.Sprintf("Failed to save vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Entry '%s' added successfully", entry.Title))
+
+	if generate {
+		display.Info(fmt.Sprintf("Generated password: %s", password))
+		display.Info(fmt.Sprintf("Entropy: %.1f bits", entropy))
+	}
+	display.ShowPasswordStrength(password, title, username, url)
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+)
+
+var agentCmd = &cobra.Command{
+	Use:   "agent",
+	Short: "Run the background unlock agent",
+	Long: `Run the unlock agent in the foreground.
+The agent keeps the vault key in memory so other commands do not have to ask
+for the master password again. It only answers requests from your own user and
+locks itself after the session timeout. 'gopassman unlock' starts it automatically.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runAgent(cmd, args)
+	},
+}
+
+var (
+	agentSocket  string
+	agentTimeout time.Duration
+)
+
+func init() {
+	rootCmd.AddCommand(agentCmd)
+	agentCmd.Flags().StringVar(&agentSocket, "socket", "", "Path of the agent socket")
+	agentCmd.Flags().DurationVar(&agentTimeout, "timeout", 0, "Lock the vault after this much idle time (default: the session_timeout setting)")
+}
+
+func runAgent(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	socketPath := agentSocket
+	if socketPath == "" {
+		socketPath = cfg.AgentSocket
+	}
+
+	timeout := cfg.SessionTimeout
+	if cmd.Flags().Changed("timeout") {
+		if agentTimeout <= 0 {
+			display.Error("Timeout must be greater than zero")
+			os.Exit(1)
+		}
+		timeout = agentTimeout
+	}
+
+	server := agent.NewServer(socketPath, timeout)
+
+	// Lock and clean up the socket on termination
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		server.Shutdown()
+	}()
+
+	if err := server.ListenAndServe(); err != nil {
+		display.Error(fmt.Sprintf("Agent failed: %v", err))
+		os.Exit(1)
+	}
+}
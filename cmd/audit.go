@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/audit"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Check the vault for weak, reused and old passwords",
+	Long: `Check every entry for security problems and report an overall score.
+
+Checks:
+  reused        the password is used by other entries
+  weak          the password's estimated strength is below --min-strength
+  old           the password has not changed for --max-age days
+  no-2fa        the entry has no one-time password
+  insecure-url  the URL uses HTTP instead of HTTPS
+  duplicate     another entry has the same username for the same site
+
+Reports never contain passwords. Use --format json or html to save a report
+for security reviews.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runAudit(cmd, args)
+	},
+}
+
+var (
+	auditFormat      string
+	auditOutput      string
+	auditMaxAge      int
+	auditMinStrength int
+	auditForce       bool
+)
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	defaults := audit.DefaultOptions()
+	auditCmd.Flags().StringVarP(&auditFormat, "format", "f", audit.FormatTable, "Report format ("+strings.Join(audit.Formats(), ", ")+")")
+	auditCmd.Flags().StringVarP(&auditOutput, "output", "o", "", "Write the report to a file instead of the terminal")
+	auditCmd.Flags().IntVar(&auditMaxAge, "max-age", int(defaults.MaxAge.Hours()/24), "Report passwords not changed for this many days (0 to disable)")
+	auditCmd.Flags().IntVar(&auditMinStrength, "min-strength", defaults.MinScore, "Report passwords with a lower strength score (0-4)")
+	auditCmd.Flags().BoolVar(&auditForce, "force", false, "Overwrite the output file if it exists")
+}
+
+func runAudit(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	// Validate options before unlocking so mistakes fail fast
+	format := strings.ToLower(auditFormat)
+	switch format {
+	case audit.FormatTable, audit.FormatJSON, audit.FormatHTML:
+	default:
+		display.Error(fmt.Sprintf("Unsupported format %q (supported: %s)", auditFormat, strings.Join(audit.Formats(), ", ")))
+		os.Exit(1)
+	}
+	if format == audit.FormatTable && auditOutput != "" {
+		display.Error("--output requires --format json or html")
+		os.Exit(1)
+	}
+	if auditMaxAge < 0 {
+		display.Error("--max-age must not be negative")
+		os.Exit(1)
+	}
+	if auditMinStrength < 0 || auditMinStrength > 4 {
+		display.Error("--min-strength must be between 0 and 4")
+		os.Exit(1)
+	}
+	if auditOutput != "" {
+		if _, err := os.Stat(auditOutput); err == nil && !auditForce {
+			display.Error(fmt.Sprintf("%s already exists. Use --force to overwrite it", auditOutput))
+			os.Exit(1)
+		}
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	report := audit.Run(session.ListEntries(), audit.Options{
+		MaxAge:   time.Duration(auditMaxAge) * 24 * time.Hour,
+		MinScore: auditMinStrength,
+	})
+
+	if format == audit.FormatTable {
+		display.ShowAuditReport(report)
+		return
+	}
+
+	var out io.Writer = os.Stdout
+	if auditOutput != "" {
+		file, err := os.OpenFile(auditOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to create report: %v", err))
+			os.Exit(1)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	var err error
+	if format == audit.FormatJSON {
+		err = audit.WriteJSON(out, report)
+	} else {
+		err = audit.WriteHTML(out, report)
+	}
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to write report: %v", err))
+		os.Exit(1)
+	}
+
+	if auditOutput != "" {
+		display.Success(fmt.Sprintf("Audit report written to %s (score %d/100)", auditOutput, report.Score))
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Manage encrypted vault backups",
+	Long: `Manage the encrypted backups kept next to your vault.
+A backup of the previous version is made every time the vault is saved.
+Backups stay encrypted with the master password that was current when they were made.`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List vault backups",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runBackupList(cmd, args)
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:   "restore <number>",
+	Short: "Restore the vault from a backup",
+	Long: `Replace the vault with one of its backups.
+The current vault is kept as backup 1, so a restore can itself be undone.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runBackupRestore(cmd, args)
+	},
+}
+
+var backupForce bool
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+	backupRestoreCmd.Flags().BoolVarP(&backupForce, "force", "f", false, "Restore without confirmation")
+}
+
+func runBackupList(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	backups, err := vault.ListBackups(cfg.VaultPath)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to list backups: %v", err))
+		os.Exit(1)
+	}
+
+	display.Title("Vault Backups")
+
+	if len(backups) == 0 {
+		display.Info("No backups found")
+		return
+	}
+
+	fmt.Printf("%-3s %-20s %-26s %-10s\n", "#", "Saved", "Vault Updated", "Size")
+	fmt.Printf("%s\n", strings.Repeat("-", 62))
+
+	for _, backup := range backups {
+		fmt.Printf("%-3d %-20s %-26s %-10s\n",
+			backup.Index, display.FormatTime(backup.ModTime), backup.UpdatedAt, fmt.Sprintf("%d B", backup.Size))
+	}
+
+	fmt.Printf("\nTotal: %d backups (keeping up to %d)\n", len(backups), vault.MaxBackups)
+}
+
+func runBackupRestore(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil || index < 1 {
+		display.Error("Backup number must be a positive integer. Use 'gopassman backup list' to see backups")
+		os.Exit(1)
+	}
+
+	backups, err := vault.ListBackups(cfg.VaultPath)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to list backups: %v", err))
+		os.Exit(1)
+	}
+
+	var selected *vault.Backup
+	for i := range backups {
+		if backups[i].Index == index {
+			selected = &backups[i]
+		}
+	}
+	if selected == nil {
+		display.Error(fmt.Sprintf("Backup %d not found. Use 'gopassman backup list' to see backups", index))
+		os.Exit(1)
+	}
+
+	// Confirm restore unless forced
+	if !backupForce {
+		if !input.CheckTTY() {
+			display.Error("Restore requires confirmation. Use --force to bypass or run in interactive mode")
+			os.Exit(1)
+		}
+
+		confirmed, err := input.PromptConfirm(fmt.Sprintf("Replace the vault with backup %d from %s?", index, display.FormatTime(selected.ModTime)), false)
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to get confirmation: %v", err))
+			os.Exit(1)
+		}
+
+		if !confirmed {
+			display.Info("Restore cancelled")
+			return
+		}
+	}
+
+	if err := vault.RestoreBackup(cfg.VaultPath, index); err != nil {
+		display.Error(fmt.Sprintf("Failed to restore backup: %v", err))
+		os.Exit(1)
+	}
+
+	// The restored vault may use a different key than the one the agent holds
+	vault.ClearSession()
+	if err := agent.NewClient(cfg.AgentSocket).Lock(); err != nil && !errors.Is(err, agent.ErrNotRunning) {
+		display.Warning(fmt.Sprintf("Failed to lock agent: %v", err))
+	}
+
+	display.Success(fmt.Sprintf("Vault restored from backup %d", index))
+	display.Info("The previous vault was kept as backup 1")
+	display.Info("The restored vault uses the master password that was current when the backup was made")
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/breach"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var breachCmd = &cobra.Command{
+	Use:   "breach (--hibp-file <file> | --online)",
+	Short: "Check passwords against known data breaches",
+	Long: `Check every entry's password against the Pwned Passwords breach data.
+
+With --hibp-file the check runs entirely offline against a local copy of the
+Pwned Passwords SHA-1 file ordered by hash (pwned-passwords-sha1-ordered.txt),
+as downloaded with the official downloader. The file is binary searched in
+place, so no index is built and nothing is written to disk.
+
+With --online the Pwned Passwords range API is queried using k-anonymity: only
+the first 5 characters of each password's SHA-1 hash are sent, and padded
+responses are requested so their size reveals nothing. Responses are cached
+for a day. Use --api-url for a mirror, such as one run inside your network.
+
+Entries found in breaches are marked as compromised, which 'gopassman show'
+displays until the password is changed. Passwords are only compared as SHA-1
+hashes and are never written anywhere.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runBreach(cmd, args)
+	},
+}
+
+var (
+	breachHIBPFile string
+	breachOnline   bool
+	breachAPIURL   string
+	breachNoCache  bool
+)
+
+func init() {
+	rootCmd.AddCommand(breachCmd)
+	breachCmd.Flags().StringVar(&breachHIBPFile, "hibp-file", "", "Pwned Passwords SHA-1 file ordered by hash")
+	breachCmd.Flags().BoolVar(&breachOnline, "online", false, "Query the Pwned Passwords range API")
+	breachCmd.Flags().StringVar(&breachAPIURL, "api-url", "", "Range API to query with --online (default from config)")
+	breachCmd.Flags().BoolVar(&breachNoCache, "no-cache", false, "Do not use or store cached API responses")
+	breachCmd.MarkFlagsMutuallyExclusive("hibp-file", "online")
+}
+
+func runBreach(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	if breachHIBPFile == "" && !breachOnline {
+		display.Error("Use --hibp-file to name a Pwned Passwords SHA-1 file, or --online to query the API")
+		os.Exit(1)
+	}
+	if breachAPIURL != "" && !breachOnline {
+		display.Error("--api-url requires --online")
+		os.Exit(1)
+	}
+
+	var source breach.Source
+	var sourceName string
+	if breachOnline {
+		apiURL := breachAPIURL
+		if apiURL == "" {
+			apiURL = cfg.BreachAPIURL
+		}
+		client := breach.NewRangeClient(apiURL)
+		if !breachNoCache {
+			client.CacheDir = filepath.Join(cfg.CacheDir, "pwned-passwords")
+		}
+		source, sourceName = client, "Pwned Passwords API"
+	} else {
+		// Open the hash file before unlocking so a bad file fails fast
+		hashFile, err := breach.OpenHashFile(breachHIBPFile)
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to open hash file: %v", err))
+			os.Exit(1)
+		}
+		defer hashFile.Close()
+		source, sourceName = hashFile, "Pwned Passwords file"
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+	entries := session.ListEntries()
+
+	results, err := breach.Check(entries, source)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to check passwords: %v", err))
+		os.Exit(1)
+	}
+
+	// Mark breached entries and clear marks of passwords no longer found
+	breached := make(map[string]int, len(results))
+	for _, result := range results {
+		breached[result.Entry.ID] = result.Count
+	}
+	checkedAt := time.Now()
+	for _, entry := range entries {
+		if entry.Password == "" {
+			continue
+		}
+		var mark *models.Compromise
+		if count, found := breached[entry.ID]; found {
+			mark = &models.Compromise{Count: count, Source: sourceName, CheckedAt: checkedAt}
+		}
+		if mark == nil && entry.Compromised == nil {
+			continue
+		}
+		if err := session.SetCompromised(entry.ID, mark); err != nil {
+			display.Error(fmt.Sprintf("Failed to mark entry: %v", err))
+			os.Exit(1)
+		}
+	}
+	if err := vault.SaveCurrentSession(); err != nil {
+		display.Error(fmt.Sprintf("Failed to save vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Title("Breach Check")
+	if len(results) == 0 {
+		display.Success(fmt.Sprintf("None of the %d entries use a password found in breaches", len(entries)))
+		return
+	}
+
+	display.ListBreachedEntries(results)
+	fmt.Println()
+	display.Warning(fmt.Sprintf("%d of %d entries use passwords found in data breaches. Change them with 'gopassman edit <entry> --generate'", len(results), len(entries)))
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/storage"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Show and change settings",
+	Long: `Show and change settings such as the session timeout, password generator
+defaults, key derivation cost and backup retention.
+Settings are read from config.yaml in the config directory, or the file given
+with --config. Each one can be overridden by an environment variable named
+after its key, e.g. GOPASSMAN_SESSION_TIMEOUT or GOPASSMAN_GENERATOR_LENGTH.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print the value of a setting",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigGet(cmd, args)
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Change a setting in the config file",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigSet(cmd, args)
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all settings with their values",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runConfigList(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configListCmd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) {
+	value, err := config.GetSetting(args[0])
+	if value.Key == "" {
+		display.Error(fmt.Sprintf("Failed to get setting: %v", err))
+		os.Exit(1)
+	}
+	if err != nil {
+		display.Warning(fmt.Sprintf("Invalid configuration: %v", err))
+	}
+
+	fmt.Println(storage.Redact(value.Value))
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+	key, value := args[0], args[1]
+
+	if err := config.SetSetting(key, value); err != nil {
+		display.Error(fmt.Sprintf("Failed to set %s: %v", key, err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Set %s to %s in %s", key, storage.Redact(value), config.FilePath(cfg.ConfigDir)))
+	if env := config.EnvVar(key); os.Getenv(env) != "" {
+		display.Warning(fmt.Sprintf("%s still overrides this setting in this shell", env))
+	}
+	if strings.HasPrefix(key, "kdf.") {
+		display.Info("Vaults with weaker key derivation settings are upgraded the next time they are unlocked")
+	}
+}
+
+func runConfigList(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	values, err := config.ListSettings()
+	if values == nil {
+		display.Error(fmt.Sprintf("Failed to list settings: %v", err))
+		os.Exit(1)
+	}
+
+	display.Title("Configuration")
+	display.Info(fmt.Sprintf("Config file: %s", config.FilePath(cfg.ConfigDir)))
+	fmt.Println()
+
+	fmt.Printf("%-28s %-36s %s\n", "Key", "Value", "Source")
+	fmt.Printf("%s\n", strings.Repeat("-", 72))
+
+	for _, value := range values {
+		source := value.Source
+		if source == config.SourceEnv {
+			source = config.EnvVar(value.Key)
+		}
+		fmt.Printf("%-28s %-36s %s\n", value.Key, storage.Redact(value.Value), source)
+	}
+
+	if err != nil {
+		fmt.Println()
+		display.Warning(fmt.Sprintf("Invalid configuration: %v", err))
+	}
+}
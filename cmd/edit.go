@@ -3,17 +3,13 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"sort"
-	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/egemengunel/Go-Password-Manager/config"
 	"github.com/egemengunel/Go-Password-Manager/internal/display"
-	"github.com/egemengunel/Go-Password-Manager/internal/generator"
 	"github.com/egemengunel/Go-Password-Manager/internal/input"
-	"github.com/egemengunel/Go-Password-Manager/models"
 	"github.com/egemengunel/Go-Password-Manager/vault"
 )
 
@@ -21,7 +17,7 @@ var editCmd = &cobra.Command{
 	Use:   "edit <entry-id-or-number>",
 	Short: "Edit an existing password entry",
 	Long: `Edit an existing password entry in your vault.
-You can specify the entry by ID or by its number from the list command.`,
+You can specify the entry by ID, a unique ID prefix, or its number from the list command.`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		runEdit(cmd, args)
@@ -36,6 +32,11 @@ var (
 	editNotes    string
 	editGenerate bool
 	editLength   int
+	editOTP      string
+	editNoOTP    bool
+
+	editPolicy     policyFlags
+	editPassphrase passphraseFlags
 )
 
 func init() {
@@ -47,6 +48,10 @@ func init() {
 	editCmd.Flags().StringVar(&editNotes, "notes", "", "New notes for the entry")
 	editCmd.Flags().BoolVarP(&editGenerate, "generate", "g", false, "Generate a new random password")
 	editCmd.Flags().IntVarP(&editLength, "length", "l", 16, "Length of generated password")
+	editPolicy.register(editCmd)
+	editPassphrase.register(editCmd)
+	editCmd.Flags().StringVar(&editOTP, "otp", "", "New otpauth:// URI or base32 secret for two-factor codes")
+	editCmd.Flags().BoolVar(&editNoOTP, "remove-otp", false, "Remove the two-factor key from the entry")
 }
 
 func runEdit(cmd *cobra.Command, args []string) {
@@ -59,74 +64,17 @@ func runEdit(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	// Try to use existing session first
-	session := vault.GetSession()
-	if session == nil {
-		// No active session, need to open vault
-		if !input.CheckTTY() {
-			display.Error("No active session. Please run with a valid session or in interactive mode")
-			os.Exit(1)
-		}
-
-		// Prompt for master password
-		masterPassword, err := input.PromptMasterPassword("Enter master password: ")
-		if err != nil {
-			display.Error(fmt.Sprintf("Failed to read password: %v", err))
-			os.Exit(1)
-		}
-
-		// Open vault
-		vaultData, err := vault.OpenVault(masterPassword, cfg.VaultPath)
-		if err != nil {
-			display.Error(fmt.Sprintf("Failed to open vault: %v", err))
-			os.Exit(1)
-		}
-
-		// Get password hash for session
-		passwordHash, err := vault.HashMasterPassword(masterPassword)
-		if err != nil {
-			display.Error(fmt.Sprintf("Failed to hash password: %v", err))
-			os.Exit(1)
-		}
-
-		// Start session
-		vault.StartSession(vaultData, cfg.VaultPath, masterPassword, passwordHash)
-		session = vault.GetSession()
-
-		// Clear master password from memory
-		for i := range masterPassword {
-			masterPassword = masterPassword[:i] + "x" + masterPassword[i+1:]
-		}
+	if editOTP != "" && editNoOTP {
+		display.Error("Use either --otp or --remove-otp, not both")
+		os.Exit(1)
 	}
 
-	entryIdentifier := args[0]
-	var entry *models.Entry
-	var err error
-
-	// Try to find entry by ID first
-	entry, err = session.GetEntry(entryIdentifier)
-	if err != nil {
-		// If not found by ID, try by number
-		if num, parseErr := strconv.Atoi(entryIdentifier); parseErr == nil {
-			entries := session.ListEntries()
-			if num > 0 && num <= len(entries) {
-				// Sort entries by title (same as list command)
-				sortedEntries := make([]*models.Entry, len(entries))
-				copy(sortedEntries, entries)
-				sort.Slice(sortedEntries, func(i, j int) bool {
-					return sortedEntries[i].Title < sortedEntries[j].Title
-				})
-
-				entry = sortedEntries[num-1]
-			} else {
-				display.Error(fmt.Sprintf("Entry number %d not found. Use 'gopassman list' to see available entries", num))
-				os.Exit(1)
-			}
-		} else {
-			display.Error(fmt.Sprintf("Entry '%s' not found", entryIdentifier))
-			os.Exit(1)
-		}
-	}
+	// Unlock the vault
+	session := openSession(cfg)
+
+	// Find the entry and remember its current values for the history
+	entry := resolveEntry(session, args[0])
+	previous := entry.Version()
 
 	// Show current entry details
 	fmt.Printf("Editing entry: %s\n", entry.Title)
@@ -134,9 +82,14 @@ func runEdit(cmd *cobra.Command, args []string) {
 
 	// Check if any flags were provided
 	hasFlags := editTitle != "" || editUsername != "" || editPassword != "" ||
-		editURL != "" || editNotes != "" || editGenerate
+		editURL != "" || editNotes != "" || editGenerate || editOTP != "" || editNoOTP
 
 	if hasFlags {
+		if (editPassphrase.enabled() || editPolicy.changed(cmd)) && !editGenerate {
+			display.Error("Passphrase and password policy options require --generate")
+			os.Exit(1)
+		}
+
 		// Use flag values
 		if editTitle != "" {
 			entry.Title = editTitle
@@ -153,24 +106,21 @@ func runEdit(cmd *cobra.Command, args []string) {
 		if editNotes != "" {
 			entry.Notes = editNotes
 		}
+		if editOTP != "" {
+			entry.OTP = parseOTPFlag(editOTP, entry.Title, entry.Username)
+		}
+		if editNoOTP {
+			entry.OTP = ""
+		}
 
 		// Generate password if requested
 		if editGenerate {
-			opts := generator.DefaultOptions()
-			opts.Length = editLength
-			if editLength < 8 {
-				opts.Length = 16
-			}
-
-			generatedPassword, err := generator.GeneratePassword(opts)
-			if err != nil {
-				display.Error(fmt.Sprintf("Failed to generate password: %v", err))
-				os.Exit(1)
-			}
+			generatedPassword, entropy, policy := generateSecret(cmd, entry.Policy, editLength, &editPassphrase, &editPolicy)
 			entry.Password = generatedPassword
+			entry.Policy = policy
 
 			display.Info(fmt.Sprintf("Generated new password: %s", generatedPassword))
-			display.ShowPasswordStrength(generatedPassword)
+			display.Info(fmt.Sprintf("Entropy: %.1f bits", entropy))
 		}
 	} else {
 		// Interactive mode
@@ -200,16 +150,12 @@ func runEdit(cmd *cobra.Command, args []string) {
 		}
 
 		if generatePassword {
-			opts := generator.DefaultOptions()
-			generatedPassword, err := generator.GeneratePassword(opts)
-			if err != nil {
-				display.Error(fmt.Sprintf("Failed to generate password: %v", err))
-				os.Exit(1)
-			}
+			generatedPassword, entropy, policy := generateSecret(cmd, entry.Policy, editLength, &editPassphrase, &editPolicy)
 			entry.Password = generatedPassword
+			entry.Policy = policy
 
 			display.Info(fmt.Sprintf("Generated new password: %s", generatedPassword))
-			display.ShowPasswordStrength(generatedPassword)
+			display.Info(fmt.Sprintf("Entropy: %.1f bits", entropy))
 		} else {
 			if newPassword, err := input.PromptPassword(fmt.Sprintf("Password [%s]:", display.MaskPassword(entry.Password)), false); err == nil && newPassword != "" {
 				entry.Password = newPassword
@@ -227,9 +173,15 @@ func runEdit(cmd *cobra.Command, args []string) {
 		}
 	}
 
-	// Update timestamps
+	// Keep the replaced values and update timestamps
+	entry.AddVersion(previous, cfg.HistoryLimit)
 	entry.UpdatedAt = time.Now()
 
+	// A breach check result only applies to the password that was checked
+	if entry.Password != previous.Password {
+		entry.Compromised = nil
+	}
+
 	// Update entry in session
 	if err := session.UpdateEntry(entry); err != nil {
 		display.Error(fmt.Sprintf("Failed to update entry: %v", err))
@@ -243,4 +195,8 @@ func runEdit(cmd *cobra.Command, args []string) {
 	}
 
 	display.Success(fmt.Sprintf("Entry '%s' updated successfully", entry.Title))
+
+	if entry.Password != previous.Password {
+		display.ShowPasswordStrength(entry.Password, entry.Title, entry.Username, entry.URL)
+	}
 }
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/exporter"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export --format <format> <file>",
+	Short: "Export entries to a file",
+	Long: `Export all entries to a file another password manager can import.
+
+Supported formats:
+  bitwarden-json  Bitwarden unencrypted JSON export
+  keepass-csv     KeePassXC CSV (no tags or custom fields)
+  keepass-xml     KeePass 2.x XML with folders as groups
+  gopassman-json  gopassman's own JSON with every entry field and timestamp
+  kdbx            KeePass KDBX 4 database, encrypted with a password you choose
+
+Exports contain every password. Use --passphrase or --recipient to write the
+export encrypted with age (https://age-encryption.org) instead of in plaintext.
+Plaintext exports must be confirmed interactively or with --plaintext.
+KDBX databases are always encrypted and need no confirmation.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runExport(cmd, args)
+	},
+}
+
+var (
+	exportFormat     string
+	exportPassphrase bool
+	exportRecipients []string
+	exportArmor      bool
+	exportPlaintext  bool
+	exportForce      bool
+)
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "", "Export format ("+strings.Join(exporter.Formats(), ", ")+")")
+	exportCmd.Flags().BoolVar(&exportPassphrase, "passphrase", false, "Encrypt the export with a passphrase (prompted)")
+	exportCmd.Flags().StringArrayVarP(&exportRecipients, "recipient", "r", nil, "Encrypt the export to an age or SSH public key (repeatable)")
+	exportCmd.Flags().BoolVarP(&exportArmor, "armor", "a", false, "Write encrypted exports as ASCII armor")
+	exportCmd.Flags().BoolVar(&exportPlaintext, "plaintext", false, "Confirm writing an unencrypted export")
+	exportCmd.Flags().BoolVar(&exportForce, "force", false, "Overwrite the output file if it exists")
+	exportCmd.MarkFlagRequired("format")
+}
+
+func runExport(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	if !exporter.IsFormat(exportFormat) {
+		display.Error(fmt.Sprintf("Unsupported format %q (supported: %s)", exportFormat, strings.Join(exporter.Formats(), ", ")))
+		os.Exit(1)
+	}
+
+	outputPath := args[0]
+	if _, err := os.Stat(outputPath); err == nil && !exportForce {
+		display.Error(fmt.Sprintf("%s already exists. Use --force to overwrite it", outputPath))
+		os.Exit(1)
+	}
+
+	// Decide how the export is protected before unlocking the vault
+	var databasePassword string
+	if exporter.NeedsPassword(exportFormat) {
+		databasePassword = promptNewPassphrase("KeePass database password")
+	}
+
+	recipients := exportRecipientsFromFlags()
+	if len(recipients) == 0 && databasePassword == "" && !confirmPlaintextExport() {
+		display.Info("Export cancelled")
+		return
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+	entries := session.ListEntries()
+
+	if err := writeExport(outputPath, entries, databasePassword, recipients); err != nil {
+		display.Error(fmt.Sprintf("Failed to export: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Exported %d entries to %s", len(entries), outputPath))
+	if len(recipients) == 0 && databasePassword == "" {
+		display.Warning("The export is not encrypted. Delete it securely once you no longer need it")
+	}
+	if exportFormat == exporter.FormatKeePassCSV && hasUnexportedFields(entries) {
+		display.Warning("Tags and custom fields are not part of the KeePass CSV format and were left out")
+	}
+}
+
+// exportRecipientsFromFlags builds the age recipients requested by --passphrase and --recipient
+func exportRecipientsFromFlags() []age.Recipient {
+	if exportPassphrase && len(exportRecipients) > 0 {
+		display.Error("--passphrase cannot be combined with --recipient")
+		os.Exit(1)
+	}
+
+	if exportPassphrase {
+		passphrase := promptNewPassphrase("Export passphrase")
+		recipient, err := exporter.PassphraseRecipient(passphrase)
+		if err != nil {
+			display.Error(err.Error())
+			os.Exit(1)
+		}
+		return []age.Recipient{recipient}
+	}
+
+	recipients := make([]age.Recipient, 0, len(exportRecipients))
+	for _, value := range exportRecipients {
+		recipient, err := exporter.ParseRecipient(value)
+		if err != nil {
+			display.Error(err.Error())
+			os.Exit(1)
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients
+}
+
+// promptNewPassphrase reads a passphrase for the export file and its confirmation
+func promptNewPassphrase(label string) string {
+	if !input.CheckTTY() {
+		display.Error(fmt.Sprintf("Reading the %s requires an interactive terminal", strings.ToLower(label)))
+		os.Exit(1)
+	}
+
+	passphrase, err := input.PromptMasterPassword(label + ": ")
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to read passphrase: %v", err))
+		os.Exit(1)
+	}
+	confirmation, err := input.PromptMasterPassword("Confirm " + strings.ToLower(label) + ": ")
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to read passphrase: %v", err))
+		os.Exit(1)
+	}
+	if passphrase != confirmation {
+		display.Error("Passphrases do not match")
+		os.Exit(1)
+	}
+
+	return passphrase
+}
+
+// confirmPlaintextExport makes the user acknowledge an unencrypted export
+func confirmPlaintextExport() bool {
+	if exportPlaintext {
+		return true
+	}
+
+	if !input.CheckTTY() {
+		display.Error("Refusing to write an unencrypted export. Use --passphrase, --recipient or --plaintext")
+		os.Exit(1)
+	}
+
+	display.Warning("The export will contain all your passwords in plaintext")
+	confirmed, err := input.PromptConfirm("Write an unencrypted export?", false)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to get confirmation: %v", err))
+		os.Exit(1)
+	}
+	return confirmed
+}
+
+// writeExport writes the export file with owner-only permissions, encrypting
+// it when recipients are given. password protects formats with their own
+// encryption. Without --force an existing file is kept.
+func writeExport(path string, entries []*models.Entry, password string, recipients []age.Recipient) error {
+	return exporter.WriteFile(path, exportForce, func(w io.Writer) error {
+		if len(recipients) == 0 {
+			return exporter.Write(w, exportFormat, entries, password)
+		}
+		return writeEncryptedExport(w, entries, password, recipients)
+	})
+}
+
+// writeEncryptedExport writes the export to w encrypted with age
+func writeEncryptedExport(w io.Writer, entries []*models.Entry, password string, recipients []age.Recipient) error {
+	encrypted, err := exporter.Encrypt(w, exportArmor, recipients...)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt export: %w", err)
+	}
+	if err := exporter.Write(encrypted, exportFormat, entries, password); err != nil {
+		return err
+	}
+	return encrypted.Close()
+}
+
+// hasUnexportedFields reports whether any entry has data the KeePass CSV layout cannot hold
+func hasUnexportedFields(entries []*models.Entry) bool {
+	for _, entry := range entries {
+		if len(entry.Tags) > 0 || len(entry.Custom) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -2,17 +2,26 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/egemengunel/Go-Password-Manager/internal/display"
 	"github.com/egemengunel/Go-Password-Manager/internal/generator"
+	"github.com/egemengunel/Go-Password-Manager/models"
 )
 
 var generateCmd = &cobra.Command{
 	Use:   "generate",
-	Short: "Generate a secure password",
+	Short: "Generate a secure password or passphrase",
 	Long: `Generate a secure random password with customizable options.
+Site password policies can be given as per-class minimums, allowed and
+forbidden characters, or in Apple's passwordrules syntax with --rules, e.g.
+--rules "minlength: 8; maxlength: 20; required: upper; required: digit; allowed: [-_]".
+Every required class is guaranteed to appear.
+With --words, generate a diceware passphrase from the EFF large wordlist
+(or your own with --wordlist) instead, which is easier to type on phones and TVs.
 This command does not store the password - it only generates and displays it.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		runGenerate(cmd, args)
@@ -20,27 +29,234 @@ This command does not store the password - it only generates and displays it.`,
 }
 
 var (
-	genLength    int
-	genNoUpper   bool
-	genNoLower   bool
-	genNoNumbers bool
-	genNoSymbols bool
-	genAmbiguous bool
-	genCount     int
+	genLength int
+	genCount  int
+
+	genPolicy     policyFlags
+	genPassphrase passphraseFlags
 )
 
 func init() {
 	rootCmd.AddCommand(generateCmd)
 	generateCmd.Flags().IntVarP(&genLength, "length", "l", 16, "Length of the password")
-	generateCmd.Flags().BoolVar(&genNoUpper, "no-upper", false, "Exclude uppercase letters")
-	generateCmd.Flags().BoolVar(&genNoLower, "no-lower", false, "Exclude lowercase letters")
-	generateCmd.Flags().BoolVar(&genNoNumbers, "no-numbers", false, "Exclude numbers")
-	generateCmd.Flags().BoolVar(&genNoSymbols, "no-symbols", false, "Exclude symbols")
-	generateCmd.Flags().BoolVar(&genAmbiguous, "ambiguous", false, "Include ambiguous characters (0, O, 1, l, I, |)")
 	generateCmd.Flags().IntVarP(&genCount, "count", "c", 1, "Number of passwords to generate")
+	genPolicy.register(generateCmd)
+	genPassphrase.register(generateCmd)
+}
+
+// policyFlags holds the password policy options shared by generate, add and edit
+type policyFlags struct {
+	noUpper        bool
+	noLower        bool
+	noNumbers      bool
+	noSymbols      bool
+	ambiguous      bool
+	minUpper       int
+	minLower       int
+	minNumbers     int
+	minSymbols     int
+	symbols        string
+	allowed        string
+	forbidden      string
+	maxConsecutive int
+	rules          string
+}
+
+// policyFlagNames lists the flags registered by policyFlags
+var policyFlagNames = []string{
+	"no-upper", "no-lower", "no-numbers", "no-symbols", "ambiguous",
+	"min-upper", "min-lower", "min-numbers", "min-symbols",
+	"symbols", "allowed", "forbidden", "max-consecutive", "rules",
+}
+
+// register adds the password policy flags to a command
+func (f *policyFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.noUpper, "no-upper", false, "Exclude uppercase letters")
+	cmd.Flags().BoolVar(&f.noLower, "no-lower", false, "Exclude lowercase letters")
+	cmd.Flags().BoolVar(&f.noNumbers, "no-numbers", false, "Exclude numbers")
+	cmd.Flags().BoolVar(&f.noSymbols, "no-symbols", false, "Exclude symbols")
+	cmd.Flags().BoolVar(&f.ambiguous, "ambiguous", false, "Include ambiguous characters (0, O, 1, l, I, |)")
+	cmd.Flags().IntVar(&f.minUpper, "min-upper", 1, "Minimum number of uppercase letters")
+	cmd.Flags().IntVar(&f.minLower, "min-lower", 1, "Minimum number of lowercase letters")
+	cmd.Flags().IntVar(&f.minNumbers, "min-numbers", 1, "Minimum number of numbers")
+	cmd.Flags().IntVar(&f.minSymbols, "min-symbols", 1, "Minimum number of symbols")
+	cmd.Flags().StringVar(&f.symbols, "symbols", "", "Symbols to use instead of the default set")
+	cmd.Flags().StringVar(&f.allowed, "allowed", "", "Additional characters passwords may contain")
+	cmd.Flags().StringVar(&f.forbidden, "forbidden", "", "Characters passwords must never contain")
+	cmd.Flags().IntVar(&f.maxConsecutive, "max-consecutive", 0, "Longest run of one repeated character (0 for no limit)")
+	cmd.Flags().StringVar(&f.rules, "rules", "", "Password rules in Apple passwordrules syntax")
+}
+
+// changed reports whether any password policy flag was given
+func (f *policyFlags) changed(cmd *cobra.Command) bool {
+	for _, name := range policyFlagNames {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply refines opts with the policy flags given on the command line. Rules
+// are applied first so the other flags can adjust them.
+func (f *policyFlags) apply(cmd *cobra.Command, opts *generator.PasswordOptions) error {
+	flags := cmd.Flags()
+
+	if flags.Changed("rules") {
+		if err := generator.ApplyPasswordRules(opts, f.rules); err != nil {
+			return fmt.Errorf("Invalid --rules: %w", err)
+		}
+	}
+
+	classes := []struct {
+		exclude, min string
+		noFlag       bool
+		minFlag      int
+		include      *bool
+		minimum      *int
+	}{
+		{"no-upper", "min-upper", f.noUpper, f.minUpper, &opts.IncludeUpper, &opts.MinUpper},
+		{"no-lower", "min-lower", f.noLower, f.minLower, &opts.IncludeLower, &opts.MinLower},
+		{"no-numbers", "min-numbers", f.noNumbers, f.minNumbers, &opts.IncludeNumbers, &opts.MinNumbers},
+		{"no-symbols", "min-symbols", f.noSymbols, f.minSymbols, &opts.IncludeSymbols, &opts.MinSymbols},
+	}
+	for _, class := range classes {
+		if class.noFlag && flags.Changed(class.min) {
+			return fmt.Errorf("Use either --%s or --%s, not both", class.exclude, class.min)
+		}
+		if class.noFlag {
+			*class.include, *class.minimum = false, 0
+		}
+		if flags.Changed(class.min) {
+			if class.minFlag < 0 {
+				return fmt.Errorf("--%s cannot be negative", class.min)
+			}
+			*class.minimum = class.minFlag
+			*class.include = *class.include || class.minFlag > 0
+		}
+	}
+
+	if flags.Changed("ambiguous") {
+		opts.ExcludeAmbiguous = !f.ambiguous
+	}
+	if flags.Changed("symbols") {
+		opts.Symbols = f.symbols
+	}
+	if flags.Changed("allowed") {
+		opts.Allowed = f.allowed
+	}
+	if flags.Changed("forbidden") {
+		opts.Forbidden = f.forbidden
+	}
+	if flags.Changed("max-consecutive") {
+		opts.MaxConsecutive = f.maxConsecutive
+	}
+	return nil
+}
+
+// passphraseFlags holds the passphrase options shared by generate, add and edit
+type passphraseFlags struct {
+	words       int
+	separator   string
+	capitalize  bool
+	appendDigit bool
+	wordlist    string
+}
+
+// register adds the passphrase flags to a command
+func (f *passphraseFlags) register(cmd *cobra.Command) {
+	cmd.Flags().IntVar(&f.words, "words", 0, "Generate a passphrase of this many words instead of random characters")
+	cmd.Flags().StringVar(&f.separator, "separator", "-", "Separator between passphrase words")
+	cmd.Flags().BoolVar(&f.capitalize, "capitalize", false, "Capitalize every passphrase word")
+	cmd.Flags().BoolVar(&f.appendDigit, "append-digit", false, "Append a random digit to the passphrase")
+	cmd.Flags().StringVar(&f.wordlist, "wordlist", "", "Wordlist file to use instead of the EFF large wordlist")
+}
+
+// enabled reports whether a passphrase was requested
+func (f *passphraseFlags) enabled() bool {
+	return f.words != 0
+}
+
+// options validates the passphrase flags and loads the wordlist, exiting on error
+func (f *passphraseFlags) options() generator.PassphraseOptions {
+	if f.words < 3 || f.words > 32 {
+		display.Error("Passphrases must have between 3 and 32 words")
+		os.Exit(1)
+	}
+
+	opts := generator.DefaultPassphraseOptions()
+	opts.Words = f.words
+	opts.Separator = f.separator
+	opts.Capitalize = f.capitalize
+	opts.AppendDigit = f.appendDigit
+
+	if f.wordlist != "" {
+		words, err := generator.LoadWordlist(f.wordlist)
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to load wordlist: %v", err))
+			os.Exit(1)
+		}
+		opts.Wordlist = words
+	}
+	return opts
+}
+
+// generateSecret generates a passphrase if one was requested, or a random
+// password otherwise. Password options start from policy, an entry's stored
+// policy, or the defaults if it is nil, and are refined by the policy flags
+// and --length given on the command line. It returns the secret, its entropy
+// in bits and the policy the entry should keep, exiting on error.
+func generateSecret(cmd *cobra.Command, policy *models.PasswordPolicy, length int, phrase *passphraseFlags, flags *policyFlags) (string, float64, *models.PasswordPolicy) {
+	if phrase.enabled() {
+		opts := phrase.options()
+		passphrase, err := generator.GeneratePassphrase(opts)
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to generate passphrase: %v", err))
+			os.Exit(1)
+		}
+		return passphrase, generator.PassphraseEntropy(opts), policy
+	}
+
+	opts := generator.DefaultOptions()
+	rules := ""
+	if policy != nil {
+		opts = generator.OptionsFromPolicy(policy)
+		rules = policy.Rules
+	}
+	if cmd.Flags().Changed("length") {
+		opts.Length = length
+		if length < 8 {
+			opts.Length = 16
+		}
+	}
+
+	if err := flags.apply(cmd, &opts); err != nil {
+		display.Error(err.Error())
+		os.Exit(1)
+	}
+	if cmd.Flags().Changed("rules") {
+		rules = flags.rules
+	}
+
+	password, err := generator.GeneratePassword(opts)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to generate password: %v", err))
+		os.Exit(1)
+	}
+
+	// Only entries generated under a site policy remember it
+	if policy != nil || flags.changed(cmd) {
+		policy = opts.Policy(rules)
+	}
+	return password, generator.PasswordEntropy(opts), policy
 }
 
 func runGenerate(cmd *cobra.Command, args []string) {
+	if genPassphrase.enabled() {
+		runGeneratePassphrase(cmd)
+		return
+	}
+
 	// Validate parameters
 	if genLength < 4 {
 		display.Error("Password length must be at least 4 characters")
@@ -53,17 +269,18 @@ func runGenerate(cmd *cobra.Command, args []string) {
 	}
 
 	// Build password options
-	opts := generator.PasswordOptions{
-		Length:           genLength,
-		IncludeLower:     !genNoLower,
-		IncludeUpper:     !genNoUpper,
-		IncludeNumbers:   !genNoNumbers,
-		IncludeSymbols:   !genNoSymbols,
-		ExcludeAmbiguous: !genAmbiguous,
+	opts := generator.DefaultOptions()
+	if cmd.Flags().Changed("length") {
+		opts.Length = genLength
+	}
+	if err := genPolicy.apply(cmd, &opts); err != nil {
+		display.Error(err.Error())
+		return
 	}
 
 	// Validate that at least one character type is included
-	if !opts.IncludeLower && !opts.IncludeUpper && !opts.IncludeNumbers && !opts.IncludeSymbols {
+	if !opts.IncludeLower && !opts.IncludeUpper && !opts.IncludeNumbers && !opts.IncludeSymbols &&
+		opts.Allowed == "" && len(opts.Required) == 0 {
 		display.Error("At least one character type must be included")
 		return
 	}
@@ -81,6 +298,7 @@ func runGenerate(cmd *cobra.Command, args []string) {
 		if genCount == 1 {
 			fmt.Printf("Password: %s\n", password)
 			display.ShowPasswordStrength(password)
+			display.Info(fmt.Sprintf("Entropy: %.1f bits", generator.PasswordEntropy(opts)))
 		} else {
 			fmt.Printf("%2d: %s\n", i+1, password)
 		}
@@ -88,27 +306,84 @@ func runGenerate(cmd *cobra.Command, args []string) {
 
 	// Show generation settings
 	fmt.Println()
-	display.Info(fmt.Sprintf("Settings: Length=%d", genLength))
+	display.Info(fmt.Sprintf("Settings: Length=%d", opts.EffectiveLength()))
 
 	var included []string
-	if opts.IncludeLower {
-		included = append(included, "lowercase")
+	if opts.IncludeLower || opts.MinLower > 0 {
+		included = append(included, fmt.Sprintf("lowercase (min %d)", opts.MinLower))
 	}
-	if opts.IncludeUpper {
-		included = append(included, "uppercase")
+	if opts.IncludeUpper || opts.MinUpper > 0 {
+		included = append(included, fmt.Sprintf("uppercase (min %d)", opts.MinUpper))
 	}
-	if opts.IncludeNumbers {
-		included = append(included, "numbers")
+	if opts.IncludeNumbers || opts.MinNumbers > 0 {
+		included = append(included, fmt.Sprintf("numbers (min %d)", opts.MinNumbers))
 	}
-	if opts.IncludeSymbols {
-		included = append(included, "symbols")
+	if opts.IncludeSymbols || opts.MinSymbols > 0 {
+		included = append(included, fmt.Sprintf("symbols (min %d)", opts.MinSymbols))
 	}
 
 	if len(included) > 0 {
-		display.Info(fmt.Sprintf("Includes: %s", fmt.Sprintf("%v", included)))
+		display.Info(fmt.Sprintf("Includes: %s", strings.Join(included, ", ")))
+	}
+	if opts.Allowed != "" {
+		display.Info(fmt.Sprintf("Also allowed: %s", opts.Allowed))
+	}
+	for _, set := range opts.Required {
+		display.Info(fmt.Sprintf("Requires one of: %s", set))
+	}
+	if opts.Forbidden != "" {
+		display.Info(fmt.Sprintf("Forbidden: %s", opts.Forbidden))
+	}
+	if opts.MaxConsecutive > 0 {
+		display.Info(fmt.Sprintf("At most %d identical characters in a row", opts.MaxConsecutive))
 	}
 
 	if opts.ExcludeAmbiguous {
 		display.Info("Ambiguous characters excluded")
 	}
 }
+
+// runGeneratePassphrase generates diceware passphrases
+func runGeneratePassphrase(cmd *cobra.Command) {
+	for _, name := range append([]string{"length"}, policyFlagNames...) {
+		if cmd.Flags().Changed(name) {
+			display.Error(fmt.Sprintf("--%s only applies to random character passwords, not to --words", name))
+			os.Exit(1)
+		}
+	}
+
+	if genCount < 1 || genCount > 50 {
+		display.Error("Count must be between 1 and 50")
+		return
+	}
+
+	opts := genPassphrase.options()
+
+	display.Title("Generated Passphrases")
+
+	for i := 0; i < genCount; i++ {
+		passphrase, err := generator.GeneratePassphrase(opts)
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to generate passphrase: %v", err))
+			return
+		}
+
+		if genCount == 1 {
+			fmt.Printf("Passphrase: %s\n", passphrase)
+			display.ShowPasswordStrength(passphrase)
+		} else {
+			fmt.Printf("%2d: %s\n", i+1, passphrase)
+		}
+	}
+
+	// Show generation settings
+	fmt.Println()
+	listSize := len(opts.Wordlist)
+	source := genPassphrase.wordlist
+	if opts.Wordlist == nil {
+		listSize = len(generator.EFFWordlist())
+		source = "EFF large wordlist"
+	}
+	display.Info(fmt.Sprintf("Settings: %d words from %s (%d words)", opts.Words, source, listSize))
+	display.Info(fmt.Sprintf("Entropy: %.1f bits", generator.PassphraseEntropy(opts)))
+}
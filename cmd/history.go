@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <entry-id-or-number>",
+	Short: "Show previous versions of an entry",
+	Long: `Show the previous values of an entry, newest first.
+A version is kept every time the entry is edited, up to the configured history limit.
+Use 'gopassman restore <entry> --version N' to roll a field back.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runHistory(cmd, args)
+	},
+}
+
+var historyPassword bool
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.Flags().BoolVarP(&historyPassword, "password", "p", false, "Show previous passwords in plain text")
+}
+
+func runHistory(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	// Find the entry
+	entry := resolveEntry(session, args[0])
+
+	display.Title(fmt.Sprintf("History: %s", entry.Title))
+	display.ShowEntryHistory(entry, historyPassword)
+
+	if len(entry.History) > 0 {
+		fmt.Println()
+		display.Info(fmt.Sprintf("Keeping up to %d versions per entry", cfg.HistoryLimit))
+	}
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/importer"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import --format <format> <file>",
+	Short: "Import entries from another password manager",
+	Long: `Import entries from an export of another password manager.
+
+Supported formats:
+  bitwarden-json  Bitwarden unencrypted JSON export
+  1pux            1Password .1pux export
+  lastpass-csv    LastPass CSV export
+  keepass-xml     KeePass / KeePassXC XML export
+  chrome-csv      Browser CSV export (Chrome, Edge, Brave, Firefox, Safari)
+  gopassman-json  gopassman's own JSON export
+  kdbx            KeePass / KeePassXC KDBX 4 database (prompts for its password)
+
+Entries that match an existing entry (same username and site, or same username
+and title) are skipped unless --allow-duplicates is given. Use --dry-run to
+preview the import without changing the vault.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runImport(cmd, args)
+	},
+}
+
+var (
+	importFormat          string
+	importDryRun          bool
+	importAllowDuplicates bool
+	importTag             string
+)
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "Export format ("+strings.Join(importer.Formats(), ", ")+")")
+	importCmd.Flags().BoolVarP(&importDryRun, "dry-run", "n", false, "Preview the import without saving")
+	importCmd.Flags().BoolVar(&importAllowDuplicates, "allow-duplicates", false, "Import entries that duplicate existing ones")
+	importCmd.Flags().StringVar(&importTag, "tag", "", "Add this tag to every imported entry")
+	importCmd.MarkFlagRequired("format")
+}
+
+func runImport(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	// Encrypted files such as KeePass databases have a password of their own
+	var filePassword string
+	if importer.NeedsPassword(importFormat) {
+		if !input.CheckTTY() {
+			display.Error(fmt.Sprintf("Importing %s files requires an interactive terminal", importFormat))
+			os.Exit(1)
+		}
+
+		var err error
+		filePassword, err = input.PromptMasterPassword(fmt.Sprintf("Password for %s: ", filepath.Base(args[0])))
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to read password: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	// Parse the export before unlocking so format errors fail fast
+	entries, err := importer.ParseFile(importFormat, args[0], filePassword)
+	if err != nil {
+		display.Error(err.Error())
+		os.Exit(1)
+	}
+
+	if len(entries) == 0 {
+		display.Info("The export contains no entries")
+		return
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+	existing := session.ListEntries()
+
+	// Classify entries, checking against both the vault and earlier rows of the export
+	var toImport []*models.Entry
+	duplicates := make(map[*models.Entry]*models.Entry)
+	for _, entry := range entries {
+		if importTag != "" {
+			entry.Tags = append(entry.Tags, importTag)
+		}
+
+		if match := importer.FindDuplicate(entry, existing); match != nil {
+			duplicates[entry] = match
+			if !importAllowDuplicates {
+				continue
+			}
+		}
+
+		toImport = append(toImport, entry)
+		existing = append(existing, entry)
+	}
+
+	if importDryRun {
+		display.Title(fmt.Sprintf("Import Preview: %s", args[0]))
+		showImportPreview(entries, duplicates)
+		fmt.Println()
+		display.Info(fmt.Sprintf("%d entries would be imported, %d duplicates found", len(toImport), len(duplicates)))
+		display.Info("Dry run: the vault was not modified")
+		return
+	}
+
+	for _, entry := range toImport {
+		if err := session.AddEntry(entry); err != nil {
+			display.Error(fmt.Sprintf("Failed to add entry '%s': %v", entry.Title, err))
+			os.Exit(1)
+		}
+	}
+
+	// Save vault
+	if len(toImport) > 0 {
+		if err := vault.SaveCurrentSession(); err != nil {
+			display.Error(fmt.Sprintf("Failed to save vault: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	display.Success(fmt.Sprintf("Imported %d of %d entries", len(toImport), len(entries)))
+	if skipped := len(entries) - len(toImport); skipped > 0 {
+		display.Info(fmt.Sprintf("Skipped %d duplicates. Use --dry-run to review them or --allow-duplicates to import them", skipped))
+	}
+}
+
+// showImportPreview prints the entries of an export with their import status
+func showImportPreview(entries []*models.Entry, duplicates map[*models.Entry]*models.Entry) {
+	fmt.Printf("%-3s %-20s %-20s %-25s %-15s %-12s\n",
+		"#", "Title", "Username", "URL", "Folder", "Status")
+	fmt.Printf("%s\n", strings.Repeat("-", 100))
+
+	for i, entry := range entries {
+		status := "new"
+		if match, ok := duplicates[entry]; ok {
+			status = "duplicate of " + display.ShortID(match.ID)
+		}
+
+		fmt.Printf("%-3d %-20s %-20s %-25s %-15s %s\n",
+			i+1, truncate(entry.Title, 20), truncate(entry.Username, 20),
+			truncate(entry.URL, 25), truncate(entry.Folder, 15), status)
+	}
+}
+
+// truncate shortens s to fit a column of the given width
+func truncate(s string, width int) string {
+	if len(s) <= width-2 {
+		return s
+	}
+	return s[:width-5] + "..."
+}
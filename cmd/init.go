@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/storage"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Initialize a new password vault",
+	Long: `Initialize a new encrypted password vault.
+You will be asked to choose a master password. The master password cannot be
+recovered, so make sure you remember it. Use --vault to create a named vault
+next to the default one.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runInit(cmd, args)
+	},
+}
+
+const minMasterPasswordLength = 8
+
+func init() {
+	rootCmd.AddCommand(initCmd)
+}
+
+func runInit(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Refuse to overwrite an existing vault
+	_, err := vault.StatVault(cfg.VaultPath)
+	if err == nil {
+		display.Error(fmt.Sprintf("A vault already exists at %s", storage.Redact(cfg.VaultPath)))
+		os.Exit(1)
+	}
+	if !errors.Is(err, storage.ErrNotExist) {
+		display.Error(fmt.Sprintf("Failed to access vault location: %v", err))
+		os.Exit(1)
+	}
+
+	if !input.CheckTTY() {
+		display.Error("Vault initialization requires an interactive terminal")
+		os.Exit(1)
+	}
+
+	display.Title("Initialize Password Vault")
+	display.Info(fmt.Sprintf("Vault location: %s", storage.Redact(cfg.VaultPath)))
+
+	createVault(cfg, cfg.VaultPath)
+
+	// Remember the vault under its name, so --vault and 'vault use' find it
+	if err := registerVault(cfg, cfg.Vault, cfg.VaultPath); err != nil {
+		display.Warning(fmt.Sprintf("Failed to register vault: %v", err))
+	}
+
+	display.Success("Vault created successfully")
+	display.Warning("Your master password cannot be recovered. Keep it safe!")
+	display.Info("Use 'gopassman add' to add your first entry")
+}
+
+// createVault prompts for a new master password and creates a vault at location
+func createVault(cfg *config.Config, location string) {
+	// Prompt for master password
+	masterPassword, err := input.PromptMasterPassword("Choose a master password: ")
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to read password: %v", err))
+		os.Exit(1)
+	}
+
+	if len(masterPassword) < minMasterPasswordLength {
+		display.Error(fmt.Sprintf("Master password must be at least %d characters", minMasterPasswordLength))
+		os.Exit(1)
+	}
+
+	// Confirm master password
+	if err := input.PromptConfirmPassword(masterPassword); err != nil {
+		display.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// Ensure configuration directory exists
+	if err := cfg.EnsureConfigDir(); err != nil {
+		display.Error(fmt.Sprintf("Failed to create config directory: %v", err))
+		os.Exit(1)
+	}
+
+	// Create vault
+	if err := vault.CreateVault(masterPassword, location); err != nil {
+		display.Error(fmt.Sprintf("Failed to create vault: %v", err))
+		os.Exit(1)
+	}
+}
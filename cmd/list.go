@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List password entries",
+	Long: `List all password entries in your vault.
+Use --search to filter entries by title, username, URL, notes, folder, tags or custom fields.`,
+	Aliases: []string{"ls"},
+	Args:    cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runList(cmd, args)
+	},
+}
+
+var (
+	listSearch string
+	listInfo   bool
+)
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.Flags().StringVarP(&listSearch, "search", "s", "", "Search entries across all fields")
+	listCmd.Flags().BoolVarP(&listInfo, "info", "i", false, "Show vault information")
+}
+
+func runList(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	if listInfo {
+		display.ShowVaultInfo(session.Vault)
+		fmt.Println()
+	}
+
+	entries := session.ListEntries()
+
+	if listSearch == "" {
+		display.Title("Password Entries")
+		display.ListEntries(entries, false)
+		return
+	}
+
+	// Search results keep their numbers from the full list, which is what
+	// show, edit and delete resolve numbers against
+	numbers := display.EntryNumbers(entries)
+	display.Title(fmt.Sprintf("Search results for '%s'", listSearch))
+	display.ListNumberedEntries(filterEntries(entries, listSearch), numbers, false)
+}
+
+// filterEntries returns the entries where any field contains the query (case-insensitive)
+func filterEntries(entries []*models.Entry, query string) []*models.Entry {
+	query = strings.ToLower(query)
+
+	var matches []*models.Entry
+	for _, entry := range entries {
+		if entryMatches(entry, query) {
+			matches = append(matches, entry)
+		}
+	}
+
+	return matches
+}
+
+// entryMatches reports whether a lowercased query appears in any searchable field of the entry
+func entryMatches(entry *models.Entry, query string) bool {
+	fields := []string{entry.Title, entry.Username, entry.URL, entry.Notes, entry.Folder}
+	fields = append(fields, entry.Tags...)
+	for key, value := range entry.Custom {
+		fields = append(fields, key, value)
+	}
+
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+
+	return false
+}
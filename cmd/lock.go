@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var lockCmd = &cobra.Command{
+	Use:   "lock",
+	Short: "Lock the vault immediately",
+	Long: `Lock the vault by wiping the key held by the background agent.
+The master password will be required again for the next command.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runLock(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lockCmd)
+}
+
+func runLock(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	vault.ClearSession()
+
+	err := agent.NewClient(cfg.AgentSocket).Lock()
+	if errors.Is(err, agent.ErrNotRunning) {
+		display.Info("Agent is not running, vault is already locked")
+		return
+	}
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to lock vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success("Vault locked")
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var passwdCmd = &cobra.Command{
+	Use:   "passwd",
+	Short: "Change the master password",
+	Long: `Change the master password and re-encrypt the vault with a new key.
+A fresh salt is generated and the vault file is replaced atomically. Backups
+are re-encrypted with the new key as well; backups made under a different
+password are deleted, and any that cannot be read are kept and reported. The
+background agent is locked afterwards because its key is no longer valid.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPasswd(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(passwdCmd)
+}
+
+func runPasswd(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	if !input.CheckTTY() {
+		display.Error("Changing the master password requires an interactive terminal")
+		os.Exit(1)
+	}
+
+	display.Title("Change Master Password")
+
+	oldPassword, err := input.PromptMasterPassword("Current master password: ")
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to read password: %v", err))
+		os.Exit(1)
+	}
+
+	newPassword, err := input.PromptMasterPassword("New master password: ")
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to read password: %v", err))
+		os.Exit(1)
+	}
+
+	if len(newPassword) < minMasterPasswordLength {
+		display.Error(fmt.Sprintf("Master password must be at least %d characters", minMasterPasswordLength))
+		os.Exit(1)
+	}
+
+	if newPassword == oldPassword {
+		display.Error("New master password must be different from the current one")
+		os.Exit(1)
+	}
+
+	if err := input.PromptConfirmPassword(newPassword); err != nil {
+		display.Error(err.Error())
+		os.Exit(1)
+	}
+
+	err = vault.Rekey(cfg.VaultPath, oldPassword, newPassword)
+	backupsRekeyed := err == nil
+	if err != nil && !errors.Is(err, vault.ErrBackupsNotRekeyed) {
+		display.Error(fmt.Sprintf("Failed to change master password: %v", err))
+		os.Exit(1)
+	}
+
+	// Any key held by the agent belongs to the old password
+	vault.ClearSession()
+	if err := agent.NewClient(cfg.AgentSocket).Lock(); err != nil && !errors.Is(err, agent.ErrNotRunning) {
+		display.Warning(fmt.Sprintf("Failed to lock agent: %v", err))
+	}
+
+	display.Success("Master password changed successfully")
+	if backupsRekeyed {
+		display.Info("Backups were re-encrypted with the new master password")
+	} else {
+		display.Warning(fmt.Sprintf("%v. Use 'gopassman backup list' to check them", err))
+	}
+	// Git history cannot be rewritten like the backups
+	if _, err := os.Stat(cfg.SyncDir); err == nil {
+		display.Warning("Earlier versions in the sync history are still encrypted with the old master password")
+	}
+}
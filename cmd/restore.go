@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore <entry-id-or-number> --version N",
+	Short: "Roll an entry field back to a previous version",
+	Long: `Roll a field of an entry back to a previous version from 'gopassman history'.
+Only the password is restored unless --field names another field, or 'all'.
+The values being replaced are kept as a new history version, so a restore can be undone.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runRestore(cmd, args)
+	},
+}
+
+var (
+	restoreVersion int
+	restoreField   string
+)
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().IntVarP(&restoreVersion, "version", "v", 0, "Version number from the history command")
+	restoreCmd.Flags().StringVar(&restoreField, "field", "password",
+		fmt.Sprintf("Field to restore (%s, or all)", strings.Join(models.HistoryFields, ", ")))
+	restoreCmd.MarkFlagRequired("version")
+}
+
+func runRestore(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	fields := []string{restoreField}
+	if restoreField == "all" {
+		fields = models.HistoryFields
+	} else if !slices.Contains(models.HistoryFields, restoreField) {
+		display.Error(fmt.Sprintf("Unknown field '%s'. Use one of: %s, all", restoreField, strings.Join(models.HistoryFields, ", ")))
+		os.Exit(1)
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	// Find the entry and the version to restore
+	entry := resolveEntry(session, args[0])
+	version, err := entry.HistoryVersion(restoreVersion)
+	if err != nil {
+		display.Error(fmt.Sprintf("%v. Use 'gopassman history' to see versions", err))
+		os.Exit(1)
+	}
+
+	changed := entry.Restore(version, fields, cfg.HistoryLimit)
+	if len(changed) == 0 {
+		display.Info(fmt.Sprintf("Entry '%s' already matches version %d", entry.Title, restoreVersion))
+		return
+	}
+
+	// A breach check result only applies to the password that was checked
+	if slices.Contains(changed, "password") {
+		entry.Compromised = nil
+	}
+
+	if err := session.UpdateEntry(entry); err != nil {
+		display.Error(fmt.Sprintf("Failed to update entry: %v", err))
+		os.Exit(1)
+	}
+
+	// Save vault
+	if err := vault.SaveCurrentSession(); err != nil {
+		display.Error(fmt.Sprintf("Failed to save vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Restored %s of '%s' from version %d", strings.Join(changed, ", "), entry.Title, restoreVersion))
+	if cfg.HistoryLimit > 0 {
+		display.Info("The replaced values were kept as version 1")
+	}
+}
@@ -3,8 +3,15 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/generator"
+	"github.com/egemengunel/Go-Password-Manager/vault"
 )
 
 var rootCmd = &cobra.Command{
@@ -12,6 +19,79 @@ var rootCmd = &cobra.Command{
 	Short: "A secure CLI password manager",
 	Long: `Go Password Manager is a secure command-line interface for managing your passwords.
 It uses strong encryption (AES-GCM) and secure key derivation (Argon2id) to protect your data.`,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cfg, err := config.Load()
+		// The config commands must still work to fix a broken setting
+		if err != nil && cmd.Parent() != configCmd {
+			display.Error(fmt.Sprintf("Invalid configuration: %v", err))
+			display.Info("Use 'gopassman config set <key> <value>' or edit " + config.FilePath(cfg.ConfigDir) + " to fix it")
+			os.Exit(1)
+		}
+		checkVaultSelection(cmd, cfg)
+		applyConfig(cfg)
+	},
+}
+
+// applyConfig pushes configuration settings into the packages that use them
+func applyConfig(cfg *config.Config) {
+	vault.MaxBackups = cfg.BackupCount
+	vault.TrashRetention = time.Duration(cfg.TrashDays) * 24 * time.Hour
+	display.ActiveVault = cfg.Vault
+
+	vault.DefaultKDF.Memory = uint32(cfg.KDF.Memory) * 1024
+	vault.DefaultKDF.Iterations = uint32(cfg.KDF.Iterations)
+	vault.DefaultKDF.Parallelism = uint8(cfg.KDF.Parallelism)
+
+	g := cfg.Generator
+	generator.Defaults = generator.PasswordOptions{
+		Length:           g.Length,
+		IncludeLower:     g.Lowercase,
+		IncludeUpper:     g.Uppercase,
+		IncludeNumbers:   g.Numbers,
+		IncludeSymbols:   g.Symbols,
+		ExcludeAmbiguous: g.ExcludeAmbiguous,
+		MinLower:         minOne(g.Lowercase),
+		MinUpper:         minOne(g.Uppercase),
+		MinNumbers:       minOne(g.Numbers),
+		MinSymbols:       minOne(g.Symbols),
+	}
+
+	switch cfg.Color {
+	case "always":
+		color.NoColor = false
+	case "never":
+		color.NoColor = true
+	}
+}
+
+// minOne returns how many characters of a class generated passwords must
+// contain: one if the class is included
+func minOne(included bool) int {
+	if included {
+		return 1
+	}
+	return 0
+}
+
+// checkVaultSelection exits if the vault registry cannot be read or the
+// selected vault is unknown. Commands that create vaults may select new names.
+func checkVaultSelection(cmd *cobra.Command, cfg *config.Config) {
+	if err := config.ValidateVaultName(cfg.Vault); err != nil {
+		display.Error(fmt.Sprintf("Failed to select vault: %v", err))
+		os.Exit(1)
+	}
+
+	vaults, err := config.LoadVaults(cfg.ConfigDir)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to load vaults: %v", err))
+		os.Exit(1)
+	}
+
+	if _, registered := vaults.Locations[cfg.Vault]; registered || cmd == initCmd || cmd == vaultCmd || cmd.Parent() == vaultCmd {
+		return
+	}
+	display.Error(fmt.Sprintf("Vault '%s' not found. Use 'gopassman vault list' to see vaults or 'gopassman vault create %s' to create it", cfg.Vault, cfg.Vault))
+	os.Exit(1)
 }
 
 func Execute() {
@@ -22,6 +102,6 @@ func Execute() {
 }
 
 func init() {
-	// Global flags can be added here
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gopassman.yaml)")
+	rootCmd.PersistentFlags().StringVar(&config.File, "config", "", "Config file (default is config.yaml in the config directory)")
+	rootCmd.PersistentFlags().StringVar(&config.SelectedVault, "vault", "", "Vault to use instead of the current one (or set GOPASSMAN_VAULT)")
 }
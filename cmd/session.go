@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+// openSession returns an unlocked session for the configured vault.
+// It reuses the in-process session, then asks the unlock agent for the key,
+// and finally falls back to prompting for the master password.
+func openSession(cfg *config.Config) *vault.Session {
+	// Try to use existing session first
+	if session := vault.GetSession(); session != nil {
+		return session
+	}
+
+	// Ask the agent for the key if it holds one for this vault
+	if key, err := agent.NewClient(cfg.AgentSocket).Key(cfg.VaultPath); err == nil {
+		session, err := vault.OpenSessionWithKey(key, cfg.VaultPath)
+		if err == nil {
+			vault.ActivateSession(session)
+			return vault.GetSession()
+		}
+		display.Warning(fmt.Sprintf("Agent key could not open the vault: %v", err))
+	}
+
+	// No active session, need to open vault
+	if !input.CheckTTY() {
+		display.Error("No active session. Run 'gopassman unlock' or use interactive mode")
+		os.Exit(1)
+	}
+
+	// Prompt for master password
+	masterPassword, err := input.PromptMasterPassword("Enter master password: ")
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to read password: %v", err))
+		os.Exit(1)
+	}
+
+	// Open vault
+	session, err := vault.UnlockVault(masterPassword, cfg.VaultPath)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to open vault: %v", err))
+		os.Exit(1)
+	}
+
+	// Start session
+	vault.ActivateSession(session)
+	return vault.GetSession()
+}
+
+// resolveEntry finds an entry by full ID, list number or unambiguous ID prefix
+func resolveEntry(session *vault.Session, identifier string) *models.Entry {
+	// Try to find entry by exact ID first
+	if entry, err := session.GetEntry(identifier); err == nil {
+		return entry
+	}
+
+	// Then by its number from the list command
+	if num, err := strconv.Atoi(identifier); err == nil {
+		entries := session.ListEntries()
+		if num > 0 && num <= len(entries) {
+			// Same order as the list command
+			display.SortEntries(entries)
+			return entries[num-1]
+		}
+	}
+
+	// Finally by ID prefix
+	entry, err := session.FindEntry(identifier)
+	if errors.Is(err, vault.ErrAmbiguousID) {
+		display.Error(fmt.Sprintf("%v. Use a longer ID prefix", err))
+		os.Exit(1)
+	}
+	if err != nil {
+		display.Error(fmt.Sprintf("Entry '%s' not found. Use 'gopassman list' to see available entries", identifier))
+		os.Exit(1)
+	}
+
+	return entry
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/storage"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the vault is unlocked",
+	Long:  `Show the state of the background agent and the vault it holds unlocked.`,
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runStatus(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
+
+func runStatus(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	display.Title("Agent Status")
+
+	resp, err := agent.NewClient(cfg.AgentSocket).Status()
+	if errors.Is(err, agent.ErrNotRunning) {
+		fmt.Printf("Agent:      not running\n")
+		fmt.Printf("Vault:      locked\n")
+		return
+	}
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to query agent: %v", err))
+		os.Exit(1)
+	}
+
+	fmt.Printf("Agent:      running (pid %d)\n", resp.PID)
+	fmt.Printf("Socket:     %s\n", cfg.AgentSocket)
+
+	if !resp.Unlocked {
+		fmt.Printf("Vault:      locked\n")
+		return
+	}
+
+	fmt.Printf("Vault:      unlocked\n")
+	fmt.Printf("Path:       %s\n", storage.Redact(resp.VaultPath))
+	fmt.Printf("Locks in:   %s\n", time.Until(resp.ExpiresAt).Round(time.Second))
+}
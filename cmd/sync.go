@@ -0,0 +1,449 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/crypto"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/gitsync"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/storage"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Synchronize the vault through a git remote",
+	Long: `Commit the vault to a local git repository and exchange it with a remote.
+
+The first sync needs the remote's URL, any URL git understands works:
+  gopassman sync --remote git@example.com:me/vault.git
+  gopassman sync --remote /mnt/backup/vault.git
+
+On a device without a vault, sync with --remote clones the vault from the
+remote. It opens with the master password used on the other devices.
+
+When both sides changed the vault, the local, remote and common versions are
+decrypted and merged entry by entry. Entries changed on both sides are shown
+for you to choose which version to keep, or resolved with --prefer.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runSync(cmd, args)
+	},
+}
+
+var (
+	syncRemote string
+	syncPrefer string
+)
+
+// Conflict resolutions for --prefer
+const (
+	preferLocal  = "local"
+	preferRemote = "remote"
+	preferNewer  = "newer"
+)
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.Flags().StringVar(&syncRemote, "remote", "", "Set the git remote to synchronize with")
+	syncCmd.Flags().StringVar(&syncPrefer, "prefer", "", "Resolve conflicts without asking (local, remote, newer)")
+}
+
+func runSync(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Every failure ends up here, so deferred cleanup like wiping prompted
+	// keys runs before the process exits
+	if err := syncVault(cfg); err != nil {
+		display.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// syncVault exchanges the vault with the configured remote
+func syncVault(cfg *config.Config) error {
+	switch syncPrefer {
+	case "", preferLocal, preferRemote, preferNewer:
+	default:
+		return fmt.Errorf("Unsupported --prefer %q (supported: local, remote, newer)", syncPrefer)
+	}
+
+	repo, err := gitsync.Open(cfg.SyncDir)
+	if err != nil {
+		return fmt.Errorf("Failed to open sync repository: %w", err)
+	}
+	if syncRemote != "" {
+		if err := repo.SetRemote(syncRemote); err != nil {
+			return fmt.Errorf("Failed to set remote: %w", err)
+		}
+	}
+	remote := repo.Remote()
+	if remote == "" {
+		return errors.New("No remote configured. Use 'gopassman sync --remote <url>' to set one")
+	}
+	_, fileName, err := storage.Open(cfg.VaultPath)
+	if err != nil {
+		return fmt.Errorf("Invalid vault location: %w", err)
+	}
+
+	// Without a local vault, clone the remote one
+	if !vault.VaultExists(cfg.VaultPath) {
+		return cloneVault(repo, cfg, fileName)
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	// Commit local changes
+	localData, err := vault.ReadVaultData(cfg.VaultPath)
+	if err != nil {
+		return fmt.Errorf("Failed to read vault: %w", err)
+	}
+	hostname, _ := os.Hostname()
+	if _, err := repo.Commit(fileName, localData, fmt.Sprintf("Update vault from %s", hostname)); err != nil {
+		return fmt.Errorf("Failed to commit vault: %w", err)
+	}
+
+	exists, err := repo.Fetch()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch from %s: %w", remote, err)
+	}
+	head, remoteHead := repo.Head(), repo.RemoteHead()
+
+	switch {
+	case exists && head == remoteHead:
+		display.Success("Vault is up to date")
+		return nil
+
+	case !exists || repo.IsAncestor(remoteHead, head):
+		if err := pushVault(repo, remote); err != nil {
+			return err
+		}
+		display.Success(fmt.Sprintf("Pushed local changes to %s", remote))
+		return nil
+	}
+
+	keys := &syncKeys{keys: []syncKey{{kdf: session.KDF, key: session.EncryptionKey}}}
+	defer keys.close()
+
+	remoteData, err := repo.ReadFile(remoteHead, fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to read remote vault: %w", err)
+	}
+	remoteVault, remoteKey, err := keys.open(remoteData, "remote")
+	if err != nil {
+		return err
+	}
+
+	var resultKey syncKey
+	if repo.IsAncestor(head, remoteHead) {
+		// Only the remote changed: take its file as is
+		resultKey = remoteKey
+		showSyncChanges(session.Vault, remoteVault)
+		if err := installSyncedVault(cfg, localData, remoteData); err != nil {
+			return err
+		}
+		if err := repo.FastForward(remoteHead); err != nil {
+			return fmt.Errorf("Failed to update sync repository: %w", err)
+		}
+	} else {
+		var result []byte
+		result, resultKey, err = mergeSyncedVaults(repo, keys, session, remoteVault, remoteKey, head, remoteHead, fileName)
+		if err != nil {
+			return err
+		}
+		if err := installSyncedVault(cfg, localData, result); err != nil {
+			return err
+		}
+		if err := repo.CommitMerge(remoteHead, fileName, result, fmt.Sprintf("Merge vault changes on %s", hostname)); err != nil {
+			return fmt.Errorf("Failed to commit merged vault: %w", err)
+		}
+		if err := pushVault(repo, remote); err != nil {
+			return err
+		}
+	}
+
+	// The unlocked session and any agent key belong to the replaced file
+	vault.ClearSession()
+	if !resultKey.kdf.Equal(session.KDF) {
+		if err := agent.NewClient(cfg.AgentSocket).Lock(); err != nil && !errors.Is(err, agent.ErrNotRunning) {
+			display.Warning(fmt.Sprintf("Failed to lock agent: %v", err))
+		}
+		display.Info("The vault now uses the master password of the remote vault")
+	}
+	display.Success(fmt.Sprintf("Vault synchronized with %s", remote))
+	return nil
+}
+
+// cloneVault installs the remote vault on a device without one
+func cloneVault(repo *gitsync.Repo, cfg *config.Config, fileName string) error {
+	exists, err := repo.Fetch()
+	if err != nil {
+		return fmt.Errorf("Failed to fetch from %s: %w", repo.Remote(), err)
+	}
+	if !exists {
+		return errors.New("No vault found locally or on the remote. Please run 'gopassman init' first")
+	}
+
+	data, err := repo.ReadFile(repo.RemoteHead(), fileName)
+	if err != nil {
+		return fmt.Errorf("Failed to read remote vault: %w", err)
+	}
+	if err := vault.InstallVaultFile(cfg.VaultPath, nil, data); err != nil {
+		return fmt.Errorf("Failed to install vault: %w", err)
+	}
+	if err := repo.FastForward(repo.RemoteHead()); err != nil {
+		return fmt.Errorf("Failed to update sync repository: %w", err)
+	}
+
+	display.Success(fmt.Sprintf("Vault cloned from %s", repo.Remote()))
+	display.Info("Unlock it with the master password used on your other devices")
+	return nil
+}
+
+// mergeSyncedVaults merges the local and remote vaults with their common
+// version and returns the encrypted result. Key changes merge like entries:
+// a master password changed locally wins, otherwise the remote's key is kept.
+func mergeSyncedVaults(repo *gitsync.Repo, keys *syncKeys, session *vault.Session, remoteVault *models.Vault, remoteKey syncKey, head, remoteHead, fileName string) ([]byte, syncKey, error) {
+	// Unrelated histories merge against an empty vault, keeping every entry
+	base := &models.Vault{}
+	var baseKDF *vault.KDFParams
+	if baseCommit := repo.MergeBase(head, remoteHead); baseCommit != "" {
+		baseData, err := repo.ReadFile(baseCommit, fileName)
+		if err != nil {
+			return nil, syncKey{}, fmt.Errorf("Failed to read common vault version: %w", err)
+		}
+		var baseKey syncKey
+		base, baseKey, err = keys.open(baseData, "previously synchronized")
+		if err != nil {
+			return nil, syncKey{}, err
+		}
+		baseKDF = baseKey.kdf
+	}
+
+	merged, conflicts := vault.MergeVaults(base, session.Vault, remoteVault)
+	if err := resolveSyncConflicts(merged, conflicts, base, session.Vault); err != nil {
+		return nil, syncKey{}, err
+	}
+
+	resultKey := remoteKey
+	if baseKDF != nil && !session.KDF.Equal(baseKDF) {
+		resultKey = syncKey{kdf: session.KDF, key: session.EncryptionKey}
+	}
+
+	merged.Revision = max(session.Vault.Revision, remoteVault.Revision) + 1
+	showSyncChanges(session.Vault, merged)
+	data, err := vault.EncodeVault(merged, resultKey.key, resultKey.kdf)
+	if err != nil {
+		return nil, syncKey{}, fmt.Errorf("Failed to encrypt merged vault: %w", err)
+	}
+	return data, resultKey, nil
+}
+
+// resolveSyncConflicts applies a choice for every entry changed on both
+// sides. merged holds the remote version of each conflict.
+func resolveSyncConflicts(merged *models.Vault, conflicts []vault.Conflict, base, local *models.Vault) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	sort.Slice(conflicts, func(i, j int) bool {
+		return conflicts[i].Title() < conflicts[j].Title()
+	})
+
+	if syncPrefer == "" && !input.CheckTTY() {
+		titles := make([]string, len(conflicts))
+		for i, conflict := range conflicts {
+			titles[i] = fmt.Sprintf("'%s'", conflict.Title())
+		}
+		return fmt.Errorf("Entries changed on both sides: %s. Run sync in a terminal or use --prefer", strings.Join(titles, ", "))
+	}
+
+	for _, conflict := range conflicts {
+		keepLocal, err := resolveSyncConflict(conflict, base.Entries[conflict.ID])
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case keepLocal == nil:
+			// Keep both: the local version becomes a separate entry
+			local := *conflict.Local
+			local.ID = models.NewID()
+			local.Title += " (local copy)"
+			merged.Entries[local.ID] = &local
+		case *keepLocal && conflict.Local != nil:
+			merged.Entries[conflict.ID] = conflict.Local
+			delete(merged.Trash, conflict.ID)
+		case *keepLocal:
+			// Keep the local deletion, with the entry back in the trash if it was trashed
+			delete(merged.Entries, conflict.ID)
+			if trashed, exists := local.Trash[conflict.ID]; exists {
+				merged.Trash[conflict.ID] = trashed
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSyncConflict decides which version of a conflicting entry to keep.
+// It returns true for local, false for remote and nil to keep both.
+func resolveSyncConflict(conflict vault.Conflict, base *models.Entry) (*bool, error) {
+	local, remote := true, false
+
+	newerLocal := conflict.Remote == nil ||
+		(conflict.Local != nil && conflict.Local.UpdatedAt.After(conflict.Remote.UpdatedAt))
+	switch syncPrefer {
+	case preferLocal:
+		return &local, nil
+	case preferRemote:
+		return &remote, nil
+	case preferNewer:
+		if newerLocal {
+			return &local, nil
+		}
+		return &remote, nil
+	}
+
+	fmt.Println()
+	display.ShowSyncConflict(conflict.Local, conflict.Remote, base)
+
+	keepLocal := fmt.Sprintf("Keep local (%s)", display.SyncChange(conflict.Local, base))
+	keepRemote := fmt.Sprintf("Keep remote (%s)", display.SyncChange(conflict.Remote, base))
+	keepBoth := "Keep both"
+	options := []string{keepLocal, keepRemote}
+	if !newerLocal {
+		options = []string{keepRemote, keepLocal}
+	}
+	if conflict.Local != nil && conflict.Remote != nil {
+		options = append(options, keepBoth)
+	}
+
+	choice, err := input.PromptSelect("Which version do you want to keep?", options)
+	if err != nil {
+		return nil, errors.New("Sync cancelled, nothing was changed")
+	}
+
+	switch choice {
+	case keepLocal:
+		return &local, nil
+	case keepRemote:
+		return &remote, nil
+	default:
+		return nil, nil
+	}
+}
+
+// installSyncedVault replaces the local vault file with the synchronized one
+func installSyncedVault(cfg *config.Config, localData, data []byte) error {
+	if err := vault.InstallVaultFile(cfg.VaultPath, localData, data); err != nil {
+		if errors.Is(err, vault.ErrVaultChanged) {
+			return errors.New("The vault changed during sync. Please run 'gopassman sync' again")
+		}
+		return fmt.Errorf("Failed to save vault: %w", err)
+	}
+	return nil
+}
+
+// pushVault uploads the local branch to the remote
+func pushVault(repo *gitsync.Repo, remote string) error {
+	if err := repo.Push(); err != nil {
+		return fmt.Errorf("Failed to push to %s: %w. Run 'gopassman sync' again to merge", remote, err)
+	}
+	return nil
+}
+
+// showSyncChanges reports the entries a sync adds, changes or removes locally
+func showSyncChanges(before, after *models.Vault) {
+	added, changed, removed := 0, 0, 0
+	for id, entry := range after.Entries {
+		previous, exists := before.Entries[id]
+		switch {
+		case !exists:
+			added++
+		case !previous.UpdatedAt.Equal(entry.UpdatedAt):
+			changed++
+		}
+	}
+	for id := range before.Entries {
+		if _, exists := after.Entries[id]; !exists {
+			removed++
+		}
+	}
+
+	if added+changed+removed > 0 {
+		display.Info(fmt.Sprintf("Received %d new, %d changed and %d removed entries", added, changed, removed))
+	}
+}
+
+// syncKey is a vault key and the parameters it was derived with
+type syncKey struct {
+	kdf *vault.KDFParams
+	key []byte
+}
+
+// syncKeys holds the keys of the vault versions met during a sync. Versions
+// encrypted with another master password, after it was changed on one side,
+// ask for that password.
+type syncKeys struct {
+	keys     []syncKey
+	prompted [][]byte
+}
+
+// open decrypts serialized vault file data
+func (k *syncKeys) open(data []byte, which string) (*models.Vault, syncKey, error) {
+	file, err := vault.ParseVaultFile(data)
+	if err != nil {
+		return nil, syncKey{}, fmt.Errorf("Failed to read the %s vault: %w", which, err)
+	}
+
+	kdf := file.KeyParams()
+	for _, known := range k.keys {
+		if known.kdf.Equal(kdf) {
+			v, err := file.Decrypt(known.key)
+			if err != nil {
+				return nil, syncKey{}, fmt.Errorf("Failed to open the %s vault: %w", which, err)
+			}
+			return v, known, nil
+		}
+	}
+
+	if !input.CheckTTY() {
+		return nil, syncKey{}, fmt.Errorf("The %s vault uses another master password. Run sync in a terminal", which)
+	}
+	password, err := input.PromptMasterPassword(fmt.Sprintf("Master password of the %s vault: ", which))
+	if err != nil {
+		return nil, syncKey{}, fmt.Errorf("Failed to read password: %w", err)
+	}
+	key, err := kdf.DeriveKey(password)
+	if err != nil {
+		return nil, syncKey{}, fmt.Errorf("Failed to derive key: %w", err)
+	}
+	v, err := file.Decrypt(key)
+	if err != nil {
+		crypto.SecureZero(key)
+		return nil, syncKey{}, fmt.Errorf("Failed to open the %s vault: %w", which, err)
+	}
+
+	known := syncKey{kdf: kdf, key: key}
+	k.keys = append(k.keys, known)
+	k.prompted = append(k.prompted, key)
+	return v, known, nil
+}
+
+// close wipes the keys derived from prompted passwords
+func (k *syncKeys) close() {
+	for _, key := range k.prompted {
+		crypto.SecureZero(key)
+	}
+}
@@ -0,0 +1,207 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "Manage deleted entries",
+	Long: `Manage the entries deleted with 'gopassman delete'.
+Deleted entries stay in the trash inside the encrypted vault until they are
+restored or purged. Entries older than the configured number of days are
+purged automatically the next time the vault is saved.`,
+}
+
+var trashListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List deleted entries",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runTrashList(cmd, args)
+	},
+}
+
+var trashRestoreCmd = &cobra.Command{
+	Use:   "restore <entry-id-or-number>",
+	Short: "Move a deleted entry back into the vault",
+	Long: `Move a deleted entry back into the vault.
+You can specify the entry by ID, a unique ID prefix, or its number from 'gopassman trash list'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTrashRestore(cmd, args)
+	},
+}
+
+var trashPurgeCmd = &cobra.Command{
+	Use:   "purge [entry-id-or-number]",
+	Short: "Permanently remove deleted entries",
+	Long: `Permanently remove one entry from the trash, or every entry if none is given.
+This action cannot be undone.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runTrashPurge(cmd, args)
+	},
+}
+
+var trashForce bool
+
+func init() {
+	rootCmd.AddCommand(trashCmd)
+	trashCmd.AddCommand(trashListCmd)
+	trashCmd.AddCommand(trashRestoreCmd)
+	trashCmd.AddCommand(trashPurgeCmd)
+	trashPurgeCmd.Flags().BoolVarP(&trashForce, "force", "f", false, "Purge without confirmation")
+}
+
+func runTrashList(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	display.Title("Trash")
+	display.ListTrash(sortedTrash(session), vault.TrashRetention)
+}
+
+func runTrashRestore(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	item := resolveTrashedEntry(session, args[0])
+	if err := session.RestoreEntry(item.Entry.ID); err != nil {
+		display.Error(fmt.Sprintf("Failed to restore entry: %v", err))
+		os.Exit(1)
+	}
+
+	// Save vault
+	if err := vault.SaveCurrentSession(); err != nil {
+		display.Error(fmt.Sprintf("Failed to save vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Entry '%s' restored from the trash", item.Entry.Title))
+}
+
+func runTrashPurge(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	// Unlock the vault
+	session := openSession(cfg)
+
+	var item *models.TrashedEntry
+	prompt := "Permanently remove every entry in the trash?"
+	if len(args) == 1 {
+		item = resolveTrashedEntry(session, args[0])
+		prompt = fmt.Sprintf("Permanently remove '%s'?", item.Entry.Title)
+	} else if len(session.ListTrash()) == 0 {
+		display.Info("The trash is empty")
+		return
+	}
+
+	// Confirm purge unless forced
+	if !trashForce {
+		if !input.CheckTTY() {
+			display.Error("Purging requires confirmation. Use --force to bypass or run in interactive mode")
+			os.Exit(1)
+		}
+
+		display.Warning("This action cannot be undone!")
+		confirmed, err := input.PromptConfirm(prompt, false)
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to get confirmation: %v", err))
+			os.Exit(1)
+		}
+
+		if !confirmed {
+			display.Info("Purge cancelled")
+			return
+		}
+	}
+
+	count := 1
+	if item != nil {
+		if err := session.PurgeEntry(item.Entry.ID); err != nil {
+			display.Error(fmt.Sprintf("Failed to purge entry: %v", err))
+			os.Exit(1)
+		}
+	} else {
+		count = session.EmptyTrash()
+	}
+
+	// Save vault
+	if err := vault.SaveCurrentSession(); err != nil {
+		display.Error(fmt.Sprintf("Failed to save vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Permanently removed %d entries from the trash", count))
+}
+
+// sortedTrash returns the trashed entries, most recently deleted first.
+// Numbers shown by 'trash list' refer to this order.
+func sortedTrash(session *vault.Session) []*models.TrashedEntry {
+	items := session.ListTrash()
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].DeletedAt.After(items[j].DeletedAt)
+	})
+	return items
+}
+
+// resolveTrashedEntry finds a trashed entry by ID, ID prefix or its number
+// from 'trash list', exiting with an error if there is no match
+func resolveTrashedEntry(session *vault.Session, identifier string) *models.TrashedEntry {
+	// Try its number from the trash list first; IDs are never plain numbers
+	if num, err := strconv.Atoi(identifier); err == nil {
+		items := sortedTrash(session)
+		if num > 0 && num <= len(items) {
+			return items[num-1]
+		}
+	}
+
+	item, err := session.FindTrashedEntry(identifier)
+	if errors.Is(err, vault.ErrAmbiguousID) {
+		display.Error(fmt.Sprintf("%v. Use a longer ID prefix", err))
+		os.Exit(1)
+	}
+	if err != nil {
+		display.Error(fmt.Sprintf("Entry '%s' not found in the trash. Use 'gopassman trash list' to see deleted entries", identifier))
+		os.Exit(1)
+	}
+	return item
+}
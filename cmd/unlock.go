@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var unlockCmd = &cobra.Command{
+	Use:   "unlock",
+	Short: "Unlock the vault for the session timeout",
+	Long: `Unlock the vault and keep it unlocked in the background agent.
+Other commands will not ask for the master password until the session times out
+or 'gopassman lock' is run. The agent is started if it is not already running.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runUnlock(cmd, args)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(unlockCmd)
+}
+
+func runUnlock(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+
+	// Check if vault exists
+	if !vault.VaultExists(cfg.VaultPath) {
+		display.Error("No vault found. Please run 'gopassman init' first")
+		os.Exit(1)
+	}
+
+	if !input.CheckTTY() {
+		display.Error("Unlocking requires an interactive terminal")
+		os.Exit(1)
+	}
+
+	client := agent.NewClient(cfg.AgentSocket)
+
+	// Start the agent if needed
+	if !client.Running() {
+		if err := agent.Spawn(cfg.AgentSocket, cfg.SessionTimeout); err != nil {
+			display.Error(fmt.Sprintf("Failed to start agent: %v", err))
+			os.Exit(1)
+		}
+	}
+
+	// Prompt for master password
+	masterPassword, err := input.PromptMasterPassword("Enter master password: ")
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to read password: %v", err))
+		os.Exit(1)
+	}
+
+	resp, err := client.Unlock(cfg.VaultPath, masterPassword)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to unlock vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success("Vault unlocked")
+	display.Info(fmt.Sprintf("Locks automatically after inactivity (at %s if unused)", resp.ExpiresAt.Format("15:04:05")))
+}
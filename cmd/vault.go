@@ -0,0 +1,359 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/egemengunel/Go-Password-Manager/config"
+	"github.com/egemengunel/Go-Password-Manager/internal/agent"
+	"github.com/egemengunel/Go-Password-Manager/internal/display"
+	"github.com/egemengunel/Go-Password-Manager/internal/input"
+	"github.com/egemengunel/Go-Password-Manager/storage"
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+var vaultCmd = &cobra.Command{
+	Use:   "vault",
+	Short: "Manage named vaults",
+	Long: `Manage several named vaults, such as personal, team and client ones.
+Every command works on the current vault, chosen with 'gopassman vault use'.
+The --vault flag or the GOPASSMAN_VAULT environment variable select another
+one for a single command or shell. Each vault has its own master password,
+backups and sync remote.`,
+}
+
+var vaultCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a new named vault",
+	Long: `Create a new named vault with its own master password.
+By default it is stored in the config directory; --location puts it at another
+path or a storage URL such as s3://bucket/team.gpv. If a vault already exists
+at the location, for example one shared by a team, it is added without
+creating a new one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runVaultCreate(cmd, args)
+	},
+}
+
+var vaultListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List named vaults",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runVaultList(cmd, args)
+	},
+}
+
+var vaultUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Switch the current vault",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runVaultUse(cmd, args)
+	},
+}
+
+var vaultRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Forget a named vault",
+	Long: `Remove a vault from the list of named vaults.
+The vault file and its backups are kept unless --delete is given, which
+removes them and the vault's sync repository permanently. Without --delete
+the sync repository is moved to the detached directory in the config
+directory, so a new vault with the same name starts with a fresh one.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runVaultRemove(cmd, args)
+	},
+}
+
+var vaultRenameCmd = &cobra.Command{
+	Use:   "rename <name> <new-name>",
+	Short: "Rename a named vault",
+	Long: `Rename a vault. The vault file stays where it is; only the name used
+to select it changes.`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runVaultRename(cmd, args)
+	},
+}
+
+var (
+	vaultCreateLocation string
+	vaultRemoveDelete   bool
+	vaultRemoveForce    bool
+)
+
+func init() {
+	rootCmd.AddCommand(vaultCmd)
+	vaultCmd.AddCommand(vaultCreateCmd)
+	vaultCmd.AddCommand(vaultListCmd)
+	vaultCmd.AddCommand(vaultUseCmd)
+	vaultCmd.AddCommand(vaultRemoveCmd)
+	vaultCmd.AddCommand(vaultRenameCmd)
+
+	vaultCreateCmd.Flags().StringVarP(&vaultCreateLocation, "location", "l", "", "Path or storage URL of the vault (default: in the config directory)")
+	vaultRemoveCmd.Flags().BoolVar(&vaultRemoveDelete, "delete", false, "Also delete the vault file and its backups")
+	vaultRemoveCmd.Flags().BoolVarP(&vaultRemoveForce, "force", "f", false, "Remove without confirmation")
+}
+
+func runVaultCreate(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+	name := args[0]
+
+	if err := config.ValidateVaultName(name); err != nil {
+		display.Error(fmt.Sprintf("Failed to create vault: %v", err))
+		os.Exit(1)
+	}
+	vaults := loadVaults(cfg)
+	if _, exists := vaults.Locations[name]; exists {
+		display.Error(fmt.Sprintf("A vault named '%s' already exists", name))
+		os.Exit(1)
+	}
+
+	location := vaultCreateLocation
+	if location == "" {
+		location = config.VaultLocation(cfg.ConfigDir, name)
+	}
+	// The registry is used from any directory, so store local paths absolute
+	if !strings.Contains(location, "://") {
+		absolute, err := filepath.Abs(location)
+		if err != nil {
+			display.Error(fmt.Sprintf("Invalid vault location: %v", err))
+			os.Exit(1)
+		}
+		location = absolute
+	}
+
+	// A sync repository left behind by an earlier vault with this name belongs to
+	// another vault's history
+	if detached, err := config.DetachSyncDir(cfg.ConfigDir, name); err != nil {
+		display.Error(fmt.Sprintf("Failed to create vault: %v", err))
+		os.Exit(1)
+	} else if detached != "" {
+		display.Warning(fmt.Sprintf("Moved an old sync repository for '%s' to %s", name, detached))
+	}
+
+	// An existing vault, such as a team vault in shared storage, is only added
+	_, err := vault.StatVault(location)
+	switch {
+	case err == nil:
+		if err := registerVault(cfg, name, location); err != nil {
+			display.Error(fmt.Sprintf("Failed to register vault: %v", err))
+			os.Exit(1)
+		}
+		display.Success(fmt.Sprintf("Added existing vault '%s' at %s", name, storage.Redact(location)))
+		display.Info(fmt.Sprintf("Use 'gopassman vault use %s' to switch to it", name))
+		return
+	case !errors.Is(err, storage.ErrNotExist):
+		display.Error(fmt.Sprintf("Failed to access vault location: %v", err))
+		os.Exit(1)
+	}
+
+	if !input.CheckTTY() {
+		display.Error("Vault creation requires an interactive terminal")
+		os.Exit(1)
+	}
+
+	display.ActiveVault = name
+	display.Title("Create Vault")
+	display.Info(fmt.Sprintf("Vault location: %s", storage.Redact(location)))
+
+	createVault(cfg, location)
+
+	if err := registerVault(cfg, name, location); err != nil {
+		display.Error(fmt.Sprintf("Failed to register vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Vault '%s' created successfully", name))
+	display.Warning("Your master password cannot be recovered. Keep it safe!")
+	display.Info(fmt.Sprintf("Use 'gopassman vault use %s' to switch to it", name))
+}
+
+func runVaultList(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+	vaults := loadVaults(cfg)
+
+	display.Title("Vaults")
+
+	if len(vaults.Locations) == 0 {
+		display.Info("No vaults found. Use 'gopassman vault create <name>' to create one")
+		return
+	}
+
+	fmt.Printf("  %-20s %s\n", "Name", "Location")
+	fmt.Printf("%s\n", strings.Repeat("-", 62))
+
+	for _, name := range vaults.Names() {
+		marker := " "
+		if name == cfg.Vault {
+			marker = "*"
+		}
+
+		location, _ := cfg.Locate(vaults, name)
+		shown := storage.Redact(location)
+		if !vault.VaultExists(location) {
+			shown += " (missing)"
+		}
+		fmt.Printf("%s %-20s %s\n", marker, name, shown)
+	}
+
+	fmt.Printf("\nTotal: %d vaults (* marks the active one)\n", len(vaults.Locations))
+}
+
+func runVaultUse(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+	name := args[0]
+
+	vaults := loadVaults(cfg)
+	if err := vaults.Use(name); err != nil {
+		exitVaultNotFound(name)
+	}
+	if err := vaults.Save(cfg.ConfigDir); err != nil {
+		display.Error(fmt.Sprintf("Failed to switch vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Now using vault '%s'", name))
+	if env := os.Getenv("GOPASSMAN_VAULT"); env != "" && env != name {
+		display.Warning(fmt.Sprintf("GOPASSMAN_VAULT=%s still selects another vault in this shell", env))
+	}
+}
+
+func runVaultRemove(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+	name := args[0]
+
+	vaults := loadVaults(cfg)
+	location, exists := cfg.Locate(vaults, name)
+	if !exists {
+		exitVaultNotFound(name)
+	}
+
+	// Confirm removal unless forced
+	if !vaultRemoveForce {
+		if !input.CheckTTY() {
+			display.Error("Removal requires confirmation. Use --force to bypass or run in interactive mode")
+			os.Exit(1)
+		}
+
+		question := fmt.Sprintf("Remove vault '%s' from the list? The vault file is kept", name)
+		if vaultRemoveDelete {
+			question = fmt.Sprintf("Permanently delete vault '%s', its backups at %s and its sync history?", name, storage.Redact(location))
+		}
+		confirmed, err := input.PromptConfirm(question, false)
+		if err != nil {
+			display.Error(fmt.Sprintf("Failed to get confirmation: %v", err))
+			os.Exit(1)
+		}
+
+		if !confirmed {
+			display.Info("Removal cancelled")
+			return
+		}
+	}
+
+	if vaultRemoveDelete {
+		if err := vault.DeleteVault(location); err != nil {
+			display.Error(fmt.Sprintf("Failed to delete vault: %v", err))
+			os.Exit(1)
+		}
+
+		// The sync repository holds every version the vault ever had
+		if err := os.RemoveAll(config.VaultSyncDir(cfg.ConfigDir, name)); err != nil {
+			display.Error(fmt.Sprintf("Failed to delete sync repository: %v", err))
+			os.Exit(1)
+		}
+
+		// The agent may still hold the deleted vault's key
+		vault.ClearSession()
+		if err := agent.NewClient(cfg.AgentSocket).Lock(); err != nil && !errors.Is(err, agent.ErrNotRunning) {
+			display.Warning(fmt.Sprintf("Failed to lock agent: %v", err))
+		}
+	}
+
+	detached, err := vaults.Remove(cfg.ConfigDir, name)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to remove vault: %v", err))
+		os.Exit(1)
+	}
+	if err := vaults.Save(cfg.ConfigDir); err != nil {
+		display.Error(fmt.Sprintf("Failed to remove vault: %v", err))
+		os.Exit(1)
+	}
+
+	if vaultRemoveDelete {
+		display.Success(fmt.Sprintf("Vault '%s' deleted", name))
+	} else {
+		display.Success(fmt.Sprintf("Vault '%s' removed, its file is kept at %s", name, storage.Redact(location)))
+	}
+	if detached != "" {
+		display.Info(fmt.Sprintf("Its sync repository was moved to %s", detached))
+	}
+}
+
+func runVaultRename(cmd *cobra.Command, args []string) {
+	// Get configuration
+	cfg := config.DefaultConfig()
+	name, newName := args[0], args[1]
+
+	vaults := loadVaults(cfg)
+	switch err := vaults.Rename(cfg.ConfigDir, name, newName); {
+	case errors.Is(err, config.ErrVaultNotFound):
+		exitVaultNotFound(name)
+	case errors.Is(err, config.ErrVaultExists):
+		display.Error(fmt.Sprintf("A vault named '%s' already exists", newName))
+		os.Exit(1)
+	case err != nil:
+		display.Error(fmt.Sprintf("Failed to rename vault: %v", err))
+		os.Exit(1)
+	}
+	if err := vaults.Save(cfg.ConfigDir); err != nil {
+		display.Error(fmt.Sprintf("Failed to rename vault: %v", err))
+		os.Exit(1)
+	}
+
+	display.Success(fmt.Sprintf("Vault '%s' renamed to '%s'", name, newName))
+}
+
+// loadVaults reads the vault registry or exits
+func loadVaults(cfg *config.Config) *config.Vaults {
+	vaults, err := config.LoadVaults(cfg.ConfigDir)
+	if err != nil {
+		display.Error(fmt.Sprintf("Failed to load vaults: %v", err))
+		os.Exit(1)
+	}
+	return vaults
+}
+
+// exitVaultNotFound reports an unknown vault name and exits
+func exitVaultNotFound(name string) {
+	display.Error(fmt.Sprintf("Vault '%s' not found. Use 'gopassman vault list' to see vaults", name))
+	os.Exit(1)
+}
+
+// registerVault adds a vault to the registry unless its name is taken
+func registerVault(cfg *config.Config, name, location string) error {
+	vaults, err := config.LoadVaults(cfg.ConfigDir)
+	if err != nil {
+		return err
+	}
+	if err := vaults.Add(name, location); err != nil {
+		if errors.Is(err, config.ErrVaultExists) {
+			return nil
+		}
+		return err
+	}
+	return vaults.Save(cfg.ConfigDir)
+}
@@ -4,17 +4,94 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 )
 
 // Config holds application configuration
 type Config struct {
-	VaultPath    string
-	ConfigDir    string
-	DefaultVault string
+	Vault            string // name of the active vault
+	VaultPath        string // local path or storage URL such as s3://bucket/team.gpv, see storage.Open
+	ConfigDir        string
+	DefaultVault     string // vault used when none is selected
+	DefaultVaultPath string // location of the vault named DefaultVaultName, empty for the config directory
+	AgentSocket      string
+	SessionTimeout   time.Duration // idle time after which the agent locks the vault
+	ClipboardTimeout time.Duration // time after which copied secrets are cleared from the clipboard
+	BackupCount      int
+	HistoryLimit     int    // previous versions kept per entry
+	TrashDays        int    // days deleted entries stay in the trash, 0 keeps them until purged
+	Color            string // auto, always or never
+	Generator        GeneratorConfig
+	KDF              KDFConfig
+	CacheDir         string
+	SyncDir          string // git repository the vault is synchronized through
+	BreachAPIURL     string // Pwned Passwords compatible range API, e.g. an internal mirror
 }
 
-// DefaultConfig returns the default configuration
+// GeneratorConfig holds the default password generator options
+type GeneratorConfig struct {
+	Length           int
+	Uppercase        bool
+	Lowercase        bool
+	Numbers          bool
+	Symbols          bool
+	ExcludeAmbiguous bool
+}
+
+// KDFConfig holds the Argon2id cost new and upgraded vaults are encrypted with
+type KDFConfig struct {
+	Memory      int // MiB
+	Iterations  int
+	Parallelism int
+}
+
+// SelectedVault names the vault to use instead of the current one. It is set
+// from the --vault flag.
+var SelectedVault string
+
+// Load returns the configuration for the active vault: the defaults,
+// overridden by the config file and GOPASSMAN_* environment variables. The
+// active vault is the one named by --vault or GOPASSMAN_VAULT, else the one
+// chosen with 'vault use', else DefaultVault. Invalid settings keep their
+// defaults and are reported in the error.
+func Load() (*Config, error) {
+	cfg := defaultConfig()
+	err := cfg.loadSettings()
+
+	// An unreadable registry is reported by the root command; fall back to
+	// the default locations here
+	vaults, vaultsErr := LoadVaults(cfg.ConfigDir)
+	if vaultsErr != nil {
+		vaults = &Vaults{}
+	}
+
+	cfg.Vault = cfg.DefaultVault
+	for _, name := range []string{SelectedVault, os.Getenv("GOPASSMAN_VAULT"), vaults.Current} {
+		if name != "" {
+			cfg.Vault = name
+			break
+		}
+	}
+
+	cfg.VaultPath, _ = cfg.Locate(vaults, cfg.Vault)
+	if location := os.Getenv("GOPASSMAN_VAULT_URL"); location != "" {
+		cfg.VaultPath = location
+	}
+	cfg.SyncDir = VaultSyncDir(cfg.ConfigDir, cfg.Vault)
+
+	return cfg, err
+}
+
+// DefaultConfig returns the configuration for the active vault, see Load.
+// Errors in the settings are reported by the root command.
 func DefaultConfig() *Config {
+	cfg, _ := Load()
+	return cfg
+}
+
+// defaultConfig returns the built-in settings, before the config file and
+// environment are applied
+func defaultConfig() *Config {
 	homeDir, _ := os.UserHomeDir()
 
 	var configDir string
@@ -28,10 +105,60 @@ func DefaultConfig() *Config {
 	}
 
 	return &Config{
-		VaultPath:    filepath.Join(configDir, "vault.gpv"),
-		ConfigDir:    configDir,
-		DefaultVault: "default",
+		ConfigDir:        configDir,
+		DefaultVault:     DefaultVaultName,
+		DefaultVaultPath: VaultLocation(configDir, DefaultVaultName),
+		AgentSocket:      agentSocketPath(configDir),
+		SessionTimeout:   15 * time.Minute,
+		ClipboardTimeout: 45 * time.Second,
+		BackupCount:      5,
+		HistoryLimit:     10,
+		TrashDays:        30,
+		Color:            "auto",
+		Generator: GeneratorConfig{
+			Length:           16,
+			Uppercase:        true,
+			Lowercase:        true,
+			Numbers:          true,
+			Symbols:          true,
+			ExcludeAmbiguous: true,
+		},
+		KDF: KDFConfig{
+			Memory:      64,
+			Iterations:  3,
+			Parallelism: 4,
+		},
+		CacheDir:     cacheDir(configDir),
+		BreachAPIURL: "https://api.pwnedpasswords.com",
+	}
+}
+
+// Locate returns where the named vault is stored. The default vault follows
+// vault_path while the registry still has it at its built-in location.
+func (c *Config) Locate(vaults *Vaults, name string) (string, bool) {
+	location, registered := vaults.Location(c.ConfigDir, name)
+	if name == DefaultVaultName && location == VaultLocation(c.ConfigDir, DefaultVaultName) {
+		location = c.DefaultVaultPath
+	}
+	return location, registered
+}
+
+// agentSocketPath returns the per-user socket the unlock agent listens on.
+// XDG_RUNTIME_DIR is preferred because it is private to the user and cleared on logout.
+func agentSocketPath(configDir string) string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "gopassman", "agent.sock")
+	}
+	return filepath.Join(configDir, "agent.sock")
+}
+
+// cacheDir returns the per-user directory for data that can be downloaded
+// again, falling back to the config directory
+func cacheDir(configDir string) string {
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "gopassman")
 	}
+	return filepath.Join(configDir, "cache")
 }
 
 // EnsureConfigDir creates the configuration directory if it doesn't exist
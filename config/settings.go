@@ -0,0 +1,442 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// File is the configuration file to use instead of config.yaml in the config
+// directory. It is set from the --config flag.
+var File string
+
+// envPrefix is prepended to setting keys to form their environment variables,
+// e.g. GOPASSMAN_BACKUP_COUNT for backup_count
+const envPrefix = "GOPASSMAN"
+
+// Sources of a setting's value
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+)
+
+// SettingError reports an invalid configuration value
+type SettingError struct {
+	Key    string
+	Value  any
+	Source string
+	Err    error
+}
+
+func (e *SettingError) Error() string {
+	if e.Source == SourceEnv {
+		return fmt.Sprintf("invalid %s (%s=%v): %v", e.Key, EnvVar(e.Key), e.Value, e.Err)
+	}
+	return fmt.Sprintf("invalid %s %q: %v", e.Key, fmt.Sprint(e.Value), e.Err)
+}
+
+func (e *SettingError) Unwrap() error {
+	return e.Err
+}
+
+// Setting describes one configuration key
+type Setting struct {
+	Key         string
+	Description string
+	parse       func(raw any) (any, error) // validates a raw value and returns it typed
+	get         func(c *Config) any
+	set         func(c *Config, value any)
+}
+
+// SettingValue is the effective value of a setting and where it came from
+type SettingValue struct {
+	Setting
+	Value  string
+	Source string
+}
+
+// settings lists every configuration key in display order
+var settings = []Setting{
+	stringSetting("default_vault", "Vault used when none is selected with --vault, GOPASSMAN_VAULT or 'vault use'", ValidateVaultName,
+		func(c *Config) *string { return &c.DefaultVault }),
+	stringSetting("vault_path", "Location of the vault named 'default': an absolute path or a storage URL such as s3://bucket/team.gpv", vaultLocation,
+		func(c *Config) *string { return &c.DefaultVaultPath }),
+	durationSetting("session_timeout", "Idle time after which the agent locks the vault", time.Minute, 24*time.Hour,
+		func(c *Config) *time.Duration { return &c.SessionTimeout }),
+	durationSetting("clipboard_timeout", "Time after which copied secrets are cleared from the clipboard", 5*time.Second, 10*time.Minute,
+		func(c *Config) *time.Duration { return &c.ClipboardTimeout }),
+	intSetting("backup_count", "Encrypted backups kept next to the vault, 0 disables them", 0, 100,
+		func(c *Config) *int { return &c.BackupCount }),
+	intSetting("history_limit", "Previous versions kept per entry", 0, 1000,
+		func(c *Config) *int { return &c.HistoryLimit }),
+	intSetting("trash_days", "Days deleted entries stay in the trash, 0 keeps them until purged", 0, 36500,
+		func(c *Config) *int { return &c.TrashDays }),
+	stringSetting("color", "Colored output: auto, always or never", oneOf("auto", "always", "never"),
+		func(c *Config) *string { return &c.Color }),
+	intSetting("generator.length", "Length of generated passwords", 8, 1024,
+		func(c *Config) *int { return &c.Generator.Length }),
+	boolSetting("generator.uppercase", "Generated passwords contain uppercase letters",
+		func(c *Config) *bool { return &c.Generator.Uppercase }),
+	boolSetting("generator.lowercase", "Generated passwords contain lowercase letters",
+		func(c *Config) *bool { return &c.Generator.Lowercase }),
+	boolSetting("generator.numbers", "Generated passwords contain numbers",
+		func(c *Config) *bool { return &c.Generator.Numbers }),
+	boolSetting("generator.symbols", "Generated passwords contain symbols",
+		func(c *Config) *bool { return &c.Generator.Symbols }),
+	boolSetting("generator.exclude_ambiguous", "Generated passwords avoid ambiguous characters (0, O, 1, l, I, |)",
+		func(c *Config) *bool { return &c.Generator.ExcludeAmbiguous }),
+	intSetting("kdf.memory", "Argon2id memory in MiB; vaults with weaker settings are upgraded when unlocked", 8, 4096,
+		func(c *Config) *int { return &c.KDF.Memory }),
+	intSetting("kdf.iterations", "Argon2id iterations; vaults with fewer are upgraded when unlocked", 1, 100,
+		func(c *Config) *int { return &c.KDF.Iterations }),
+	intSetting("kdf.parallelism", "Argon2id lanes used for new vaults and upgrades", 1, 64,
+		func(c *Config) *int { return &c.KDF.Parallelism }),
+	stringSetting("cache_dir", "Directory for downloaded data such as breach check responses", absolutePath,
+		func(c *Config) *string { return &c.CacheDir }),
+	stringSetting("breach_api_url", "Pwned Passwords compatible range API used by 'breach --online'", httpURL,
+		func(c *Config) *string { return &c.BreachAPIURL }),
+}
+
+// Settings returns the configuration keys in display order
+func Settings() []Setting {
+	return settings
+}
+
+// lookupSetting finds a setting by key
+func lookupSetting(key string) (Setting, error) {
+	for _, setting := range settings {
+		if setting.Key == key {
+			return setting, nil
+		}
+	}
+	return Setting{}, fmt.Errorf("unknown config key %q", key)
+}
+
+// EnvVar returns the environment variable that overrides a setting
+func EnvVar(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// FilePath returns the configuration file in use
+func FilePath(configDir string) string {
+	if File != "" {
+		return File
+	}
+	return filepath.Join(configDir, "config.yaml")
+}
+
+// readConfigFile reads the configuration file, if there is one
+func readConfigFile(path string) (*viper.Viper, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("yaml")
+	v.SetConfigPermissions(0600)
+
+	if err := v.ReadInConfig(); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return v, nil
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// loadSettings overrides the defaults in c with the configuration file and
+// environment. Invalid values are left at their defaults and reported.
+func (c *Config) loadSettings() error {
+	file, err := readConfigFile(FilePath(c.ConfigDir))
+	if err != nil {
+		return err
+	}
+
+	v := viper.New()
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	for _, setting := range settings {
+		v.SetDefault(setting.Key, setting.get(c))
+	}
+	if err := v.MergeConfigMap(file.AllSettings()); err != nil {
+		return err
+	}
+
+	// Catch typos, which would otherwise be silently ignored
+	var errs []error
+	for _, key := range file.AllKeys() {
+		if _, err := lookupSetting(key); err != nil {
+			errs = append(errs, fmt.Errorf("%w in %s", err, FilePath(c.ConfigDir)))
+		}
+	}
+
+	for _, setting := range settings {
+		raw := v.Get(setting.Key)
+		value, err := setting.parse(raw)
+		if err != nil {
+			errs = append(errs, &SettingError{Key: setting.Key, Value: raw, Source: settingSource(file, setting.Key), Err: err})
+			continue
+		}
+		setting.set(c, value)
+	}
+	if err := c.validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// validate checks rules that involve several settings
+func (c *Config) validate() error {
+	g := c.Generator
+	if !g.Uppercase && !g.Lowercase && !g.Numbers && !g.Symbols {
+		return errors.New("invalid generator settings: enable at least one of generator.uppercase, generator.lowercase, generator.numbers or generator.symbols")
+	}
+	return nil
+}
+
+// settingSource reports where the effective value of a setting comes from
+func settingSource(file *viper.Viper, key string) string {
+	if value, set := os.LookupEnv(EnvVar(key)); set && value != "" {
+		return SourceEnv
+	}
+	if file.InConfig(key) {
+		return SourceFile
+	}
+	return SourceDefault
+}
+
+// ListSettings returns the effective value and source of every setting
+func ListSettings() ([]SettingValue, error) {
+	cfg, err := Load()
+	file, fileErr := readConfigFile(FilePath(cfg.ConfigDir))
+	if fileErr != nil {
+		return nil, fileErr
+	}
+
+	values := make([]SettingValue, 0, len(settings))
+	for _, setting := range settings {
+		values = append(values, SettingValue{
+			Setting: setting,
+			Value:   formatValue(setting.get(cfg)),
+			Source:  settingSource(file, setting.Key),
+		})
+	}
+	return values, err
+}
+
+// GetSetting returns the effective value and source of one setting
+func GetSetting(key string) (SettingValue, error) {
+	if _, err := lookupSetting(key); err != nil {
+		return SettingValue{}, err
+	}
+
+	values, err := ListSettings()
+	for _, value := range values {
+		if value.Key == key {
+			return value, err
+		}
+	}
+	return SettingValue{}, err
+}
+
+// SetSetting validates value and stores it in the configuration file
+func SetSetting(key, value string) error {
+	setting, err := lookupSetting(key)
+	if err != nil {
+		return err
+	}
+	parsed, err := setting.parse(value)
+	if err != nil {
+		return &SettingError{Key: key, Value: value, Source: SourceFile, Err: err}
+	}
+
+	cfg := defaultConfig()
+	path := FilePath(cfg.ConfigDir)
+	file, err := readConfigFile(path)
+	if err != nil {
+		return err
+	}
+	file.Set(key, storedValue(parsed))
+
+	// Check the whole file, so rules between settings hold after the change
+	for _, setting := range settings {
+		if !file.InConfig(setting.Key) && setting.Key != key {
+			continue
+		}
+		stored, err := setting.parse(file.Get(setting.Key))
+		if err != nil {
+			return &SettingError{Key: setting.Key, Value: file.Get(setting.Key), Source: SourceFile, Err: err}
+		}
+		setting.set(cfg, stored)
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := file.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// storedValue converts a parsed value to what is written to the file
+func storedValue(value any) any {
+	if duration, ok := value.(time.Duration); ok {
+		return duration.String()
+	}
+	return value
+}
+
+// formatValue formats a setting value for display
+func formatValue(value any) string {
+	if duration, ok := value.(time.Duration); ok {
+		return duration.String()
+	}
+	return fmt.Sprint(value)
+}
+
+func stringSetting(key, description string, validate func(string) error, field func(*Config) *string) Setting {
+	return Setting{
+		Key:         key,
+		Description: description,
+		parse: func(raw any) (any, error) {
+			value, ok := raw.(string)
+			if !ok {
+				return nil, errors.New("must be text")
+			}
+			value = strings.TrimSpace(value)
+			if err := validate(value); err != nil {
+				return nil, err
+			}
+			return value, nil
+		},
+		get: func(c *Config) any { return *field(c) },
+		set: func(c *Config, value any) { *field(c) = value.(string) },
+	}
+}
+
+func intSetting(key, description string, min, max int, field func(*Config) *int) Setting {
+	return Setting{
+		Key:         key,
+		Description: description,
+		parse: func(raw any) (any, error) {
+			var value int
+			switch raw := raw.(type) {
+			case int:
+				value = raw
+			case int64:
+				value = int(raw)
+			case float64:
+				if raw != math.Trunc(raw) {
+					return nil, errors.New("must be a whole number")
+				}
+				value = int(raw)
+			case string:
+				parsed, err := strconv.Atoi(strings.TrimSpace(raw))
+				if err != nil {
+					return nil, errors.New("must be a whole number")
+				}
+				value = parsed
+			default:
+				return nil, errors.New("must be a whole number")
+			}
+			if value < min || value > max {
+				return nil, fmt.Errorf("must be between %d and %d", min, max)
+			}
+			return value, nil
+		},
+		get: func(c *Config) any { return *field(c) },
+		set: func(c *Config, value any) { *field(c) = value.(int) },
+	}
+}
+
+func boolSetting(key, description string, field func(*Config) *bool) Setting {
+	return Setting{
+		Key:         key,
+		Description: description,
+		parse: func(raw any) (any, error) {
+			switch raw := raw.(type) {
+			case bool:
+				return raw, nil
+			case string:
+				value, err := strconv.ParseBool(strings.TrimSpace(raw))
+				if err != nil {
+					return nil, errors.New("must be true or false")
+				}
+				return value, nil
+			}
+			return nil, errors.New("must be true or false")
+		},
+		get: func(c *Config) any { return *field(c) },
+		set: func(c *Config, value any) { *field(c) = value.(bool) },
+	}
+}
+
+func durationSetting(key, description string, min, max time.Duration, field func(*Config) *time.Duration) Setting {
+	return Setting{
+		Key:         key,
+		Description: description,
+		parse: func(raw any) (any, error) {
+			var value time.Duration
+			switch raw := raw.(type) {
+			case time.Duration:
+				value = raw
+			case string:
+				parsed, err := time.ParseDuration(strings.TrimSpace(raw))
+				if err != nil {
+					return nil, errors.New("must be a duration such as 15m or 1h30m")
+				}
+				value = parsed
+			default:
+				return nil, errors.New("must be a duration such as 15m or 1h30m")
+			}
+			if value < min || value > max {
+				return nil, fmt.Errorf("must be between %s and %s", min, max)
+			}
+			return value, nil
+		},
+		get: func(c *Config) any { return *field(c) },
+		set: func(c *Config, value any) { *field(c) = value.(time.Duration) },
+	}
+}
+
+func absolutePath(value string) error {
+	if !filepath.IsAbs(value) {
+		return errors.New("must be an absolute path")
+	}
+	return nil
+}
+
+func vaultLocation(value string) error {
+	if !strings.Contains(value, "://") && !filepath.IsAbs(value) {
+		return errors.New("must be an absolute path or a storage URL")
+	}
+	return nil
+}
+
+func oneOf(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, option := range allowed {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+func httpURL(value string) error {
+	if !strings.HasPrefix(value, "https://") && !strings.HasPrefix(value, "http://") {
+		return errors.New("must be an http:// or https:// URL")
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes content as the config file of configDir
+func writeConfigFile(t *testing.T, configDir, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(FilePath(configDir), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// settingErrors returns the SettingErrors joined in err by key
+func settingErrors(err error) map[string]*SettingError {
+	found := make(map[string]*SettingError)
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return found
+	}
+	for _, err := range joined.Unwrap() {
+		var settingErr *SettingError
+		if errors.As(err, &settingErr) {
+			found[settingErr.Key] = settingErr
+		}
+	}
+	return found
+}
+
+func TestDefaults(t *testing.T) {
+	useTempHome(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load without a config file: %v", err)
+	}
+	if cfg.BackupCount != 5 || cfg.SessionTimeout != 15*time.Minute || cfg.Generator.Length != 16 {
+		t.Errorf("defaults = %+v", cfg)
+	}
+}
+
+func TestInvalidFileValues(t *testing.T) {
+	configDir := useTempHome(t)
+	writeConfigFile(t, configDir, `
+backup_count: 500
+session_timeout: soon
+history_limit: 20
+colour: never
+`)
+
+	cfg, err := Load()
+	if err == nil {
+		t.Fatal("Load accepted invalid settings")
+	}
+
+	invalid := settingErrors(err)
+	for _, key := range []string{"backup_count", "session_timeout"} {
+		settingErr, ok := invalid[key]
+		if !ok {
+			t.Errorf("no SettingError for %s in %v", key, err)
+			continue
+		}
+		if settingErr.Source != SourceFile {
+			t.Errorf("%s source = %s, want %s", key, settingErr.Source, SourceFile)
+		}
+		if !strings.Contains(settingErr.Error(), key) {
+			t.Errorf("error %q does not name %s", settingErr.Error(), key)
+		}
+	}
+	if len(invalid) != 2 {
+		t.Errorf("got SettingErrors for %d keys, want 2: %v", len(invalid), err)
+	}
+	if !strings.Contains(err.Error(), `unknown config key "colour"`) {
+		t.Errorf("error %q does not report the misspelled key", err)
+	}
+
+	// Invalid values keep their defaults, valid ones still apply
+	if cfg.BackupCount != 5 || cfg.SessionTimeout != 15*time.Minute {
+		t.Errorf("invalid settings were applied: backup_count %d, session_timeout %s", cfg.BackupCount, cfg.SessionTimeout)
+	}
+	if cfg.HistoryLimit != 20 {
+		t.Errorf("history_limit = %d, want 20 from the file", cfg.HistoryLimit)
+	}
+}
+
+func TestEnvOverridesFile(t *testing.T) {
+	configDir := useTempHome(t)
+	writeConfigFile(t, configDir, `
+backup_count: 3
+generator:
+  length: 20
+  symbols: false
+`)
+	t.Setenv("GOPASSMAN_BACKUP_COUNT", "7")
+	t.Setenv("GOPASSMAN_GENERATOR_LENGTH", "32")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.BackupCount != 7 || cfg.Generator.Length != 32 {
+		t.Errorf("backup_count %d, generator.length %d, want 7 and 32 from the environment", cfg.BackupCount, cfg.Generator.Length)
+	}
+	if cfg.Generator.Symbols {
+		t.Error("generator.symbols from the file was not applied")
+	}
+
+	value, err := GetSetting("backup_count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.Value != "7" || value.Source != SourceEnv {
+		t.Errorf("backup_count = %s from %s, want 7 from %s", value.Value, value.Source, SourceEnv)
+	}
+	if value, _ := GetSetting("generator.symbols"); value.Source != SourceFile {
+		t.Errorf("generator.symbols source = %s, want %s", value.Source, SourceFile)
+	}
+
+	// A bad environment value names its variable
+	t.Setenv("GOPASSMAN_BACKUP_COUNT", "lots")
+	cfg, err = Load()
+	settingErr, ok := settingErrors(err)["backup_count"]
+	if !ok || settingErr.Source != SourceEnv {
+		t.Fatalf("Load with a bad environment value = %v", err)
+	}
+	if !strings.Contains(settingErr.Error(), "GOPASSMAN_BACKUP_COUNT") {
+		t.Errorf("error %q does not name the environment variable", settingErr.Error())
+	}
+	if cfg.BackupCount != 5 {
+		t.Errorf("backup_count = %d, want the default 5", cfg.BackupCount)
+	}
+}
+
+func TestSetSetting(t *testing.T) {
+	configDir := useTempHome(t)
+
+	if err := SetSetting("clipboard_timeout", "90s"); err != nil {
+		t.Fatalf("SetSetting: %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ClipboardTimeout != 90*time.Second {
+		t.Errorf("clipboard_timeout = %s, want 1m30s", cfg.ClipboardTimeout)
+	}
+
+	before, err := os.ReadFile(FilePath(configDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key, value string
+	}{
+		{"backup_count", "101"},
+		{"backup_count", "-1"},
+		{"clipboard_timeout", "1s"},
+		{"generator.length", "4"},
+		{"color", "sometimes"},
+		{"vault_path", "relative/vault.gpv"},
+	}
+	for _, tt := range tests {
+		err := SetSetting(tt.key, tt.value)
+		var settingErr *SettingError
+		if !errors.As(err, &settingErr) || settingErr.Key != tt.key {
+			t.Errorf("SetSetting(%s, %s) = %v, want a SettingError for %s", tt.key, tt.value, err, tt.key)
+		}
+	}
+
+	if err := SetSetting("backup_cuont", "3"); err == nil || !strings.Contains(err.Error(), "unknown config key") {
+		t.Errorf("SetSetting of an unknown key = %v, want an unknown key error", err)
+	}
+
+	// Turning off the last character class breaks a rule between settings
+	for _, key := range []string{"generator.uppercase", "generator.lowercase", "generator.numbers"} {
+		if err := SetSetting(key, "false"); err != nil {
+			t.Fatalf("SetSetting(%s): %v", key, err)
+		}
+	}
+	if err := SetSetting("generator.symbols", "false"); err == nil {
+		t.Error("SetSetting disabled every character class")
+	}
+
+	// Rejected values never reach the file
+	after, err := os.ReadFile(filepath.Join(configDir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rejected := range []string{"101", "sometimes", "relative", "backup_cuont", "symbols"} {
+		if strings.Contains(string(after), rejected) {
+			t.Errorf("config file contains rejected value %q:\n%s", rejected, after)
+		}
+	}
+	if !strings.Contains(string(after), "clipboard_timeout") || len(after) <= len(before) {
+		t.Errorf("config file lost accepted settings:\n%s", after)
+	}
+}
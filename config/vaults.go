@@ -0,0 +1,225 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// DefaultVaultName is the vault used when no other is selected. It lives at
+// the pre-registry location, so vaults created before named vaults existed
+// keep working.
+const DefaultVaultName = "default"
+
+// vaultsFileName is the registry of named vaults in the config directory
+const vaultsFileName = "vaults.json"
+
+var (
+	// ErrVaultNotFound is returned for a vault name that is not registered
+	ErrVaultNotFound = errors.New("vault not found")
+
+	// ErrVaultExists is returned when a vault name is already taken
+	ErrVaultExists = errors.New("a vault with this name already exists")
+)
+
+// vaultNamePattern restricts vault names to what is safe in file names
+var vaultNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// Vaults records the named vaults and which one is in use
+type Vaults struct {
+	Current   string            `json:"current,omitempty"` // set by 'vault use', empty for the default vault
+	Locations map[string]string `json:"vaults"`            // vault name to path or storage URL
+}
+
+// ValidateVaultName checks that name can be used for a vault
+func ValidateVaultName(name string) error {
+	if !vaultNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid vault name %q: use up to 64 letters, digits, '.', '_' or '-', starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// VaultLocation returns where a new local vault with the given name is stored
+func VaultLocation(configDir, name string) string {
+	if name == DefaultVaultName {
+		return filepath.Join(configDir, "vault.gpv")
+	}
+	return filepath.Join(configDir, "vaults", name+".gpv")
+}
+
+// VaultSyncDir returns the git repository a vault is synchronized through.
+// Every vault has its own, so their histories never mix.
+func VaultSyncDir(configDir, name string) string {
+	if name == DefaultVaultName {
+		return filepath.Join(configDir, "sync")
+	}
+	return filepath.Join(configDir, "sync-"+name)
+}
+
+// DetachSyncDir moves the sync repository of the named vault out of the way,
+// so a new vault with that name starts with a fresh one instead of merging
+// with the old vault's history. It returns where the repository was moved, or
+// an empty string if there was none.
+func DetachSyncDir(configDir, name string) (string, error) {
+	dir := VaultSyncDir(configDir, name)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	detachedDir := filepath.Join(configDir, "detached")
+	if err := os.MkdirAll(detachedDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to detach sync repository: %w", err)
+	}
+	base := filepath.Join(detachedDir, filepath.Base(dir)+"-"+time.Now().Format("20060102-150405"))
+	target := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(target); os.IsNotExist(err) {
+			break
+		}
+		target = fmt.Sprintf("%s-%d", base, i)
+	}
+	if err := os.Rename(dir, target); err != nil {
+		return "", fmt.Errorf("failed to detach sync repository: %w", err)
+	}
+	return target, nil
+}
+
+// LoadVaults reads the vault registry. Without one, only the default vault
+// is known.
+func LoadVaults(configDir string) (*Vaults, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, vaultsFileName))
+	if os.IsNotExist(err) {
+		return &Vaults{
+			Locations: map[string]string{DefaultVaultName: VaultLocation(configDir, DefaultVaultName)},
+		}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault registry: %w", err)
+	}
+
+	var vaults Vaults
+	if err := json.Unmarshal(data, &vaults); err != nil {
+		return nil, fmt.Errorf("failed to parse vault registry %s: %w", filepath.Join(configDir, vaultsFileName), err)
+	}
+	if vaults.Locations == nil {
+		vaults.Locations = make(map[string]string)
+	}
+	return &vaults, nil
+}
+
+// Save writes the vault registry
+func (v *Vaults) Save(configDir string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault registry: %w", err)
+	}
+
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	// Write a temporary file and rename it, so the registry is never half written
+	path := filepath.Join(configDir, vaultsFileName)
+	if err := os.WriteFile(path+".tmp", data, 0600); err != nil {
+		return fmt.Errorf("failed to write vault registry: %w", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("failed to write vault registry: %w", err)
+	}
+	return nil
+}
+
+// Names returns the registered vault names in order
+func (v *Vaults) Names() []string {
+	names := make([]string, 0, len(v.Locations))
+	for name := range v.Locations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Location returns where the named vault is stored, or the location a new
+// local vault with that name would get if it is not registered
+func (v *Vaults) Location(configDir, name string) (string, bool) {
+	if location, exists := v.Locations[name]; exists {
+		return location, true
+	}
+	return VaultLocation(configDir, name), false
+}
+
+// Add registers a vault stored at location under name
+func (v *Vaults) Add(name, location string) error {
+	if err := ValidateVaultName(name); err != nil {
+		return err
+	}
+	if _, exists := v.Locations[name]; exists {
+		return ErrVaultExists
+	}
+
+	v.Locations[name] = location
+	return nil
+}
+
+// Use makes the named vault the current one
+func (v *Vaults) Use(name string) error {
+	if _, exists := v.Locations[name]; !exists {
+		return ErrVaultNotFound
+	}
+
+	v.Current = name
+	return nil
+}
+
+// Rename gives a vault a new name. The vault file stays where it is, but its
+// sync repository moves along.
+func (v *Vaults) Rename(configDir, name, newName string) error {
+	if err := ValidateVaultName(newName); err != nil {
+		return err
+	}
+	location, exists := v.Locations[name]
+	if !exists {
+		return ErrVaultNotFound
+	}
+	if _, taken := v.Locations[newName]; taken {
+		return ErrVaultExists
+	}
+
+	err := os.Rename(VaultSyncDir(configDir, name), VaultSyncDir(configDir, newName))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to move sync repository: %w", err)
+	}
+
+	delete(v.Locations, name)
+	v.Locations[newName] = location
+	if v.Current == name {
+		v.Current = newName
+	}
+	return nil
+}
+
+// Remove forgets the named vault, leaving its file alone. Its sync repository
+// is detached, so a vault created later under the same name does not inherit
+// the remote; Remove returns where it was moved, as DetachSyncDir does.
+func (v *Vaults) Remove(configDir, name string) (string, error) {
+	if _, exists := v.Locations[name]; !exists {
+		return "", ErrVaultNotFound
+	}
+
+	detached, err := DetachSyncDir(configDir, name)
+	if err != nil {
+		return "", err
+	}
+
+	delete(v.Locations, name)
+	if v.Current == name {
+		v.Current = ""
+	}
+	return detached, nil
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useTempHome points the config directory at a fresh temporary directory
+// and clears the vault selection and settings from the environment,
+// returning the config directory
+func useTempHome(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APPDATA", home)
+	t.Setenv("GOPASSMAN_VAULT", "")
+	t.Setenv("GOPASSMAN_VAULT_URL", "")
+	for _, setting := range settings {
+		t.Setenv(EnvVar(setting.Key), "")
+	}
+
+	savedVault, savedFile := SelectedVault, File
+	SelectedVault, File = "", ""
+	t.Cleanup(func() { SelectedVault, File = savedVault, savedFile })
+
+	return DefaultConfig().ConfigDir
+}
+
+func TestValidateVaultName(t *testing.T) {
+	valid := []string{"default", "team", "Client-2", "a.b_c", strings.Repeat("x", 64)}
+	for _, name := range valid {
+		if err := ValidateVaultName(name); err != nil {
+			t.Errorf("ValidateVaultName(%q) = %v, want nil", name, err)
+		}
+	}
+
+	invalid := []string{"", ".hidden", "-flag", "../escape", "a/b", `a\b`, "with space", "ünicode", strings.Repeat("x", 65)}
+	for _, name := range invalid {
+		if err := ValidateVaultName(name); err == nil {
+			t.Errorf("ValidateVaultName(%q) accepted an invalid name", name)
+		}
+	}
+}
+
+func TestVaultLifecycle(t *testing.T) {
+	configDir := useTempHome(t)
+
+	// Without a registry only the default vault is known
+	vaults, err := LoadVaults(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := vaults.Names(); len(names) != 1 || names[0] != DefaultVaultName {
+		t.Fatalf("vaults without a registry = %v, want [%s]", names, DefaultVaultName)
+	}
+
+	// Create
+	teamLocation := "s3://bucket/team.gpv"
+	if err := vaults.Add("team", teamLocation); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := vaults.Add("team", "/elsewhere.gpv"); !errors.Is(err, ErrVaultExists) {
+		t.Errorf("Add of a taken name = %v, want ErrVaultExists", err)
+	}
+	if err := vaults.Add("../team", "/elsewhere.gpv"); err == nil {
+		t.Error("Add accepted an invalid name")
+	}
+
+	// Use
+	if err := vaults.Use("missing"); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("Use of an unknown vault = %v, want ErrVaultNotFound", err)
+	}
+	if err := vaults.Use("team"); err != nil {
+		t.Fatalf("Use: %v", err)
+	}
+	if err := vaults.Save(configDir); err != nil {
+		t.Fatal(err)
+	}
+	if cfg := DefaultConfig(); cfg.Vault != "team" || cfg.VaultPath != teamLocation {
+		t.Errorf("config after use = %s at %s, want team at %s", cfg.Vault, cfg.VaultPath, teamLocation)
+	}
+
+	// Rename, taking the sync repository along
+	syncFile := filepath.Join(VaultSyncDir(configDir, "team"), "vault.gpv")
+	if err := os.MkdirAll(filepath.Dir(syncFile), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(syncFile, []byte("history"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := vaults.Rename(configDir, "team", DefaultVaultName); !errors.Is(err, ErrVaultExists) {
+		t.Errorf("Rename to a taken name = %v, want ErrVaultExists", err)
+	}
+	if err := vaults.Rename(configDir, "team", "a/b"); err == nil {
+		t.Error("Rename accepted an invalid name")
+	}
+	if err := vaults.Rename(configDir, "missing", "other"); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("Rename of an unknown vault = %v, want ErrVaultNotFound", err)
+	}
+	if err := vaults.Rename(configDir, "team", "work"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if vaults.Current != "work" || vaults.Locations["work"] != teamLocation {
+		t.Errorf("after rename current = %q, location = %q", vaults.Current, vaults.Locations["work"])
+	}
+	if _, exists := vaults.Locations["team"]; exists {
+		t.Error("old name is still registered")
+	}
+	if _, err := os.Stat(filepath.Join(VaultSyncDir(configDir, "work"), "vault.gpv")); err != nil {
+		t.Errorf("sync repository did not move: %v", err)
+	}
+
+	// Remove detaches the sync repository and falls back to the default vault
+	detached, err := vaults.Remove(configDir, "work")
+	if err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(VaultSyncDir(configDir, "work")); !os.IsNotExist(err) {
+		t.Errorf("sync repository was left in place: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(detached, "vault.gpv")); err != nil || string(data) != "history" {
+		t.Errorf("detached sync repository = %q, %v", data, err)
+	}
+	if _, err := vaults.Remove(configDir, "work"); !errors.Is(err, ErrVaultNotFound) {
+		t.Errorf("second Remove = %v, want ErrVaultNotFound", err)
+	}
+	if err := vaults.Save(configDir); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := LoadVaults(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Current != "" || len(reloaded.Locations) != 1 {
+		t.Errorf("reloaded registry = %+v, want only the default vault", reloaded)
+	}
+	if cfg := DefaultConfig(); cfg.Vault != DefaultVaultName {
+		t.Errorf("vault after remove = %s, want %s", cfg.Vault, DefaultVaultName)
+	}
+}
+
+func TestVaultSelection(t *testing.T) {
+	configDir := useTempHome(t)
+
+	vaults, err := LoadVaults(configDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"current", "env", "flag"} {
+		if err := vaults.Add(name, filepath.Join(configDir, name+".gpv")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(want string) {
+		t.Helper()
+		cfg := DefaultConfig()
+		if cfg.Vault != want {
+			t.Errorf("selected vault = %s, want %s", cfg.Vault, want)
+		}
+		if cfg.SyncDir != VaultSyncDir(configDir, want) {
+			t.Errorf("sync directory = %s, want the one of %s", cfg.SyncDir, want)
+		}
+	}
+
+	check(DefaultVaultName)
+
+	vaults.Current = "current"
+	if err := vaults.Save(configDir); err != nil {
+		t.Fatal(err)
+	}
+	check("current")
+
+	t.Setenv("GOPASSMAN_VAULT", "env")
+	check("env")
+
+	SelectedVault = "flag"
+	check("flag")
+	if cfg := DefaultConfig(); cfg.VaultPath != filepath.Join(configDir, "flag.gpv") {
+		t.Errorf("vault path = %s, want the flag vault's", cfg.VaultPath)
+	}
+}
+
+func TestDetachSyncDirWithoutRepository(t *testing.T) {
+	configDir := t.TempDir()
+
+	detached, err := DetachSyncDir(configDir, "team")
+	if err != nil || detached != "" {
+		t.Errorf("DetachSyncDir without a repository = %q, %v, want nothing", detached, err)
+	}
+}
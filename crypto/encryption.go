@@ -8,6 +8,7 @@ import (
 	"errors"
 	"io"
 
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 )
 
@@ -15,6 +16,9 @@ const (
 	keySize   = 32 // AES-256
 	nonceSize = 12 // GCM standard nonce size
 	saltSize  = 32 // Salt size for key derivation
+
+	// LegacyPBKDF2Iterations is the iteration count used by vaults created before Argon2id
+	LegacyPBKDF2Iterations = 10000
 )
 
 // EncryptionKey represents a derived encryption key
@@ -24,24 +28,50 @@ type EncryptionKey struct {
 }
 
 // DeriveKey derives an encryption key from a master password using PBKDF2
+// with the legacy iteration count
 func DeriveKey(masterPassword string, salt []byte) *EncryptionKey {
 	if salt == nil {
-		salt = make([]byte, saltSize)
-		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		var err error
+		salt, err = GenerateSalt()
+		if err != nil {
 			panic(err) // This should be handled properly in production
 		}
 	}
 
-	key := pbkdf2.Key([]byte(masterPassword), salt, 10000, keySize, sha256.New)
-
 	return &EncryptionKey{
-		Key:  key,
+		Key:  DeriveKeyPBKDF2(masterPassword, salt, LegacyPBKDF2Iterations),
 		Salt: salt,
 	}
 }
 
+// DeriveKeyPBKDF2 derives an encryption key using PBKDF2-SHA256
+func DeriveKeyPBKDF2(masterPassword string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(masterPassword), salt, iterations, keySize, sha256.New)
+}
+
+// DeriveKeyArgon2id derives an encryption key using Argon2id.
+// memory is given in KiB.
+func DeriveKeyArgon2id(masterPassword string, salt []byte, memory, iterations uint32, parallelism uint8) []byte {
+	return argon2.IDKey([]byte(masterPassword), salt, iterations, memory, parallelism, keySize)
+}
+
+// GenerateSalt returns a new random salt for key derivation
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
 // Encrypt encrypts plaintext using AES-GCM
 func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
+	return EncryptWithAD(plaintext, key, nil)
+}
+
+// EncryptWithAD encrypts plaintext using AES-GCM and authenticates
+// additionalData alongside it without encrypting it
+func EncryptWithAD(plaintext []byte, key []byte, additionalData []byte) ([]byte, error) {
 	if len(key) != keySize {
 		return nil, errors.New("invalid key size")
 	}
@@ -61,12 +91,18 @@ func Encrypt(plaintext []byte, key []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, additionalData)
 	return ciphertext, nil
 }
 
 // Decrypt decrypts ciphertext using AES-GCM
 func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
+	return DecryptWithAD(ciphertext, key, nil)
+}
+
+// DecryptWithAD decrypts ciphertext using AES-GCM, failing unless
+// additionalData matches what was authenticated at encryption time
+func DecryptWithAD(ciphertext []byte, key []byte, additionalData []byte) ([]byte, error) {
 	if len(key) != keySize {
 		return nil, errors.New("invalid key size")
 	}
@@ -88,7 +124,7 @@ func Decrypt(ciphertext []byte, key []byte) ([]byte, error) {
 	nonce := ciphertext[:nonceSize]
 	ciphertext = ciphertext[nonceSize:]
 
-	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additionalData)
 	if err != nil {
 		return nil, err
 	}
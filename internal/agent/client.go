@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// dialTimeout bounds how long a client waits for the agent
+const dialTimeout = 2 * time.Second
+
+// Client talks to a running agent
+type Client struct {
+	socketPath string
+}
+
+// NewClient creates a client for the agent listening on socketPath
+func NewClient(socketPath string) *Client {
+	return &Client{socketPath: socketPath}
+}
+
+// Running reports whether an agent is accepting connections
+func (c *Client) Running() bool {
+	_, err := c.Status()
+	return err == nil
+}
+
+// Status returns the agent's current state
+func (c *Client) Status() (*Response, error) {
+	return c.call(&Request{Op: OpStatus})
+}
+
+// Unlock asks the agent to unlock the vault at vaultPath with the master password
+func (c *Client) Unlock(vaultPath, masterPassword string) (*Response, error) {
+	return c.call(&Request{Op: OpUnlock, VaultPath: vaultPath, Password: masterPassword})
+}
+
+// Lock asks the agent to forget its key
+func (c *Client) Lock() error {
+	_, err := c.call(&Request{Op: OpLock})
+	return err
+}
+
+// Key returns the encryption key the agent holds for vaultPath
+func (c *Client) Key(vaultPath string) ([]byte, error) {
+	resp, err := c.call(&Request{Op: OpKey, VaultPath: vaultPath})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Key, nil
+}
+
+// call sends a single request and waits for the response
+func (c *Client) call(req *Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.socketPath, dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(connTimeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if !resp.OK {
+		if resp.Error == ErrLocked.Error() {
+			return nil, ErrLocked
+		}
+		return nil, errors.New(resp.Error)
+	}
+
+	return &resp, nil
+}
+
+// Spawn starts a detached agent for socketPath that locks after timeout and
+// waits until it accepts connections
+func Spawn(socketPath string, timeout time.Duration) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	cmd := exec.Command(executable, "agent", "--socket", socketPath, "--timeout", timeout.String())
+	cmd.SysProcAttr = detachedProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+	cmd.Process.Release()
+
+	client := NewClient(socketPath)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if client.Running() {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return fmt.Errorf("agent did not start listening on %s", socketPath)
+}
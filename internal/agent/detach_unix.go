@@ -0,0 +1,10 @@
+//go:build !windows
+
+package agent
+
+import "syscall"
+
+// detachedProcAttr starts the agent in its own session so it outlives the terminal
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
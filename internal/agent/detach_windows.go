@@ -0,0 +1,11 @@
+//go:build windows
+
+package agent
+
+import "syscall"
+
+// detachedProcAttr starts the agent without a console window
+func detachedProcAttr() *syscall.SysProcAttr {
+	const createNoWindow = 0x08000000
+	return &syscall.SysProcAttr{CreationFlags: createNoWindow}
+}
@@ -0,0 +1,14 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+)
+
+// checkPeerUID rejects peers that are not running as the agent's own user
+func checkPeerUID(uid int) error {
+	if uid != os.Getuid() {
+		return fmt.Errorf("permission denied for uid %d", uid)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+//go:build darwin
+
+package agent
+
+import (
+	"fmt"
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// checkPeer rejects connections from processes owned by another user
+func checkPeer(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("failed to inspect connection: %w", err)
+	}
+
+	var cred *unix.Xucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptXucred(int(fd), unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+	}); err != nil {
+		return fmt.Errorf("failed to inspect connection: %w", err)
+	}
+	if credErr != nil {
+		return fmt.Errorf("failed to read peer credentials: %w", credErr)
+	}
+
+	return checkPeerUID(int(cred.Uid))
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"errors"
+	"time"
+)
+
+// Operations understood by the agent
+const (
+	OpStatus = "status"
+	OpUnlock = "unlock"
+	OpLock   = "lock"
+	OpKey    = "key"
+)
+
+var (
+	// ErrNotRunning is returned when no agent is listening on the socket
+	ErrNotRunning = errors.New("agent is not running")
+	// ErrLocked is returned when the agent does not hold a key for the requested vault
+	ErrLocked = errors.New("agent is locked")
+)
+
+// Request is a single message sent from a client to the agent
+type Request struct {
+	Op        string `json:"op"`
+	VaultPath string `json:"vault_path,omitempty"`
+	Password  string `json:"password,omitempty"`
+}
+
+// Response is the agent's reply to a Request
+type Response struct {
+	OK        bool      `json:"ok"`
+	Error     string    `json:"error,omitempty"`
+	Unlocked  bool      `json:"unlocked"`
+	VaultPath string    `json:"vault_path,omitempty"`
+	Key       []byte    `json:"key,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	PID       int       `json:"pid"`
+}
@@ -0,0 +1,272 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+const (
+	// connTimeout bounds how long a single client may take to send its request
+	connTimeout = 10 * time.Second
+	// expiryCheckInterval is how often the agent checks for an idle session
+	expiryCheckInterval = 5 * time.Second
+)
+
+// Server holds an unlocked vault session in memory and hands its key to
+// clients of the same user over a Unix socket
+type Server struct {
+	socketPath string
+	timeout    time.Duration
+	listener   net.Listener
+	session    *vault.Session
+	mutex      sync.Mutex
+	done       chan struct{}
+}
+
+// NewServer creates an agent server listening on socketPath. Sessions are
+// locked after being idle for longer than timeout.
+func NewServer(socketPath string, timeout time.Duration) *Server {
+	return &Server{
+		socketPath: socketPath,
+		timeout:    timeout,
+		done:       make(chan struct{}),
+	}
+}
+
+// ListenAndServe creates the socket and serves requests until Shutdown is called
+func (s *Server) ListenAndServe() error {
+	if err := prepareSocketDir(filepath.Dir(s.socketPath)); err != nil {
+		return err
+	}
+
+	// A leftover socket from a crashed agent blocks Listen, but a live one must not be stolen
+	if _, err := os.Stat(s.socketPath); err == nil {
+		if NewClient(s.socketPath).Running() {
+			return fmt.Errorf("an agent is already running on %s", s.socketPath)
+		}
+		if err := os.Remove(s.socketPath); err != nil {
+			return fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.socketPath, err)
+	}
+	if err := os.Chmod(s.socketPath, 0600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to secure socket: %w", err)
+	}
+	s.listener = listener
+
+	go s.expireIdleSession()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+
+		go s.handle(conn.(*net.UnixConn))
+	}
+}
+
+// Shutdown locks the session, stops accepting connections and removes the socket
+func (s *Server) Shutdown() {
+	s.lock()
+
+	select {
+	case <-s.done:
+		return
+	default:
+		close(s.done)
+	}
+
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	os.Remove(s.socketPath)
+}
+
+// handle serves a single request on conn
+func (s *Server) handle(conn *net.UnixConn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(connTimeout))
+
+	encoder := json.NewEncoder(conn)
+
+	if err := checkPeer(conn); err != nil {
+		encoder.Encode(&Response{Error: err.Error()})
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		encoder.Encode(&Response{Error: fmt.Sprintf("invalid request: %v", err)})
+		return
+	}
+
+	resp := s.dispatch(&req)
+	resp.PID = os.Getpid()
+	encoder.Encode(resp)
+
+	// Key material only lives in the response for as long as it takes to send it
+	if resp.Key != nil {
+		for i := range resp.Key {
+			resp.Key[i] = 0
+		}
+	}
+}
+
+// dispatch executes a request against the agent state
+func (s *Server) dispatch(req *Request) *Response {
+	switch req.Op {
+	case OpStatus:
+		return s.status()
+	case OpUnlock:
+		return s.unlock(req.VaultPath, req.Password)
+	case OpLock:
+		s.lock()
+		return &Response{OK: true}
+	case OpKey:
+		return s.key(req.VaultPath)
+	default:
+		return &Response{Error: fmt.Sprintf("unknown operation %q", req.Op)}
+	}
+}
+
+func (s *Server) status() *Response {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.session == nil {
+		return &Response{OK: true}
+	}
+
+	return &Response{
+		OK:        true,
+		Unlocked:  true,
+		VaultPath: s.session.VaultPath,
+		ExpiresAt: s.session.ExpiresAt(),
+	}
+}
+
+func (s *Server) unlock(vaultPath, masterPassword string) *Response {
+	if vaultPath == "" || masterPassword == "" {
+		return &Response{Error: "vault path and master password are required"}
+	}
+
+	session, err := vault.UnlockVault(masterPassword, vaultPath)
+	if err != nil {
+		return &Response{Error: err.Error()}
+	}
+	session.SessionTimeout = s.timeout
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.session != nil {
+		s.session.Close()
+	}
+	s.session = session
+
+	return &Response{
+		OK:        true,
+		Unlocked:  true,
+		VaultPath: session.VaultPath,
+		ExpiresAt: session.ExpiresAt(),
+	}
+}
+
+func (s *Server) lock() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.session != nil {
+		s.session.Close()
+		s.session = nil
+	}
+}
+
+func (s *Server) key(vaultPath string) *Response {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.session == nil || s.session.VaultPath != vaultPath {
+		return &Response{Error: ErrLocked.Error()}
+	}
+
+	s.session.Touch()
+
+	key := make([]byte, len(s.session.EncryptionKey))
+	copy(key, s.session.EncryptionKey)
+
+	return &Response{
+		OK:        true,
+		Unlocked:  true,
+		VaultPath: s.session.VaultPath,
+		Key:       key,
+		ExpiresAt: s.session.ExpiresAt(),
+	}
+}
+
+// expireIdleSession locks the agent once the session has been idle for too long
+func (s *Server) expireIdleSession() {
+	ticker := time.NewTicker(expiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			s.expireIfIdle()
+		}
+	}
+}
+
+// expireIfIdle wipes the session if it has been idle for longer than its timeout
+func (s *Server) expireIfIdle() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.session != nil && s.session.Expired() {
+		s.session.Close()
+		s.session = nil
+	}
+}
+
+// prepareSocketDir makes sure the socket directory exists and is private to the user
+func prepareSocketDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("failed to stat socket directory: %w", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("failed to secure socket directory: %w", err)
+		}
+	}
+
+	return nil
+}
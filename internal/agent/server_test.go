@@ -0,0 +1,250 @@
+package agent
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/vault"
+)
+
+const testPassword = "correct horse battery staple"
+
+// newTestVault creates a vault in a temporary directory and returns its path
+func newTestVault(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "vault.json")
+	if err := vault.CreateVault(testPassword, path); err != nil {
+		t.Fatalf("CreateVault: %v", err)
+	}
+	return path
+}
+
+// startServer runs an agent on a socket inside a fresh directory and stops it
+// when the test ends
+func startServer(t *testing.T, timeout time.Duration) (*Server, *Client, string) {
+	t.Helper()
+
+	socketPath := filepath.Join(t.TempDir(), "agent", "agent.sock")
+	server, client := startServerAt(t, socketPath, timeout)
+	return server, client, socketPath
+}
+
+// startServerAt runs an agent on socketPath until the test ends
+func startServerAt(t *testing.T, socketPath string, timeout time.Duration) (*Server, *Client) {
+	t.Helper()
+
+	server := NewServer(socketPath, timeout)
+
+	errc := make(chan error, 1)
+	go func() { errc <- server.ListenAndServe() }()
+	t.Cleanup(func() {
+		server.Shutdown()
+		if err := <-errc; err != nil {
+			t.Errorf("ListenAndServe: %v", err)
+		}
+	})
+
+	client := NewClient(socketPath)
+	deadline := time.Now().Add(5 * time.Second)
+	for !client.Running() {
+		if time.Now().After(deadline) {
+			t.Fatal("agent did not start listening")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return server, client
+}
+
+func TestProtocolRoundTrip(t *testing.T) {
+	vaultPath := newTestVault(t)
+	_, client, _ := startServer(t, time.Minute)
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if status.Unlocked {
+		t.Error("new agent reports unlocked")
+	}
+	if status.PID != os.Getpid() {
+		t.Errorf("PID = %d, want %d", status.PID, os.Getpid())
+	}
+
+	if _, err := client.Key(vaultPath); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Key before unlock: got %v, want ErrLocked", err)
+	}
+	if _, err := client.Unlock(vaultPath, "wrong password"); err == nil {
+		t.Fatal("Unlock with wrong password succeeded")
+	}
+
+	resp, err := client.Unlock(vaultPath, testPassword)
+	if err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if !resp.Unlocked || resp.VaultPath != vaultPath {
+		t.Errorf("Unlock response = %+v", resp)
+	}
+	if !resp.ExpiresAt.After(time.Now()) {
+		t.Errorf("ExpiresAt = %v, want a time in the future", resp.ExpiresAt)
+	}
+
+	key, err := client.Key(vaultPath)
+	if err != nil {
+		t.Fatalf("Key: %v", err)
+	}
+	session, err := vault.UnlockVault(testPassword, vaultPath)
+	if err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	if !bytes.Equal(key, session.EncryptionKey) {
+		t.Error("agent key does not match the vault key")
+	}
+	if _, err := vault.OpenSessionWithKey(key, vaultPath); err != nil {
+		t.Errorf("agent key does not open the vault: %v", err)
+	}
+
+	if _, err := client.Key(filepath.Join(t.TempDir(), "other.json")); !errors.Is(err, ErrLocked) {
+		t.Errorf("Key for another vault: got %v, want ErrLocked", err)
+	}
+
+	if err := client.Lock(); err != nil {
+		t.Fatalf("Lock: %v", err)
+	}
+	if _, err := client.Key(vaultPath); !errors.Is(err, ErrLocked) {
+		t.Errorf("Key after lock: got %v, want ErrLocked", err)
+	}
+	if status, err := client.Status(); err != nil || status.Unlocked {
+		t.Errorf("Status after lock = %+v, %v", status, err)
+	}
+}
+
+func TestUnknownOperation(t *testing.T) {
+	_, client, _ := startServer(t, time.Minute)
+
+	if _, err := client.call(&Request{Op: "dump"}); err == nil {
+		t.Error("unknown operation succeeded")
+	}
+}
+
+func TestSocketPermissions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "agent")
+	// A directory left readable by others must be locked down
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	socketPath := filepath.Join(dir, "agent.sock")
+	startServerAt(t, socketPath, time.Minute)
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("socket directory mode = %o, want 700", perm)
+	}
+
+	info, err = os.Stat(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode = %o, want 600", perm)
+	}
+}
+
+func TestNewSocketDirIsPrivate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "nested", "agent")
+	if err := prepareSocketDir(dir); err != nil {
+		t.Fatalf("prepareSocketDir: %v", err)
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("socket directory mode = %o, want 700", perm)
+	}
+}
+
+func TestShutdownRemovesSocket(t *testing.T) {
+	server, client, socketPath := startServer(t, time.Minute)
+
+	server.Shutdown()
+
+	if _, err := os.Stat(socketPath); !os.IsNotExist(err) {
+		t.Errorf("socket still exists after shutdown: %v", err)
+	}
+	if client.Running() {
+		t.Error("agent still answers after shutdown")
+	}
+}
+
+func TestCheckPeerUID(t *testing.T) {
+	if err := checkPeerUID(os.Getuid()); err != nil {
+		t.Errorf("own uid rejected: %v", err)
+	}
+	if err := checkPeerUID(os.Getuid() + 1); err == nil {
+		t.Error("different uid accepted")
+	}
+}
+
+func TestIdleTimeoutWipesKey(t *testing.T) {
+	vaultPath := newTestVault(t)
+	server := NewServer(filepath.Join(t.TempDir(), "agent.sock"), time.Minute)
+
+	if resp := server.unlock(vaultPath, testPassword); !resp.OK {
+		t.Fatalf("unlock: %s", resp.Error)
+	}
+	session := server.session
+
+	// A fresh session survives the check
+	server.expireIfIdle()
+	if server.session == nil {
+		t.Fatal("session expired before its timeout")
+	}
+
+	session.LastAccessed = time.Now().Add(-2 * time.Minute)
+	server.expireIfIdle()
+
+	if server.session != nil {
+		t.Fatal("idle session was not expired")
+	}
+	if session.EncryptionKey != nil || session.Vault != nil {
+		t.Error("expired session still holds key material")
+	}
+	if resp := server.key(vaultPath); resp.OK || resp.Key != nil {
+		t.Errorf("key after expiry = %+v", resp)
+	}
+}
+
+func TestLockWipesKey(t *testing.T) {
+	vaultPath := newTestVault(t)
+	server := NewServer(filepath.Join(t.TempDir(), "agent.sock"), time.Minute)
+
+	if resp := server.unlock(vaultPath, testPassword); !resp.OK {
+		t.Fatalf("unlock: %s", resp.Error)
+	}
+	session := server.session
+
+	if resp := server.dispatch(&Request{Op: OpLock}); !resp.OK {
+		t.Fatalf("lock: %s", resp.Error)
+	}
+
+	if server.session != nil {
+		t.Error("lock kept the session")
+	}
+	if session.EncryptionKey != nil || session.Vault != nil {
+		t.Error("locked session still holds key material")
+	}
+	if resp := server.key(vaultPath); resp.OK {
+		t.Error("key returned after lock")
+	}
+}
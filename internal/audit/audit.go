@@ -0,0 +1,333 @@
+// Package audit checks the entries of a vault for security problems: reused,
+// weak and old passwords, missing two-factor authentication, insecure URLs
+// and duplicate entries.
+package audit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/internal/strength"
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// Check names a problem the audit looks for
+type Check string
+
+const (
+	CheckReused      Check = "reused"
+	CheckWeak        Check = "weak"
+	CheckOld         Check = "old"
+	CheckNo2FA       Check = "no-2fa"
+	CheckInsecureURL Check = "insecure-url"
+	CheckDuplicate   Check = "duplicate"
+)
+
+// Checks lists every check in report order
+var Checks = []Check{CheckReused, CheckWeak, CheckOld, CheckNo2FA, CheckInsecureURL, CheckDuplicate}
+
+// Description returns a short human readable name of the check
+func (c Check) Description() string {
+	switch c {
+	case CheckReused:
+		return "Reused passwords"
+	case CheckWeak:
+		return "Weak passwords"
+	case CheckOld:
+		return "Old passwords"
+	case CheckNo2FA:
+		return "No two-factor authentication"
+	case CheckInsecureURL:
+		return "Non-HTTPS URLs"
+	case CheckDuplicate:
+		return "Duplicate entries"
+	}
+	return string(c)
+}
+
+// Severity ranks findings
+type Severity int
+
+const (
+	SeverityLow Severity = iota + 1
+	SeverityMedium
+	SeverityHigh
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityHigh:
+		return "high"
+	case SeverityMedium:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// MarshalText encodes the severity by name
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// penalty is how many points a finding of each severity costs an entry's score
+var penalty = map[Severity]int{
+	SeverityHigh:   40,
+	SeverityMedium: 20,
+	SeverityLow:    10,
+}
+
+// Options configures an audit
+type Options struct {
+	MaxAge   time.Duration // passwords older than this are reported, 0 disables the check
+	MinScore int           // passwords with a lower strength score (0-4) are weak
+	Now      time.Time     // reference time, the current time if zero
+}
+
+// DefaultOptions returns the options used when none are given
+func DefaultOptions() Options {
+	return Options{
+		MaxAge:   180 * 24 * time.Hour,
+		MinScore: 3,
+	}
+}
+
+// Finding is one problem with an entry
+type Finding struct {
+	Check    Check    `json:"check"`
+	Severity Severity `json:"severity"`
+	Detail   string   `json:"detail"`
+}
+
+// EntryReport is the audit result of one entry. Passwords are never included.
+type EntryReport struct {
+	ID                string    `json:"id"`
+	Title             string    `json:"title"`
+	Username          string    `json:"username,omitempty"`
+	URL               string    `json:"url,omitempty"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	Strength          int       `json:"strength"` // strength score from 0 to 4
+	Score             int       `json:"score"`    // 0 to 100, 100 without findings
+	Findings          []Finding `json:"findings"`
+}
+
+// Report is the result of an audit
+type Report struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Score       int            `json:"score"` // average entry score from 0 to 100
+	Grade       string         `json:"grade"`
+	Total       int            `json:"total_entries"`
+	MaxAgeDays  int            `json:"max_age_days,omitempty"` // age of passwords reported as old
+	MinStrength int            `json:"min_strength"`           // strength score below which passwords are weak
+	Summary     map[Check]int  `json:"summary"`                // findings by check
+	Entries     []*EntryReport `json:"entries"`                // entries with findings, worst first
+}
+
+// Run audits entries
+func Run(entries []*models.Entry, opts Options) *Report {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+
+	reports := make(map[string]*EntryReport, len(entries))
+	for _, entry := range entries {
+		reports[entry.ID] = &EntryReport{
+			ID:                entry.ID,
+			Title:             entry.Title,
+			Username:          entry.Username,
+			URL:               entry.URL,
+			PasswordChangedAt: entry.PasswordChangedAt(),
+		}
+	}
+
+	checkReused(entries, reports)
+	for _, entry := range entries {
+		report := reports[entry.ID]
+		checkWeak(entry, report, opts)
+		checkOld(entry, report, opts)
+		checkNo2FA(entry, report)
+		checkInsecureURL(entry, report)
+	}
+	checkDuplicates(entries, reports)
+
+	result := &Report{
+		GeneratedAt: opts.Now,
+		Total:       len(entries),
+		MaxAgeDays:  int(opts.MaxAge.Hours() / 24),
+		MinStrength: opts.MinScore,
+		Summary:     make(map[Check]int),
+		Entries:     []*EntryReport{},
+	}
+	for _, check := range Checks {
+		result.Summary[check] = 0
+	}
+
+	totalScore := 0
+	for _, entry := range entries {
+		report := reports[entry.ID]
+		report.Score = 100
+		for _, finding := range report.Findings {
+			report.Score -= penalty[finding.Severity]
+			result.Summary[finding.Check]++
+		}
+		report.Score = max(report.Score, 0)
+		totalScore += report.Score
+
+		if len(report.Findings) > 0 {
+			result.Entries = append(result.Entries, report)
+		}
+	}
+
+	result.Score = 100
+	if len(entries) > 0 {
+		result.Score = int(math.Round(float64(totalScore) / float64(len(entries))))
+	}
+	result.Grade = grade(result.Score)
+
+	sort.SliceStable(result.Entries, func(i, j int) bool {
+		a, b := result.Entries[i], result.Entries[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+	return result
+}
+
+// grade maps a score to a school grade
+func grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+func (r *EntryReport) add(check Check, severity Severity, detail string) {
+	r.Findings = append(r.Findings, Finding{Check: check, Severity: severity, Detail: detail})
+}
+
+// checkReused reports passwords shared by several entries
+func checkReused(entries []*models.Entry, reports map[string]*EntryReport) {
+	byPassword := make(map[string][]*models.Entry)
+	for _, entry := range entries {
+		if entry.Password != "" {
+			byPassword[entry.Password] = append(byPassword[entry.Password], entry)
+		}
+	}
+
+	for _, entry := range entries {
+		shared := byPassword[entry.Password]
+		if len(shared) < 2 {
+			continue
+		}
+
+		var others []string
+		for _, other := range shared {
+			if other != entry {
+				others = append(others, other.Title)
+			}
+		}
+		sort.Strings(others)
+		reports[entry.ID].add(CheckReused, SeverityHigh,
+			fmt.Sprintf("Same password as %s", strings.Join(others, ", ")))
+	}
+}
+
+// checkWeak reports passwords below the minimum strength score
+func checkWeak(entry *models.Entry, report *EntryReport, opts Options) {
+	if entry.Password == "" {
+		report.add(CheckWeak, SeverityHigh, "No password set")
+		return
+	}
+
+	result := strength.Estimate(entry.Password, entry.Title, entry.Username, entry.URL)
+	report.Strength = result.Score
+	if result.Score >= opts.MinScore {
+		return
+	}
+
+	severity := SeverityMedium
+	if result.Score <= 1 {
+		severity = SeverityHigh
+	}
+
+	detail := fmt.Sprintf("%s (score %d/4), cracked in %s offline", result.Label(), result.Score,
+		strength.FormatCrackTime(result.CrackTimes.OfflineSlow))
+	if result.Feedback.Warning != "" {
+		detail += ": " + result.Feedback.Warning
+	}
+	report.add(CheckWeak, severity, detail)
+}
+
+// checkOld reports passwords not changed within the maximum age
+func checkOld(entry *models.Entry, report *EntryReport, opts Options) {
+	if opts.MaxAge <= 0 {
+		return
+	}
+
+	age := opts.Now.Sub(report.PasswordChangedAt)
+	if age <= opts.MaxAge {
+		return
+	}
+
+	severity := SeverityLow
+	if age > 2*opts.MaxAge {
+		severity = SeverityMedium
+	}
+	report.add(CheckOld, severity, fmt.Sprintf("Password not changed for %d days", int(age.Hours()/24)))
+}
+
+// checkNo2FA reports entries without a one-time password
+func checkNo2FA(entry *models.Entry, report *EntryReport) {
+	if entry.OTP == "" {
+		report.add(CheckNo2FA, SeverityLow, "No one-time password configured")
+	}
+}
+
+// checkInsecureURL reports URLs that send the login over plain HTTP. Local
+// addresses are not reported.
+func checkInsecureURL(entry *models.Entry, report *EntryReport) {
+	u, err := url.Parse(strings.TrimSpace(entry.URL))
+	if err != nil || !strings.EqualFold(u.Scheme, "http") {
+		return
+	}
+
+	host := u.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		return
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsLoopback() || ip.IsPrivate()) {
+		return
+	}
+	report.add(CheckInsecureURL, SeverityMedium, fmt.Sprintf("%s does not use HTTPS", u.Host))
+}
+
+// checkDuplicates reports entries for the same account
+func checkDuplicates(entries []*models.Entry, reports map[string]*EntryReport) {
+	for _, entry := range entries {
+		var others []string
+		for _, other := range entries {
+			if other != entry && entry.IsDuplicate(other) {
+				others = append(others, fmt.Sprintf("%s (%.8s)", other.Title, other.ID))
+			}
+		}
+		if len(others) > 0 {
+			sort.Strings(others)
+			reports[entry.ID].add(CheckDuplicate, SeverityLow,
+				fmt.Sprintf("Same account as %s", strings.Join(others, ", ")))
+		}
+	}
+}
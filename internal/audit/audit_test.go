@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+var auditNow = time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// cleanEntry returns an entry no check reports: a strong unique password,
+// two-factor authentication, an HTTPS URL and a recent change
+func cleanEntry(title string) *models.Entry {
+	entry := models.NewEntry(title, strings.ToLower(title)+"@example.com", "kX9#mQ2$vL7@pR4w-"+title)
+	entry.URL = "https://" + strings.ToLower(title) + ".example.com"
+	entry.OTP = "otpauth://totp/" + title + "?secret=JBSWY3DPEHPK3PXP"
+	entry.UpdatedAt = auditNow.Add(-24 * time.Hour)
+	return entry
+}
+
+// checksOf returns the checks reported for an entry, in report order
+func checksOf(report *EntryReport) []Check {
+	var checks []Check
+	if report == nil {
+		return checks
+	}
+	for _, finding := range report.Findings {
+		checks = append(checks, finding.Check)
+	}
+	return checks
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(target, other *models.Entry)
+		checks []Check // reported for the target entry
+		score  int     // of the target entry
+	}{
+		{
+			name:   "clean",
+			modify: func(target, other *models.Entry) {},
+			score:  100,
+		},
+		{
+			name:   "reused",
+			modify: func(target, other *models.Entry) { target.Password = other.Password },
+			checks: []Check{CheckReused},
+			score:  60,
+		},
+		{
+			name:   "weak",
+			modify: func(target, other *models.Entry) { target.Password = "P@ssw0rd" },
+			checks: []Check{CheckWeak},
+			score:  60,
+		},
+		{
+			name:   "empty password",
+			modify: func(target, other *models.Entry) { target.Password = "" },
+			checks: []Check{CheckWeak},
+			score:  60,
+		},
+		{
+			name:   "old",
+			modify: func(target, other *models.Entry) { target.UpdatedAt = auditNow.Add(-200 * 24 * time.Hour) },
+			checks: []Check{CheckOld},
+			score:  90,
+		},
+		{
+			name:   "very old",
+			modify: func(target, other *models.Entry) { target.UpdatedAt = auditNow.Add(-400 * 24 * time.Hour) },
+			checks: []Check{CheckOld},
+			score:  80,
+		},
+		{
+			name: "old password in a recently edited entry",
+			modify: func(target, other *models.Entry) {
+				target.History = []models.EntryVersion{{
+					Password:   "earlier-password",
+					ModifiedAt: auditNow.Add(-500 * 24 * time.Hour),
+					ReplacedAt: auditNow.Add(-300 * 24 * time.Hour),
+				}}
+			},
+			checks: []Check{CheckOld},
+			score:  90,
+		},
+		{
+			name:   "no-2fa",
+			modify: func(target, other *models.Entry) { target.OTP = "" },
+			checks: []Check{CheckNo2FA},
+			score:  90,
+		},
+		{
+			name:   "insecure-url",
+			modify: func(target, other *models.Entry) { target.URL = "http://target.example.com/login" },
+			checks: []Check{CheckInsecureURL},
+			score:  80,
+		},
+		{
+			name:   "local http URL",
+			modify: func(target, other *models.Entry) { target.URL = "http://192.168.1.1" },
+			score:  100,
+		},
+		{
+			name: "duplicate",
+			modify: func(target, other *models.Entry) {
+				target.Username = other.Username
+				target.URL = "https://www." + strings.TrimPrefix(other.URL, "https://")
+			},
+			checks: []Check{CheckDuplicate},
+			score:  90,
+		},
+		{
+			name: "several findings",
+			modify: func(target, other *models.Entry) {
+				target.Password = "password"
+				target.OTP = ""
+				target.URL = "http://target.example.com"
+			},
+			checks: []Check{CheckWeak, CheckNo2FA, CheckInsecureURL},
+			score:  30,
+		},
+		{
+			name: "score floor",
+			modify: func(target, other *models.Entry) {
+				other.Password = "password"
+				target.Password = "password"
+				target.OTP = ""
+				target.URL = "http://target.example.com"
+				target.UpdatedAt = auditNow.Add(-400 * 24 * time.Hour)
+			},
+			checks: []Check{CheckReused, CheckWeak, CheckOld, CheckNo2FA, CheckInsecureURL},
+			score:  0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target, other := cleanEntry("Target"), cleanEntry("Other")
+			test.modify(target, other)
+
+			opts := DefaultOptions()
+			opts.Now = auditNow
+			report := Run([]*models.Entry{target, other}, opts)
+
+			var targetReport *EntryReport
+			for _, entry := range report.Entries {
+				if entry.ID == target.ID {
+					targetReport = entry
+				}
+			}
+
+			if got := checksOf(targetReport); !slices.Equal(got, test.checks) {
+				t.Errorf("checks = %v, want %v", got, test.checks)
+			}
+			score := 100
+			if targetReport != nil {
+				score = targetReport.Score
+			}
+			if score != test.score {
+				t.Errorf("score = %d, want %d", score, test.score)
+			}
+
+			summary := make(map[Check]int)
+			for _, entry := range report.Entries {
+				for _, check := range checksOf(entry) {
+					summary[check]++
+				}
+			}
+			for _, check := range Checks {
+				if report.Summary[check] != summary[check] {
+					t.Errorf("summary[%s] = %d, want %d", check, report.Summary[check], summary[check])
+				}
+			}
+		})
+	}
+}
+
+func TestRunSummary(t *testing.T) {
+	clean := cleanEntry("Clean")
+	weak := cleanEntry("Weak")
+	weak.Password = "qwertyuiop"
+	noOTP := cleanEntry("Mail")
+	noOTP.OTP = ""
+
+	opts := DefaultOptions()
+	opts.Now = auditNow
+	report := Run([]*models.Entry{clean, noOTP, weak}, opts)
+
+	if report.Total != 3 {
+		t.Errorf("total = %d, want 3", report.Total)
+	}
+	// Entry scores 100, 90 and 60
+	if report.Score != 83 || report.Grade != "B" {
+		t.Errorf("score %d grade %s, want 83 and B", report.Score, report.Grade)
+	}
+	if len(report.Entries) != 2 || report.Entries[0].ID != weak.ID || report.Entries[1].ID != noOTP.ID {
+		t.Errorf("entries with findings are not listed worst first: %+v", report.Entries)
+	}
+	if report.MaxAgeDays != 180 || report.MinStrength != 3 {
+		t.Errorf("max age %d days, min strength %d, want 180 and 3", report.MaxAgeDays, report.MinStrength)
+	}
+
+	if empty := Run(nil, opts); empty.Score != 100 || empty.Grade != "A" || len(empty.Entries) != 0 {
+		t.Errorf("empty vault report = %+v", empty)
+	}
+}
+
+func TestReportsLeaveOutPasswords(t *testing.T) {
+	entry := cleanEntry("Mail")
+	entry.Password = "hunter2-reused"
+	other := cleanEntry("Bank")
+	other.Password = entry.Password
+
+	opts := DefaultOptions()
+	opts.Now = auditNow
+	report := Run([]*models.Entry{entry, other}, opts)
+
+	for format, write := range map[string]func(*bytes.Buffer) error{
+		FormatJSON: func(buf *bytes.Buffer) error { return WriteJSON(buf, report) },
+		FormatHTML: func(buf *bytes.Buffer) error { return WriteHTML(buf, report) },
+	} {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if strings.Contains(buf.String(), entry.Password) {
+			t.Errorf("%s report contains a password", format)
+		}
+		if !strings.Contains(buf.String(), "Mail") {
+			t.Errorf("%s report does not name the entry", format)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+)
+
+// Output formats of a report besides the terminal table
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatHTML  = "html"
+)
+
+// Formats returns the supported report formats
+func Formats() []string {
+	return []string{FormatTable, FormatJSON, FormatHTML}
+}
+
+//go:embed templates/report.html
+var htmlTemplate string
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"checks": func() []Check { return Checks },
+}).Parse(htmlTemplate))
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// WriteHTML writes the report as a standalone HTML page
+func WriteHTML(w io.Writer, report *Report) error {
+	if err := reportTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("failed to render report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Package breach checks passwords against Pwned Passwords style breach data.
+// Passwords are only ever handled as SHA-1 hashes and never written anywhere.
+package breach
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// Source looks up how often a password appeared in breaches
+type Source interface {
+	// Count returns how many times the password with the given uppercase
+	// hex SHA-1 hash was seen, 0 if never
+	Count(hash string) (int, error)
+}
+
+// Result is an entry whose password was found in breach data
+type Result struct {
+	Entry *models.Entry
+	Count int // times the password was seen in breaches
+}
+
+// HashPassword returns the uppercase hex SHA-1 hash of password, the form
+// used by Pwned Passwords
+func HashPassword(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// Check looks up the password of every entry in source and returns the
+// entries whose password was found, most common first. Each distinct
+// password is looked up once.
+func Check(entries []*models.Entry, source Source) ([]Result, error) {
+	counts := make(map[string]int)
+	var results []Result
+
+	for _, entry := range entries {
+		if entry.Password == "" {
+			continue
+		}
+
+		hash := HashPassword(entry.Password)
+		count, checked := counts[hash]
+		if !checked {
+			var err error
+			count, err = source.Count(hash)
+			if err != nil {
+				return nil, err
+			}
+			counts[hash] = count
+		}
+
+		if count > 0 {
+			results = append(results, Result{Entry: entry, Count: count})
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Entry.Title < results[j].Entry.Title
+	})
+	return results, nil
+}
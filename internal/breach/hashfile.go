@@ -0,0 +1,217 @@
+package breach
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxLineLength bounds a line of a hash file, "HASH:COUNT" plus line ending
+const maxLineLength = 128
+
+// HashFile is a Pwned Passwords SHA-1 file ordered by hash, with one
+// "HASH:COUNT" line per password. It is searched in place, so even the full
+// dataset needs no index or extra disk space.
+type HashFile struct {
+	file *os.File
+	size int64
+}
+
+// OpenHashFile opens a hash file and checks that it holds SHA-1 hashes
+// ordered by hash, not by prevalence
+func OpenHashFile(path string) (*HashFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	h := &HashFile{file: file, size: info.Size()}
+	if err := h.validate(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return h, nil
+}
+
+// Close closes the file
+func (h *HashFile) Close() error {
+	return h.file.Close()
+}
+
+// Count returns how many times the password with the given hash was seen,
+// using a binary search over the lines of the file
+func (h *HashFile) Count(hash string) (int, error) {
+	hash = strings.ToUpper(hash)
+
+	// lo is always the start of a line; the line for hash, if any, starts in [lo, hi)
+	lo, hi := int64(0), h.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+
+		start := mid
+		if mid > lo {
+			var err error
+			if start, err = h.nextLineStart(mid); err != nil {
+				return 0, err
+			}
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		line, next, err := h.readLine(start)
+		if err != nil {
+			return 0, err
+		}
+		if strings.TrimSpace(line) == "" {
+			// Only blank lines can follow, at the end of the file
+			hi = mid
+			continue
+		}
+		lineHash, count, err := parseHashLine(line)
+		if err != nil {
+			return 0, fmt.Errorf("invalid line at offset %d: %w", start, err)
+		}
+
+		switch {
+		case lineHash == hash:
+			return count, nil
+		case lineHash < hash:
+			lo = next
+		default:
+			hi = mid
+		}
+	}
+	return 0, nil
+}
+
+// validationSamples is the number of lines spread over the file that
+// validate checks are in order
+const validationSamples = 16
+
+// validate checks that lines spread over the file are SHA-1 hashes in order
+func (h *HashFile) validate() error {
+	if h.size == 0 {
+		return errors.New("the hash file is empty")
+	}
+
+	lastStart, err := h.lastLineStart()
+	if err != nil {
+		return err
+	}
+
+	previous := ""
+	for i := 0; i <= validationSamples; i++ {
+		start := lastStart
+		if i < validationSamples {
+			if start, err = h.nextLineStart(max(h.size*int64(i)/validationSamples, 1)); err != nil {
+				return err
+			}
+			if i == 0 {
+				start = 0
+			}
+			start = min(start, lastStart)
+		}
+
+		line, _, err := h.readLine(start)
+		if err != nil {
+			return err
+		}
+		hash, _, err := parseHashLine(line)
+		if err != nil {
+			return fmt.Errorf("not a Pwned Passwords SHA-1 file: %w", err)
+		}
+		if hash < previous {
+			return errors.New("the hash file is not ordered by hash, download the SHA-1 file ordered by hash")
+		}
+		previous = hash
+	}
+	return nil
+}
+
+// readLine returns the line starting at offset without its line ending, and
+// the offset of the next line
+func (h *HashFile) readLine(offset int64) (string, int64, error) {
+	buf := make([]byte, maxLineLength)
+	n, err := h.file.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return "", 0, err
+	}
+	buf = buf[:n]
+
+	end := bytes.IndexByte(buf, '\n')
+	next := offset + int64(end) + 1
+	if end < 0 {
+		if offset+int64(n) < h.size {
+			return "", 0, fmt.Errorf("line at offset %d is too long", offset)
+		}
+		end, next = n, h.size
+	}
+	return strings.TrimRight(string(buf[:end]), "\r"), next, nil
+}
+
+// nextLineStart returns the start of the first line beginning after offset-1,
+// that is offset itself if a line starts there
+func (h *HashFile) nextLineStart(offset int64) (int64, error) {
+	buf := make([]byte, maxLineLength)
+	n, err := h.file.ReadAt(buf, offset-1)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	i := bytes.IndexByte(buf[:n], '\n')
+	if i < 0 {
+		if offset-1+int64(n) < h.size {
+			return 0, fmt.Errorf("line at offset %d is too long", offset)
+		}
+		return h.size, nil
+	}
+	return offset + int64(i), nil
+}
+
+// lastLineStart returns the start of the last non-empty line
+func (h *HashFile) lastLineStart() (int64, error) {
+	size := min(h.size, maxLineLength)
+	buf := make([]byte, size)
+	if _, err := h.file.ReadAt(buf, h.size-size); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	trimmed := bytes.TrimRight(buf, "\r\n")
+	i := bytes.LastIndexByte(trimmed, '\n')
+	if i < 0 {
+		if size < h.size {
+			return 0, errors.New("the last line is too long")
+		}
+		return 0, nil
+	}
+	return h.size - size + int64(i) + 1, nil
+}
+
+// parseHashLine parses a "HASH:COUNT" line
+func parseHashLine(line string) (string, int, error) {
+	hash, countText, found := strings.Cut(line, ":")
+	if !found {
+		return "", 0, fmt.Errorf("expected HASH:COUNT, got %q", line)
+	}
+	if len(hash) != 40 || strings.Trim(strings.ToUpper(hash), "0123456789ABCDEF") != "" {
+		return "", 0, fmt.Errorf("expected a 40 character SHA-1 hash, got %q", hash)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(countText))
+	if err != nil || count < 0 {
+		return "", 0, fmt.Errorf("invalid count %q", countText)
+	}
+	return strings.ToUpper(hash), count, nil
+}
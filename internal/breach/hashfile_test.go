@@ -0,0 +1,102 @@
+package breach
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeHashFile writes the hashes, which must be ordered, with varying
+// counts to a hash file and returns its path and the count of each hash
+func writeHashFile(t *testing.T, hashes []string, newline string, trailing bool) (string, map[string]int) {
+	counts := make(map[string]int)
+	lines := make([]string, len(hashes))
+	for i, hash := range hashes {
+		// Counts of different widths give lines of different lengths
+		counts[hash] = (i*7919)%100000 + 1
+		lines[i] = fmt.Sprintf("%s:%d", hash, counts[hash])
+	}
+
+	content := strings.Join(lines, newline)
+	if trailing {
+		content += newline
+	}
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, counts
+}
+
+// sortedHashes returns n distinct ordered hashes
+func sortedHashes(n int) []string {
+	hashes := make([]string, n)
+	for i := range hashes {
+		hashes[i] = HashPassword(fmt.Sprintf("password %d", i))
+	}
+	slices.Sort(hashes)
+	return hashes
+}
+
+func TestHashFileCount(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 7, 100, 1001} {
+		for _, newline := range []string{"\n", "\r\n"} {
+			for _, trailing := range []bool{false, true} {
+				name := fmt.Sprintf("%d lines %q trailing %v", n, newline, trailing)
+				t.Run(name, func(t *testing.T) {
+					hashes := sortedHashes(n)
+					path, counts := writeHashFile(t, hashes, newline, trailing)
+
+					file, err := OpenHashFile(path)
+					if err != nil {
+						t.Fatal(err)
+					}
+					defer file.Close()
+
+					for _, hash := range hashes {
+						// Lookups ignore case
+						count, err := file.Count(strings.ToLower(hash))
+						if err != nil {
+							t.Fatal(err)
+						}
+						if count != counts[hash] {
+							t.Errorf("Count(%s) = %d, want %d", hash, count, counts[hash])
+						}
+					}
+
+					// Hashes before, between and after the lines of the file
+					missing := []string{strings.Repeat("0", 40), strings.Repeat("F", 40)}
+					for i := 0; len(missing) < 50 && i < 1000; i++ {
+						hash := HashPassword(fmt.Sprintf("missing %d", i))
+						if _, found := counts[hash]; !found {
+							missing = append(missing, hash)
+						}
+					}
+					for _, hash := range missing {
+						count, err := file.Count(hash)
+						if err != nil {
+							t.Fatal(err)
+						}
+						if count != 0 {
+							t.Errorf("Count(%s) = %d for a hash not in the file", hash, count)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestOpenHashFileRejectsUnorderedFiles(t *testing.T) {
+	hashes := sortedHashes(100)
+	slices.Reverse(hashes)
+	path, _ := writeHashFile(t, hashes, "\n", true)
+
+	if file, err := OpenHashFile(path); err == nil {
+		file.Close()
+		t.Error("a file ordered by prevalence was accepted")
+	}
+}
@@ -0,0 +1,225 @@
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAPIURL is the Pwned Passwords range API
+const DefaultAPIURL = "https://api.pwnedpasswords.com"
+
+// Defaults of a RangeClient
+const (
+	DefaultInterval = 100 * time.Millisecond
+	DefaultCacheTTL = 24 * time.Hour
+	maxAttempts     = 3
+	maxResponseSize = 4 << 20
+)
+
+// RangeClient looks up passwords in a Pwned Passwords compatible range API
+// using k-anonymity: only the first five hex characters of a hash are sent,
+// and the server answers with every hash suffix sharing that prefix. Padding
+// is requested so the response size reveals nothing either.
+type RangeClient struct {
+	BaseURL    string        // API root, /range/<prefix> is appended
+	HTTPClient *http.Client  // nil uses a client with a 30 second timeout
+	Interval   time.Duration // minimum time between requests
+	CacheDir   string        // directory for cached range responses, "" to cache in memory only
+	CacheTTL   time.Duration // how long cached responses are used
+	UserAgent  string
+	Requests   int // number of requests sent to the API
+
+	mutex       sync.Mutex
+	lastRequest time.Time
+	ranges      map[string]map[string]int // prefix to suffix counts
+}
+
+// NewRangeClient returns a client for the API at baseURL, or DefaultAPIURL if empty
+func NewRangeClient(baseURL string) *RangeClient {
+	if baseURL == "" {
+		baseURL = DefaultAPIURL
+	}
+	return &RangeClient{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+		Interval:   DefaultInterval,
+		CacheTTL:   DefaultCacheTTL,
+		UserAgent:  "gopassman",
+	}
+}
+
+// Count returns how many times the password with the given hash was seen
+func (c *RangeClient) Count(hash string) (int, error) {
+	hash = strings.ToUpper(hash)
+	if len(hash) != 40 {
+		return 0, fmt.Errorf("invalid SHA-1 hash")
+	}
+
+	counts, err := c.lookupRange(hash[:5])
+	if err != nil {
+		return 0, err
+	}
+	return counts[hash[5:]], nil
+}
+
+// lookupRange returns the suffix counts for prefix from the memory cache,
+// the disk cache or the API
+func (c *RangeClient) lookupRange(prefix string) (map[string]int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if counts, ok := c.ranges[prefix]; ok {
+		return counts, nil
+	}
+
+	body, cached := c.readCache(prefix)
+	if !cached {
+		var err error
+		if body, err = c.fetchRange(prefix); err != nil {
+			return nil, err
+		}
+		c.writeCache(prefix, body)
+	}
+
+	counts, err := parseRange(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response for range %s: %w", prefix, err)
+	}
+
+	if c.ranges == nil {
+		c.ranges = make(map[string]map[string]int)
+	}
+	c.ranges[prefix] = counts
+	return counts, nil
+}
+
+// fetchRange requests a range from the API, waiting between requests and
+// retrying when the server asks to slow down
+func (c *RangeClient) fetchRange(prefix string) ([]byte, error) {
+	client := c.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	for attempt := 1; ; attempt++ {
+		if wait := c.Interval - time.Since(c.lastRequest); wait > 0 {
+			time.Sleep(wait)
+		}
+		c.lastRequest = time.Now()
+
+		req, err := http.NewRequest(http.MethodGet, c.BaseURL+"/range/"+prefix, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Add-Padding", "true")
+		if c.UserAgent != "" {
+			req.Header.Set("User-Agent", c.UserAgent)
+		}
+
+		c.Requests++
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("range request failed: %w", err)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read range response: %w", err)
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			return body, nil
+		case (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) && attempt < maxAttempts:
+			time.Sleep(retryAfter(resp.Header.Get("Retry-After"), attempt))
+		default:
+			return nil, fmt.Errorf("range API returned %s", resp.Status)
+		}
+	}
+}
+
+// retryAfter returns how long to wait before retrying, from the Retry-After
+// header in seconds or a growing delay
+func retryAfter(header string, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && seconds >= 0 {
+		return min(time.Duration(seconds)*time.Second, time.Minute)
+	}
+	return time.Duration(attempt) * time.Second
+}
+
+// parseRange parses "SUFFIX:COUNT" lines. Padding lines have a count of zero
+// and are dropped.
+func parseRange(body []byte) (map[string]int, error) {
+	counts := make(map[string]int)
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		suffix, countText, found := strings.Cut(line, ":")
+		if !found || len(suffix) != 35 {
+			return nil, fmt.Errorf("expected SUFFIX:COUNT, got %q", line)
+		}
+		count, err := strconv.Atoi(strings.TrimSpace(countText))
+		if err != nil || count < 0 {
+			return nil, fmt.Errorf("invalid count %q", countText)
+		}
+		if count > 0 {
+			counts[strings.ToUpper(suffix)] = count
+		}
+	}
+	return counts, scanner.Err()
+}
+
+// cachePath returns the file a range is cached in. The cache key includes
+// the API so responses of different mirrors are kept apart.
+func (c *RangeClient) cachePath(prefix string) string {
+	api := strings.NewReplacer("://", "_", "/", "_", ":", "_").Replace(c.BaseURL)
+	return filepath.Join(c.CacheDir, api, prefix)
+}
+
+// readCache returns a cached range response younger than CacheTTL
+func (c *RangeClient) readCache(prefix string) ([]byte, bool) {
+	if c.CacheDir == "" || c.CacheTTL <= 0 {
+		return nil, false
+	}
+
+	path := c.cachePath(prefix)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > c.CacheTTL {
+		return nil, false
+	}
+	body, err := os.ReadFile(path)
+	return body, err == nil
+}
+
+// writeCache stores a range response. Failures only cost a request later.
+func (c *RangeClient) writeCache(prefix string, body []byte) {
+	if c.CacheDir == "" || c.CacheTTL <= 0 {
+		return
+	}
+
+	path := c.cachePath(prefix)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, body, 0600); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
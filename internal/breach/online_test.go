@@ -0,0 +1,84 @@
+package breach
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// rangeServer serves the range API for the given passwords and their counts,
+// padded with a zero count line, and records the requested paths
+func rangeServer(t *testing.T, counts map[string]int, paths *[]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*paths = append(*paths, r.URL.Path)
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Errorf("request without padding")
+		}
+
+		prefix := strings.TrimPrefix(r.URL.Path, "/range/")
+		for password, count := range counts {
+			if hash := HashPassword(password); hash[:5] == prefix {
+				fmt.Fprintf(w, "%s:%d\r\n", hash[5:], count)
+			}
+		}
+		fmt.Fprintf(w, "%s:0\r\n", strings.Repeat("0", 35))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestRangeClientCount(t *testing.T) {
+	var paths []string
+	server := rangeServer(t, map[string]int{"password": 10434004, "hunter2": 35}, &paths)
+
+	client := NewRangeClient(server.URL)
+	client.Interval = 0
+
+	entries := []*models.Entry{
+		{Title: "Mail", Password: "password"},
+		{Title: "Bank", Password: "hunter2"},
+		{Title: "Shop", Password: "password"},
+		{Title: "Safe", Password: "a password nobody used"},
+	}
+	results, err := Check(entries, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"Mail:10434004", "Shop:10434004", "Bank:35"}
+	if len(results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(results), len(want))
+	}
+	for i, result := range results {
+		if got := fmt.Sprintf("%s:%d", result.Entry.Title, result.Count); got != want[i] {
+			t.Errorf("result %d = %s, want %s", i, got, want[i])
+		}
+	}
+
+	// Only the prefix leaves the machine, and each range is fetched once
+	if len(paths) != 3 || client.Requests != 3 {
+		t.Errorf("got %d requests, want 3: %v", len(paths), paths)
+	}
+	for _, path := range paths {
+		if len(path) != len("/range/")+5 {
+			t.Errorf("request path %q does not end in a 5 character prefix", path)
+		}
+	}
+}
+
+func TestRangeClientRejectsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewRangeClient(server.URL)
+	client.Interval = 0
+	if _, err := client.Count(HashPassword("password")); err == nil {
+		t.Error("Count succeeded on a 403 response")
+	}
+}
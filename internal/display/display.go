@@ -2,12 +2,18 @@ package display
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/fatih/color"
 
+	"github.com/egemengunel/Go-Password-Manager/internal/audit"
+	"github.com/egemengunel/Go-Password-Manager/internal/breach"
+	"github.com/egemengunel/Go-Password-Manager/internal/generator"
+	"github.com/egemengunel/Go-Password-Manager/internal/otp"
+	"github.com/egemengunel/Go-Password-Manager/internal/strength"
 	"github.com/egemengunel/Go-Password-Manager/models"
 )
 
@@ -40,8 +46,14 @@ func Warning(message string) {
 	warningColor.Printf("⚠ %s\n", message)
 }
 
-// Title prints a section title
+// ActiveVault is the name of the vault commands work on, shown in titles
+var ActiveVault string
+
+// Title prints a section title followed by the active vault
 func Title(message string) {
+	if ActiveVault != "" {
+		message = fmt.Sprintf("%s [%s]", message, ActiveVault)
+	}
 	titleColor.Printf("\n%s\n", message)
 	titleColor.Printf("%s\n\n", strings.Repeat("=", len(message)))
 }
@@ -54,6 +66,14 @@ func MaskPassword(password string) string {
 	return strings.Repeat("*", min(len(password), 8))
 }
 
+// ShortID returns the leading part of an entry ID, enough to select it by prefix
+func ShortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
 // FormatTime formats a time for display
 func FormatTime(t time.Time) string {
 	return t.Format("2006-01-02 15:04:05")
@@ -77,24 +97,54 @@ func FormatTimeAgo(t time.Time) string {
 	}
 }
 
-// ListEntries displays entries in a table format
+// SortEntries orders entries by title, breaking ties by ID, so the list
+// numbers that show, edit and delete accept are the same on every run
+func SortEntries(entries []*models.Entry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Title != entries[j].Title {
+			return entries[i].Title < entries[j].Title
+		}
+		return entries[i].ID < entries[j].ID
+	})
+}
+
+// EntryNumbers returns the list number of every entry by ID, counting from 1
+// in SortEntries order
+func EntryNumbers(entries []*models.Entry) map[string]int {
+	sorted := append([]*models.Entry(nil), entries...)
+	SortEntries(sorted)
+
+	numbers := make(map[string]int, len(sorted))
+	for i, entry := range sorted {
+		numbers[entry.ID] = i + 1
+	}
+	return numbers
+}
+
+// ListEntries displays all entries in a table format, numbered in SortEntries order
 func ListEntries(entries []*models.Entry, showPasswords bool) {
+	ListNumberedEntries(entries, EntryNumbers(entries), showPasswords)
+}
+
+// ListNumberedEntries displays a subset of the entries, such as search
+// results, with their numbers from the full list. Entries are shown in
+// number order.
+func ListNumberedEntries(entries []*models.Entry, numbers map[string]int, showPasswords bool) {
 	if len(entries) == 0 {
 		Info("No entries found")
 		return
 	}
 
-	// Sort entries by title
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Title < entries[j].Title
+	sort.SliceStable(entries, func(i, j int) bool {
+		return numbers[entries[i].ID] < numbers[entries[j].ID]
 	})
 
 	// Simple table formatting without complex tablewriter features
-	fmt.Printf("%-3s %-20s %-20s %-15s %-30s %-15s\n",
-		"#", "Title", "Username", "Password", "URL", "Updated")
-	fmt.Printf("%s\n", strings.Repeat("-", 100))
+	fmt.Printf("%-3s %-8s %-20s %-20s %-15s %-30s %-15s\n",
+		"#", "ID", "Title", "Username", "Password", "URL", "Updated")
+	fmt.Printf("%s\n", strings.Repeat("-", 109))
 
-	for i, entry := range entries {
+	for _, entry := range entries {
 		password := MaskPassword(entry.Password)
 		if showPasswords {
 			password = entry.Password
@@ -115,8 +165,8 @@ func ListEntries(entries []*models.Entry, showPasswords bool) {
 			username = username[:15] + "..."
 		}
 
-		fmt.Printf("%-3d %-20s %-20s %-15s %-30s %-15s\n",
-			i+1, title, username, password, url, FormatTimeAgo(entry.UpdatedAt))
+		fmt.Printf("%-3d %-8s %-20s %-20s %-15s %-30s %-15s\n",
+			numbers[entry.ID], ShortID(entry.ID), title, username, password, url, FormatTimeAgo(entry.UpdatedAt))
 	}
 
 	fmt.Printf("\nTotal: %d entries\n", len(entries))
@@ -144,6 +194,10 @@ func ShowEntryDetails(entry *models.Entry, showPassword bool) {
 		fmt.Printf("Notes:      %s\n", entry.Notes)
 	}
 
+	if entry.Folder != "" {
+		fmt.Printf("Folder:     %s\n", entry.Folder)
+	}
+
 	if len(entry.Tags) > 0 {
 		fmt.Printf("Tags:       %s\n", strings.Join(entry.Tags, ", "))
 	}
@@ -156,11 +210,153 @@ func ShowEntryDetails(entry *models.Entry, showPassword bool) {
 		fmt.Println()
 	}
 
+	if entry.OTP != "" {
+		fmt.Printf("OTP:        %s\n", otpSummary(entry.OTP))
+	}
+
+	if entry.Policy != nil {
+		length := generator.OptionsFromPolicy(entry.Policy).EffectiveLength()
+		policy := fmt.Sprintf("length %d", length)
+		if entry.Policy.Rules != "" {
+			policy = fmt.Sprintf("%s (length %d)", entry.Policy.Rules, length)
+		}
+		fmt.Printf("Policy:     %s\n", policy)
+	}
+
+	if entry.Compromised != nil {
+		fmt.Printf("Breached:   ")
+		errorColor.Printf("seen %d times in breaches", entry.Compromised.Count)
+		fmt.Printf(" (%s, checked %s)\n", entry.Compromised.Source, FormatTime(entry.Compromised.CheckedAt))
+	}
+
 	fmt.Printf("Created:    %s\n", FormatTime(entry.CreatedAt))
 	fmt.Printf("Updated:    %s\n", FormatTime(entry.UpdatedAt))
 	fmt.Printf("Accessed:   %s\n", FormatTime(entry.AccessedAt))
 }
 
+// ListTrash displays trashed entries in a table format, in the order given.
+// retention is how long entries stay in the trash, zero if they are kept until purged.
+func ListTrash(items []*models.TrashedEntry, retention time.Duration) {
+	if len(items) == 0 {
+		Info("The trash is empty")
+		return
+	}
+
+	fmt.Printf("%-3s %-8s %-20s %-20s %-15s %-15s\n", "#", "ID", "Title", "Username", "Deleted", "Purged in")
+	fmt.Printf("%s\n", strings.Repeat("-", 86))
+
+	for i, item := range items {
+		title := item.Entry.Title
+		if len(title) > 18 {
+			title = title[:15] + "..."
+		}
+
+		username := item.Entry.Username
+		if len(username) > 18 {
+			username = username[:15] + "..."
+		}
+
+		purge := "never"
+		if retention > 0 {
+			days := int(math.Ceil(time.Until(item.DeletedAt.Add(retention)).Hours() / 24))
+			purge = fmt.Sprintf("%d days", max(days, 0))
+		}
+
+		fmt.Printf("%-3d %-8s %-20s %-20s %-15s %-15s\n",
+			i+1, ShortID(item.Entry.ID), title, username, FormatTimeAgo(item.DeletedAt), purge)
+	}
+
+	fmt.Printf("\nTotal: %d entries in trash\n", len(items))
+}
+
+// ShowEntryHistory displays the previous versions of an entry, newest first.
+// Each version lists the fields that changed when it was replaced.
+func ShowEntryHistory(entry *models.Entry, showPasswords bool) {
+	if len(entry.History) == 0 {
+		Info("No previous versions")
+		return
+	}
+
+	fmt.Printf("%-3s %-20s %-24s %-20s %-15s\n", "#", "Replaced", "Changed", "Username", "Password")
+	fmt.Printf("%s\n", strings.Repeat("-", 86))
+
+	newer := entry.Version()
+	for n := 1; n <= len(entry.History); n++ {
+		version, _ := entry.HistoryVersion(n)
+
+		password := MaskPassword(version.Password)
+		if showPasswords {
+			password = version.Password
+		}
+
+		changed := strings.Join(version.Changed(newer), ", ")
+		if len(changed) > 22 {
+			changed = changed[:19] + "..."
+		}
+
+		username := version.Username
+		if len(username) > 18 {
+			username = username[:15] + "..."
+		}
+
+		fmt.Printf("%-3d %-20s %-24s %-20s %-15s\n",
+			n, FormatTime(version.ReplacedAt), changed, username, password)
+		newer = version
+	}
+
+	fmt.Printf("\nTotal: %d versions\n", len(entry.History))
+}
+
+// otpSummary describes an entry's one-time password. TOTP codes are shown
+// directly; HOTP codes are not, since generating one advances the counter.
+func otpSummary(uri string) string {
+	key, err := otp.Parse(uri)
+	if err != nil {
+		return fmt.Sprintf("invalid otpauth URI (%v)", err)
+	}
+
+	if key.Type == otp.TypeHOTP {
+		return fmt.Sprintf("HOTP, counter %d (run 'gopassman otp' for the next code)", key.Counter)
+	}
+
+	now := time.Now()
+	code, err := key.Code(now)
+	if err != nil {
+		return fmt.Sprintf("invalid otpauth URI (%v)", err)
+	}
+	return fmt.Sprintf("%s (%ds left)", otp.FormatCode(code), int(key.Remaining(now).Seconds()))
+}
+
+// ShowSyncConflict describes an entry changed differently on both sides of
+// a sync. local or remote is nil if that side deleted the entry; base is the
+// version both started from, nil if the entry is new on both sides.
+func ShowSyncConflict(local, remote, base *models.Entry) {
+	entry := local
+	if entry == nil {
+		entry = remote
+	}
+
+	Warning(fmt.Sprintf("'%s' (%s) was changed on both sides", entry.Title, ShortID(entry.ID)))
+	fmt.Printf("  Local:   %s\n", SyncChange(local, base))
+	fmt.Printf("  Remote:  %s\n", SyncChange(remote, base))
+}
+
+// SyncChange summarizes how one side of a sync changed an entry
+func SyncChange(entry, base *models.Entry) string {
+	if entry == nil {
+		return "deleted"
+	}
+	if base == nil {
+		return fmt.Sprintf("added, updated %s", FormatTime(entry.UpdatedAt))
+	}
+
+	change := "changed other fields"
+	if fields := base.Version().Changed(entry.Version()); len(fields) > 0 {
+		change = "changed " + strings.Join(fields, ", ")
+	}
+	return fmt.Sprintf("%s, updated %s", change, FormatTime(entry.UpdatedAt))
+}
+
 // ConfirmAction prompts for confirmation before dangerous actions
 func ConfirmAction(action, target string) bool {
 	warningColor.Printf("⚠ Are you sure you want to %s '%s'? This action cannot be undone.\n", action, target)
@@ -180,6 +376,9 @@ func ShowVaultInfo(vault *models.Vault) {
 	fmt.Printf("Created:    %s\n", FormatTime(vault.CreatedAt))
 	fmt.Printf("Updated:    %s\n", FormatTime(vault.UpdatedAt))
 	fmt.Printf("Entries:    %d\n", len(vault.Entries))
+	if len(vault.Trash) > 0 {
+		fmt.Printf("Trash:      %d\n", len(vault.Trash))
+	}
 
 	if len(vault.Metadata) > 0 {
 		fmt.Printf("Metadata:   ")
@@ -190,52 +389,138 @@ func ShowVaultInfo(vault *models.Vault) {
 	}
 }
 
-// ShowPasswordStrength displays password strength information
-func ShowPasswordStrength(password string) {
-	length := len(password)
-	hasUpper := strings.ContainsAny(password, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
-	hasLower := strings.ContainsAny(password, "abcdefghijklmnopqrstuvwxyz")
-	hasDigit := strings.ContainsAny(password, "0123456789")
-	hasSymbol := strings.ContainsAny(password, "!@#$%^&*()_+-=[]{}|;:,.<>?")
+// ShowPasswordStrength displays the estimated strength of a password, how
+// long it would take to crack and how to improve it. userInputs are values
+// the password should not be based on, like the entry's title and username.
+func ShowPasswordStrength(password string, userInputs ...string) {
+	result := strength.Estimate(password, userInputs...)
 
-	score := 0
-	if length >= 8 {
-		score++
+	var strengthColor *color.Color
+	switch {
+	case result.Score >= 3:
+		strengthColor = color.New(color.FgGreen)
+	case result.Score == 2:
+		strengthColor = color.New(color.FgYellow)
+	default:
+		strengthColor = color.New(color.FgRed)
 	}
-	if length >= 12 {
-		score++
+
+	fmt.Printf("Password strength: ")
+	strengthColor.Printf("%s", result.Label())
+	fmt.Printf(" (Score: %d/4, ~10^%.0f guesses)\n", result.Score, math.Floor(result.GuessesLog10))
+	fmt.Printf("Time to crack: %s online, %s offline (slow hash), %s offline (fast hash)\n",
+		strength.FormatCrackTime(result.CrackTimes.OnlineThrottled),
+		strength.FormatCrackTime(result.CrackTimes.OfflineSlow),
+		strength.FormatCrackTime(result.CrackTimes.OfflineFast))
+
+	if result.Feedback.Warning != "" {
+		Warning(result.Feedback.Warning)
 	}
-	if hasUpper {
-		score++
+	for _, suggestion := range result.Feedback.Suggestions {
+		fmt.Printf("  • %s\n", suggestion)
 	}
-	if hasLower {
-		score++
+}
+
+// ShowAuditReport displays a security audit as a summary and a table of findings
+func ShowAuditReport(report *audit.Report) {
+	Title("Security Audit")
+
+	scoreColor := color.New(color.FgGreen, color.Bold)
+	switch {
+	case report.Score < 60:
+		scoreColor = color.New(color.FgRed, color.Bold)
+	case report.Score < 80:
+		scoreColor = color.New(color.FgYellow, color.Bold)
 	}
-	if hasDigit {
-		score++
+
+	fmt.Printf("Score:      ")
+	scoreColor.Printf("%d/100 (%s)\n", report.Score, report.Grade)
+	fmt.Printf("Entries:    %d\n\n", report.Total)
+
+	for _, check := range audit.Checks {
+		count := report.Summary[check]
+		countColor := color.New(color.FgGreen)
+		if count > 0 {
+			countColor = color.New(color.FgRed)
+		}
+		fmt.Printf("%-30s ", check.Description())
+		countColor.Printf("%d\n", count)
 	}
-	if hasSymbol {
-		score++
+	fmt.Printf("\nWeak means a strength score below %d/4", report.MinStrength)
+	if report.MaxAgeDays > 0 {
+		fmt.Printf(", old means not changed for %d days", report.MaxAgeDays)
 	}
+	fmt.Println()
 
-	var strength string
-	var strengthColor *color.Color
+	if len(report.Entries) == 0 {
+		fmt.Println()
+		Success("No problems found")
+		return
+	}
 
-	switch {
-	case score >= 5:
-		strength = "Strong"
-		strengthColor = color.New(color.FgGreen)
-	case score >= 3:
-		strength = "Medium"
-		strengthColor = color.New(color.FgYellow)
-	default:
-		strength = "Weak"
-		strengthColor = color.New(color.FgRed)
+	fmt.Println()
+	fmt.Printf("%-8s %-20s %-20s %-5s %-8s %-18s %s\n", "ID", "Title", "Username", "Score", "Severity", "Issue", "Details")
+	fmt.Printf("%s\n", strings.Repeat("-", 120))
+
+	for _, entry := range report.Entries {
+		title := entry.Title
+		if len(title) > 18 {
+			title = title[:15] + "..."
+		}
+
+		username := entry.Username
+		if len(username) > 18 {
+			username = username[:15] + "..."
+		}
+
+		for i, finding := range entry.Findings {
+			if i == 0 {
+				fmt.Printf("%-8s %-20s %-20s %-5d ", ShortID(entry.ID), title, username, entry.Score)
+			} else {
+				fmt.Printf("%-8s %-20s %-20s %-5s ", "", "", "", "")
+			}
+
+			severityColor := color.New(color.FgWhite)
+			switch finding.Severity {
+			case audit.SeverityHigh:
+				severityColor = color.New(color.FgRed, color.Bold)
+			case audit.SeverityMedium:
+				severityColor = color.New(color.FgYellow)
+			}
+			severityColor.Printf("%-8s", finding.Severity)
+			fmt.Printf(" %-18s %s\n", finding.Check, finding.Detail)
+		}
 	}
 
-	fmt.Printf("Password strength: ")
-	strengthColor.Printf("%s", strength)
-	fmt.Printf(" (Length: %d, Score: %d/6)\n", length, score)
+	fmt.Printf("\nTotal: %d entries with problems\n", len(report.Entries))
+}
+
+// ListBreachedEntries displays the entries whose passwords were found in breach data
+func ListBreachedEntries(results []breach.Result) {
+	fmt.Printf("%-3s %-8s %-20s %-20s %-30s %s\n", "#", "ID", "Title", "Username", "URL", "Seen in breaches")
+	fmt.Printf("%s\n", strings.Repeat("-", 100))
+
+	for i, result := range results {
+		entry := result.Entry
+
+		url := entry.URL
+		if len(url) > 28 {
+			url = url[:25] + "..."
+		}
+
+		title := entry.Title
+		if len(title) > 18 {
+			title = title[:15] + "..."
+		}
+
+		username := entry.Username
+		if len(username) > 18 {
+			username = username[:15] + "..."
+		}
+
+		fmt.Printf("%-3d %-8s %-20s %-20s %-30s ", i+1, ShortID(entry.ID), title, username, url)
+		errorColor.Printf("%d times\n", result.Count)
+	}
 }
 
 // Helper function for min
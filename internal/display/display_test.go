@@ -0,0 +1,31 @@
+package display
+
+import (
+	"testing"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+func TestEntryNumbersBreakTitleTiesByID(t *testing.T) {
+	entries := []*models.Entry{
+		{ID: "c3", Title: "GitHub"},
+		{ID: "a1", Title: "Mail"},
+		{ID: "b2", Title: "GitHub"},
+	}
+
+	// The input order must not matter, whichever way the session map yields entries
+	for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 2, 0}} {
+		shuffled := make([]*models.Entry, len(entries))
+		for i, index := range order {
+			shuffled[i] = entries[index]
+		}
+
+		numbers := EntryNumbers(shuffled)
+		want := map[string]int{"b2": 1, "c3": 2, "a1": 3}
+		for id, number := range want {
+			if numbers[id] != number {
+				t.Errorf("order %v: entry %s has number %d, want %d", order, id, numbers[id], number)
+			}
+		}
+	}
+}
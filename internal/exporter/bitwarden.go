@@ -0,0 +1,132 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// Bitwarden constants used in exports
+const (
+	bitwardenLogin     = 1
+	bitwardenTextField = 0
+	// bitwardenTagsField holds entry tags, which Bitwarden has no equivalent for
+	bitwardenTagsField = "tags"
+)
+
+type bitwardenExport struct {
+	Encrypted bool              `json:"encrypted"`
+	Folders   []bitwardenFolder `json:"folders"`
+	Items     []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	ID             string             `json:"id"`
+	OrganizationID *string            `json:"organizationId"`
+	FolderID       *string            `json:"folderId"`
+	Type           int                `json:"type"`
+	Reprompt       int                `json:"reprompt"`
+	Name           string             `json:"name"`
+	Notes          *string            `json:"notes"`
+	Favorite       bool               `json:"favorite"`
+	Fields         []bitwardenField   `json:"fields,omitempty"`
+	Login          bitwardenLoginData `json:"login"`
+	CollectionIDs  []string           `json:"collectionIds"`
+	CreationDate   time.Time          `json:"creationDate"`
+	RevisionDate   time.Time          `json:"revisionDate"`
+}
+
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	Type  int    `json:"type"`
+}
+
+type bitwardenLoginData struct {
+	URIs     []bitwardenURI `json:"uris,omitempty"`
+	Username string         `json:"username"`
+	Password string         `json:"password"`
+	TOTP     *string        `json:"totp"`
+}
+
+type bitwardenURI struct {
+	Match *int   `json:"match"`
+	URI   string `json:"uri"`
+}
+
+// writeBitwarden writes an unencrypted Bitwarden JSON export. Folders are
+// flattened to their full path, as Bitwarden names nested folders "a/b".
+func writeBitwarden(w io.Writer, entries []*models.Entry) error {
+	export := bitwardenExport{
+		Folders: make([]bitwardenFolder, 0),
+		Items:   make([]bitwardenItem, 0, len(entries)),
+	}
+
+	folderIDs := make(map[string]string)
+	for _, entry := range entries {
+		item := bitwardenItem{
+			ID:           entry.ID,
+			Type:         bitwardenLogin,
+			Name:         entry.Title,
+			CreationDate: entry.CreatedAt.UTC(),
+			RevisionDate: entry.UpdatedAt.UTC(),
+			Login: bitwardenLoginData{
+				Username: entry.Username,
+				Password: entry.Password,
+			},
+		}
+
+		if entry.Folder != "" {
+			id, ok := folderIDs[entry.Folder]
+			if !ok {
+				id = models.NewID()
+				folderIDs[entry.Folder] = id
+				export.Folders = append(export.Folders, bitwardenFolder{ID: id, Name: entry.Folder})
+			}
+			item.FolderID = &id
+		}
+
+		if entry.Notes != "" {
+			notes := entry.Notes
+			item.Notes = &notes
+		}
+		if entry.URL != "" {
+			item.Login.URIs = []bitwardenURI{{URI: entry.URL}}
+		}
+		if entry.OTP != "" {
+			totp := entry.OTP
+			item.Login.TOTP = &totp
+		}
+
+		for _, name := range sortedKeys(entry.Custom) {
+			item.Fields = append(item.Fields, bitwardenField{Name: name, Value: entry.Custom[name], Type: bitwardenTextField})
+		}
+
+		for _, tag := range entry.Tags {
+			if strings.EqualFold(tag, "favorite") {
+				item.Favorite = true
+			}
+		}
+		if len(entry.Tags) > 0 {
+			item.Fields = append(item.Fields, bitwardenField{
+				Name:  bitwardenTagsField,
+				Value: strings.Join(entry.Tags, ", "),
+				Type:  bitwardenTextField,
+			})
+		}
+
+		export.Items = append(export.Items, item)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"filippo.io/age/armor"
+)
+
+// PassphraseRecipient returns an age recipient that encrypts to a passphrase.
+// It cannot be combined with other recipients.
+func PassphraseRecipient(passphrase string) (age.Recipient, error) {
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid passphrase: %w", err)
+	}
+	return recipient, nil
+}
+
+// ParseRecipient parses an age public key (age1...) or an SSH public key
+func ParseRecipient(value string) (age.Recipient, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "ssh-") {
+		recipient, err := agessh.ParseRecipient(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SSH recipient: %w", err)
+		}
+		return recipient, nil
+	}
+
+	recipient, err := age.ParseX25519Recipient(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age recipient: %w", err)
+	}
+	return recipient, nil
+}
+
+// Encrypt returns a writer that age-encrypts everything written to it for the
+// given recipients. With armored set the output is PEM-style ASCII. The
+// returned writer must be closed to complete the file.
+func Encrypt(w io.Writer, armored bool, recipients ...age.Recipient) (io.WriteCloser, error) {
+	if !armored {
+		return age.Encrypt(w, recipients...)
+	}
+
+	armorWriter := armor.NewWriter(w)
+	encrypted, err := age.Encrypt(armorWriter, recipients...)
+	if err != nil {
+		return nil, err
+	}
+	return &armoredWriter{WriteCloser: encrypted, armor: armorWriter}, nil
+}
+
+// armoredWriter closes the age writer before the armor wrapping it
+type armoredWriter struct {
+	io.WriteCloser
+	armor io.WriteCloser
+}
+
+func (w *armoredWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return w.armor.Close()
+}
@@ -0,0 +1,101 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// Supported export formats
+const (
+	FormatBitwardenJSON = "bitwarden-json"
+	FormatKeePassCSV    = "keepass-csv"
+	FormatKeePassXML    = "keepass-xml"
+	FormatJSON          = "gopassman-json"
+	FormatKDBX          = "kdbx"
+)
+
+// writer serializes entries in an export format
+type writer func(w io.Writer, entries []*models.Entry) error
+
+var writers = map[string]writer{
+	FormatBitwardenJSON: writeBitwarden,
+	FormatKeePassCSV:    writeKeePassCSV,
+	FormatKeePassXML:    writeKeePassXML,
+	FormatJSON:          writeJSON,
+}
+
+// passwordWriter serializes entries in a format encrypted with a password
+type passwordWriter func(w io.Writer, entries []*models.Entry, password string) error
+
+var passwordWriters = map[string]passwordWriter{
+	FormatKDBX: writeKDBX,
+}
+
+// Formats returns the supported export format names
+func Formats() []string {
+	formats := make([]string, 0, len(writers)+len(passwordWriters))
+	for format := range writers {
+		formats = append(formats, format)
+	}
+	for format := range passwordWriters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// IsFormat reports whether format is a supported export format
+func IsFormat(format string) bool {
+	_, ok := writers[format]
+	return ok || NeedsPassword(format)
+}
+
+// NeedsPassword reports whether the format is encrypted with a password of
+// its own, which must be passed to Write
+func NeedsPassword(format string) bool {
+	_, ok := passwordWriters[format]
+	return ok
+}
+
+// Write serializes entries to w in the given format, ordered by folder and
+// title. The password is only used by encrypted formats.
+func Write(w io.Writer, format string, entries []*models.Entry, password string) error {
+	write, ok := writers[format]
+	if !ok {
+		writeEncrypted, encrypted := passwordWriters[format]
+		if !encrypted {
+			return fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+		}
+		write = func(w io.Writer, entries []*models.Entry) error {
+			return writeEncrypted(w, entries, password)
+		}
+	}
+
+	sorted := make([]*models.Entry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Folder != sorted[j].Folder {
+			return sorted[i].Folder < sorted[j].Folder
+		}
+		return strings.ToLower(sorted[i].Title) < strings.ToLower(sorted[j].Title)
+	})
+
+	if err := write(w, sorted); err != nil {
+		return fmt.Errorf("failed to write %s export: %w", format, err)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a custom field map in a stable order
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,370 @@
+package exporter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+
+	"github.com/egemengunel/Go-Password-Manager/internal/importer"
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// testEntries returns entries using every field an entry has
+func testEntries() []*models.Entry {
+	created := time.Date(2024, 5, 1, 9, 30, 0, 0, time.UTC)
+	return []*models.Entry{
+		{
+			ID:        models.NewID(),
+			Title:     "Bank",
+			Username:  "me@example.com",
+			Password:  "s3cr3t-ü",
+			URL:       "https://bank.example.com",
+			Notes:     "line one\nline two",
+			Folder:    "Finance",
+			Tags:      []string{"money", "important"},
+			OTP:       "otpauth://totp/Bank:me@example.com?algorithm=SHA1&digits=6&issuer=Bank&period=30&secret=JBSWY3DPEHPK3PXP",
+			Custom:    map[string]string{"PIN": "1234", "totp": "JBSWY3DPEHPK3PXP"},
+			CreatedAt: created,
+			UpdatedAt: created.Add(48 * time.Hour),
+		},
+		{
+			ID:        models.NewID(),
+			Title:     "Mail",
+			Username:  "me",
+			Password:  `quote " and <tag> & comma,`,
+			Tags:      []string{},
+			Custom:    map[string]string{},
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+	}
+}
+
+// byTitle indexes entries by their title
+func byTitle(entries []*models.Entry) map[string]*models.Entry {
+	index := make(map[string]*models.Entry, len(entries))
+	for _, entry := range entries {
+		index[entry.Title] = entry
+	}
+	return index
+}
+
+// writeTemp exports entries in format to a file and returns its path
+func writeTemp(t *testing.T, format string, entries []*models.Entry, password string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "export")
+	err := WriteFile(path, false, func(w io.Writer) error {
+		return Write(w, format, entries, password)
+	})
+	if err != nil {
+		t.Fatalf("export: %v", err)
+	}
+	return path
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	entries := testEntries()
+	path := writeTemp(t, FormatJSON, entries, "")
+
+	imported, err := importer.ParseFile(importer.FormatGopassmanJSON, path, "")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(imported) != len(entries) {
+		t.Fatalf("imported %d entries, want %d", len(imported), len(entries))
+	}
+
+	importedByTitle := byTitle(imported)
+	for _, want := range entries {
+		got := *importedByTitle[want.Title]
+		if got.ID == want.ID {
+			t.Errorf("entry %q kept its ID, imports must get fresh ones", want.Title)
+		}
+		got.ID = want.ID
+		if !reflect.DeepEqual(&got, want) {
+			t.Errorf("entry %q round trip:\n got %+v\nwant %+v", want.Title, &got, want)
+		}
+	}
+}
+
+func TestRoundTripThroughOtherManagers(t *testing.T) {
+	formats := map[string]string{
+		FormatBitwardenJSON: importer.FormatBitwardenJSON,
+		FormatKeePassXML:    importer.FormatKeePassXML,
+	}
+
+	for exportFormat, importFormat := range formats {
+		t.Run(exportFormat, func(t *testing.T) {
+			entries := testEntries()
+			path := writeTemp(t, exportFormat, entries, "")
+
+			imported, err := importer.ParseFile(importFormat, path, "")
+			if err != nil {
+				t.Fatalf("import: %v", err)
+			}
+			if len(imported) != len(entries) {
+				t.Fatalf("imported %d entries, want %d", len(imported), len(entries))
+			}
+
+			importedByTitle := byTitle(imported)
+			for _, want := range entries {
+				got, ok := importedByTitle[want.Title]
+				if !ok {
+					t.Errorf("entry %q is missing", want.Title)
+					continue
+				}
+				if got.Title != want.Title || got.Username != want.Username || got.Password != want.Password ||
+					got.URL != want.URL || got.Notes != want.Notes || got.Folder != want.Folder || got.OTP != want.OTP {
+					t.Errorf("entry %q round trip:\n got %+v\nwant %+v", want.Title, got, want)
+				}
+				if !got.CreatedAt.Equal(want.CreatedAt) || !got.UpdatedAt.Equal(want.UpdatedAt) {
+					t.Errorf("entry %q times = %v, %v, want %v, %v", want.Title, got.CreatedAt, got.UpdatedAt, want.CreatedAt, want.UpdatedAt)
+				}
+				for name, value := range want.Custom {
+					if got.Custom[name] != value {
+						t.Errorf("entry %q custom field %q = %q, want %q", want.Title, name, got.Custom[name], value)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestKDBXRoundTrip(t *testing.T) {
+	entries := testEntries()
+	path := writeTemp(t, FormatKDBX, entries, "database password")
+
+	if _, err := importer.ParseFile(importer.FormatKDBX, path, "wrong password"); err == nil {
+		t.Fatal("import with the wrong password succeeded")
+	}
+
+	imported, err := importer.ParseFile(importer.FormatKDBX, path, "database password")
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if len(imported) != len(entries) {
+		t.Fatalf("imported %d entries, want %d", len(imported), len(entries))
+	}
+
+	importedByTitle := byTitle(imported)
+	for _, want := range entries {
+		got, ok := importedByTitle[want.Title]
+		if !ok {
+			t.Errorf("entry %q is missing", want.Title)
+			continue
+		}
+		if got.Username != want.Username || got.Password != want.Password || got.URL != want.URL ||
+			got.Notes != want.Notes || got.Folder != want.Folder || got.OTP != want.OTP {
+			t.Errorf("entry %q round trip:\n got %+v\nwant %+v", want.Title, got, want)
+		}
+		if !reflect.DeepEqual(got.Custom, want.Custom) {
+			t.Errorf("entry %q custom fields = %v, want %v", want.Title, got.Custom, want.Custom)
+		}
+		if !got.UpdatedAt.Equal(want.UpdatedAt) {
+			t.Errorf("entry %q updated at %v, want %v", want.Title, got.UpdatedAt, want.UpdatedAt)
+		}
+	}
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	if err := Write(io.Discard, "lastpass-csv", testEntries(), ""); err == nil {
+		t.Error("writing an unsupported format succeeded")
+	}
+}
+
+// decrypt reads an age file, removing the armor first if it has one
+func decrypt(t *testing.T, data []byte, armored bool, identity age.Identity) ([]byte, error) {
+	t.Helper()
+
+	var r io.Reader = bytes.NewReader(data)
+	if armored {
+		r = armor.NewReader(r)
+	}
+	decrypted, err := age.Decrypt(r, identity)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(decrypted)
+}
+
+// encrypt age-encrypts plaintext for recipient
+func encrypt(t *testing.T, plaintext []byte, armored bool, recipient age.Recipient) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w, err := Encrypt(&buf, armored, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncryptToRecipient(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient, err := ParseRecipient(" " + identity.Recipient().String() + "\n")
+	if err != nil {
+		t.Fatalf("ParseRecipient: %v", err)
+	}
+
+	plaintext := []byte("every password there is")
+	for _, armored := range []bool{false, true} {
+		sealed := encrypt(t, plaintext, armored, recipient)
+		if bytes.Contains(sealed, plaintext) {
+			t.Fatal("encrypted export contains the plaintext")
+		}
+		if armored && !bytes.HasPrefix(sealed, []byte(armor.Header)) {
+			t.Errorf("armored export starts with %q", sealed[:min(len(sealed), 20)])
+		}
+
+		got, err := decrypt(t, sealed, armored, identity)
+		if err != nil {
+			t.Fatalf("armored=%v: decrypt: %v", armored, err)
+		}
+		if !bytes.Equal(got, plaintext) {
+			t.Errorf("armored=%v: decrypted %q, want %q", armored, got, plaintext)
+		}
+		if _, err := decrypt(t, sealed, armored, other); err == nil {
+			t.Errorf("armored=%v: another identity decrypted the export", armored)
+		}
+	}
+}
+
+func TestEncryptWithPassphrase(t *testing.T) {
+	recipient, err := PassphraseRecipient("export passphrase")
+	if err != nil {
+		t.Fatalf("PassphraseRecipient: %v", err)
+	}
+	// Keep scrypt cheap for the test
+	recipient.(*age.ScryptRecipient).SetWorkFactor(10)
+
+	plaintext := []byte("every password there is")
+	sealed := encrypt(t, plaintext, false, recipient)
+
+	identity, err := age.NewScryptIdentity("export passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decrypt(t, sealed, false, identity)
+	if err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("decrypted %q, want %q", got, plaintext)
+	}
+
+	wrong, err := age.NewScryptIdentity("wrong passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decrypt(t, sealed, false, wrong); err == nil {
+		t.Error("wrong passphrase decrypted the export")
+	}
+}
+
+func TestParseRecipientRejectsGarbage(t *testing.T) {
+	for _, value := range []string{"", "age1notakey", "ssh-ed25519 AAAA"} {
+		if _, err := ParseRecipient(value); err == nil {
+			t.Errorf("ParseRecipient(%q) succeeded", value)
+		}
+	}
+}
+
+// dirNames returns the names of the files in dir
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = file.Name()
+	}
+	return names
+}
+
+func TestWriteFileRemovesTempFileOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.json")
+	if err := os.WriteFile(path, []byte("previous export"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("disk full")
+	err := WriteFile(path, true, func(w io.Writer) error {
+		if _, err := w.Write([]byte("partial")); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WriteFile = %v, want the write error", err)
+	}
+
+	if names := dirNames(t, dir); !reflect.DeepEqual(names, []string{"export.json"}) {
+		t.Errorf("files after failed export = %v, want only the previous export", names)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "previous export" {
+		t.Errorf("previous export was changed to %q", data)
+	}
+}
+
+func TestWriteFileKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.json")
+	if err := os.WriteFile(path, []byte("previous export"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(w io.Writer) error {
+		_, err := w.Write([]byte("new export"))
+		return err
+	}
+	if err := WriteFile(path, false, write); err == nil {
+		t.Fatal("WriteFile replaced an existing file without overwrite")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "previous export" {
+		t.Errorf("previous export was changed to %q", data)
+	}
+	if names := dirNames(t, dir); len(names) != 1 {
+		t.Errorf("files after refused export = %v, want only the previous export", names)
+	}
+
+	if err := WriteFile(path, true, write); err != nil {
+		t.Fatalf("WriteFile with overwrite: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new export" {
+		t.Errorf("export = %q, want the new one", data)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("export mode = %o, want 600", perm)
+	}
+}
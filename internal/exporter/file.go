@@ -0,0 +1,54 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile creates path with owner-only permissions and fills it with write.
+// The data goes to a temporary file next to path that only takes its place
+// once complete, so a failed export never destroys a file being overwritten.
+// Unless overwrite is set an existing file at path is kept and an error returned.
+func WriteFile(path string, overwrite bool, write func(w io.Writer) error) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := file.Name()
+	defer func() {
+		if err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	if err := file.Chmod(0600); err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if overwrite {
+		return os.Rename(tmpPath, path)
+	}
+
+	// Link fails if path was created in the meantime, unlike a rename
+	if err := os.Link(tmpPath, path); err != nil {
+		if os.IsExist(err) {
+			return err
+		}
+		// Some file systems have no hard links
+		if _, statErr := os.Lstat(path); statErr == nil {
+			return fmt.Errorf("%s already exists", path)
+		}
+		return os.Rename(tmpPath, path)
+	}
+	os.Remove(tmpPath)
+	return nil
+}
@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// jsonFormatName identifies gopassman's own export files
+const jsonFormatName = "gopassman-export"
+
+// jsonFormatVersion is the layout version of gopassman's own export
+const jsonFormatVersion = 1
+
+// Export is gopassman's full-fidelity export document. Entries are written
+// exactly as they are stored in the vault, including IDs and timestamps.
+type Export struct {
+	Format     string          `json:"format"`
+	Version    int             `json:"version"`
+	ExportedAt time.Time       `json:"exported_at"`
+	Entries    []*models.Entry `json:"entries"`
+}
+
+// writeJSON writes gopassman's own JSON export
+func writeJSON(w io.Writer, entries []*models.Entry) error {
+	export := Export{
+		Format:     jsonFormatName,
+		Version:    jsonFormatVersion,
+		ExportedAt: time.Now().UTC(),
+		Entries:    entries,
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(export)
+}
@@ -0,0 +1,22 @@
+package exporter
+
+import (
+	"io"
+
+	"github.com/egemengunel/Go-Password-Manager/internal/kdbx"
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// writeKDBX writes a KeePass KDBX 4 database encrypted with the password
+func writeKDBX(w io.Writer, entries []*models.Entry, password string) error {
+	db := kdbx.NewDatabase("gopassman")
+	db.AddEntries(entries)
+
+	data, err := db.Encode(password)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
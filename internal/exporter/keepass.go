@@ -0,0 +1,198 @@
+package exporter
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// keePassRootGroup names the top level group, which KeePass treats as the database
+const keePassRootGroup = "Root"
+
+// keePassCSVHeader is the column layout of KeePassXC CSV exports and imports
+var keePassCSVHeader = []string{
+	"Group", "Title", "Username", "Password", "URL", "Notes", "TOTP", "Icon", "Last Modified", "Created",
+}
+
+// writeKeePassCSV writes a KeePassXC compatible CSV export. The CSV layout
+// has no room for tags or custom fields.
+func writeKeePassCSV(w io.Writer, entries []*models.Entry) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(keePassCSVHeader); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		group := keePassRootGroup
+		if entry.Folder != "" {
+			group += "/" + entry.Folder
+		}
+
+		record := []string{
+			group,
+			entry.Title,
+			entry.Username,
+			entry.Password,
+			entry.URL,
+			entry.Notes,
+			entry.OTP,
+			"0",
+			keePassTime(entry.UpdatedAt),
+			keePassTime(entry.CreatedAt),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+type keePassFile struct {
+	XMLName xml.Name    `xml:"KeePassFile"`
+	Meta    keePassMeta `xml:"Meta"`
+	Root    struct {
+		Group *keePassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+type keePassMeta struct {
+	Generator         string `xml:"Generator"`
+	DatabaseName      string `xml:"DatabaseName"`
+	RecycleBinEnabled string `xml:"RecycleBinEnabled"`
+}
+
+type keePassGroup struct {
+	UUID    string          `xml:"UUID"`
+	Name    string          `xml:"Name"`
+	IconID  int             `xml:"IconID"`
+	Entries []keePassEntry  `xml:"Entry"`
+	Groups  []*keePassGroup `xml:"Group"`
+}
+
+type keePassEntry struct {
+	UUID    string          `xml:"UUID"`
+	IconID  int             `xml:"IconID"`
+	Tags    string          `xml:"Tags,omitempty"`
+	Times   keePassTimes    `xml:"Times"`
+	Strings []keePassString `xml:"String"`
+}
+
+type keePassTimes struct {
+	CreationTime         string `xml:"CreationTime"`
+	LastModificationTime string `xml:"LastModificationTime"`
+	LastAccessTime       string `xml:"LastAccessTime"`
+}
+
+type keePassString struct {
+	Key   string       `xml:"Key"`
+	Value keePassValue `xml:"Value"`
+}
+
+type keePassValue struct {
+	ProtectInMemory string `xml:"ProtectInMemory,attr,omitempty"`
+	Text            string `xml:",chardata"`
+}
+
+// writeKeePassXML writes a KeePass 2.x XML export with folders as nested groups
+func writeKeePassXML(w io.Writer, entries []*models.Entry) error {
+	var file keePassFile
+	file.Meta = keePassMeta{
+		Generator:         "gopassman",
+		DatabaseName:      "gopassman",
+		RecycleBinEnabled: "False",
+	}
+
+	root := &keePassGroup{UUID: keePassUUID(""), Name: keePassRootGroup, IconID: 48}
+	file.Root.Group = root
+
+	groups := map[string]*keePassGroup{"": root}
+	for _, entry := range entries {
+		group := keePassGroupFor(groups, entry.Folder)
+		group.Entries = append(group.Entries, convertEntry(entry))
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+	if err := encoder.Encode(file); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// keePassGroupFor returns the group for a folder path, creating missing parents
+func keePassGroupFor(groups map[string]*keePassGroup, folder string) *keePassGroup {
+	if group, ok := groups[folder]; ok {
+		return group
+	}
+
+	parentPath, name := "", folder
+	if i := strings.LastIndex(folder, "/"); i >= 0 {
+		parentPath, name = folder[:i], folder[i+1:]
+	}
+
+	parent := keePassGroupFor(groups, parentPath)
+	group := &keePassGroup{UUID: keePassUUID(""), Name: name, IconID: 48}
+	parent.Groups = append(parent.Groups, group)
+	groups[folder] = group
+	return group
+}
+
+// convertEntry maps an entry onto KeePass standard and custom strings
+func convertEntry(entry *models.Entry) keePassEntry {
+	item := keePassEntry{
+		UUID: keePassUUID(entry.ID),
+		Tags: strings.Join(entry.Tags, ";"),
+		Times: keePassTimes{
+			CreationTime:         keePassTime(entry.CreatedAt),
+			LastModificationTime: keePassTime(entry.UpdatedAt),
+			LastAccessTime:       keePassTime(entry.AccessedAt),
+		},
+		Strings: []keePassString{
+			{Key: "Title", Value: keePassValue{Text: entry.Title}},
+			{Key: "UserName", Value: keePassValue{Text: entry.Username}},
+			{Key: "Password", Value: keePassValue{Text: entry.Password, ProtectInMemory: "True"}},
+			{Key: "URL", Value: keePassValue{Text: entry.URL}},
+			{Key: "Notes", Value: keePassValue{Text: entry.Notes}},
+		},
+	}
+
+	for _, name := range sortedKeys(entry.Custom) {
+		item.Strings = append(item.Strings, keePassString{Key: name, Value: keePassValue{Text: entry.Custom[name]}})
+	}
+
+	// KeePassXC keeps the 2FA seed as an otpauth URI in the "otp" field
+	if entry.OTP != "" {
+		item.Strings = append(item.Strings, keePassString{Key: "otp", Value: keePassValue{Text: entry.OTP, ProtectInMemory: "True"}})
+	}
+
+	return item
+}
+
+// keePassUUID converts an entry ID to the base64 encoded 16 byte UUID KeePass
+// uses. IDs that are not UUIDs, and empty ones, get a random UUID.
+func keePassUUID(id string) string {
+	raw, err := hex.DecodeString(strings.ReplaceAll(id, "-", ""))
+	if err != nil || len(raw) != 16 {
+		raw = make([]byte, 16)
+		rand.Read(raw) // crypto/rand.Read never returns an error
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// keePassTime formats a timestamp the way KeePass XML exports do
+func keePassTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
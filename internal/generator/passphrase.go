@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"bufio"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// effLargeWordlist is the EFF large wordlist for five dice (7776 words),
+// https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+//
+//go:embed wordlists/eff_large.txt
+var effLargeWordlist string
+
+// minWordlistSize is the smallest wordlist accepted for passphrases
+const minWordlistSize = 100
+
+// PassphraseOptions defines options for passphrase generation
+type PassphraseOptions struct {
+	Words       int
+	Separator   string
+	Capitalize  bool     // capitalize the first letter of every word
+	AppendDigit bool     // append a random digit to the passphrase
+	Wordlist    []string // nil uses the embedded EFF large wordlist
+}
+
+// DefaultPassphraseOptions returns sensible default options for passphrase generation
+func DefaultPassphraseOptions() PassphraseOptions {
+	return PassphraseOptions{
+		Words:     6,
+		Separator: "-",
+	}
+}
+
+// EFFWordlist returns the embedded EFF large wordlist
+var EFFWordlist = sync.OnceValue(func() []string {
+	words, err := ParseWordlist(strings.NewReader(effLargeWordlist))
+	if err != nil {
+		panic(fmt.Sprintf("embedded wordlist is invalid: %v", err))
+	}
+	return words
+})
+
+// LoadWordlist reads a wordlist file. See ParseWordlist for the format.
+func LoadWordlist(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	words, err := ParseWordlist(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return words, nil
+}
+
+// ParseWordlist reads one word per line. Diceware style lines with the dice
+// roll before the word ("11111	abacus") are accepted too. Blank lines and
+// lines starting with # are skipped, and duplicates are dropped so every word
+// counts once towards the entropy.
+func ParseWordlist(r io.Reader) ([]string, error) {
+	var words []string
+	seen := make(map[string]bool)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		word := fields[len(fields)-1]
+		if len(fields) > 2 || (len(fields) == 2 && strings.Trim(fields[0], "0123456789") != "") {
+			return nil, fmt.Errorf("invalid wordlist line %q", line)
+		}
+
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(words) < minWordlistSize {
+		return nil, fmt.Errorf("wordlist has %d unique words, at least %d are required", len(words), minWordlistSize)
+	}
+	return words, nil
+}
+
+// GeneratePassphrase creates a random passphrase of dictionary words
+func GeneratePassphrase(opts PassphraseOptions) (string, error) {
+	if opts.Words <= 0 {
+		return "", errors.New("a passphrase needs at least one word")
+	}
+
+	wordlist := opts.Wordlist
+	if wordlist == nil {
+		wordlist = EFFWordlist()
+	}
+
+	words := make([]string, opts.Words)
+	for i := range words {
+		word, err := randomElement(wordlist)
+		if err != nil {
+			return "", err
+		}
+		if opts.Capitalize {
+			word = capitalize(word)
+		}
+		words[i] = word
+	}
+
+	passphrase := strings.Join(words, opts.Separator)
+	if opts.AppendDigit {
+		digit, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		passphrase += digit.String()
+	}
+	return passphrase, nil
+}
+
+// PassphraseEntropy returns the entropy in bits of passphrases generated
+// with opts. Capitalization and the separator are fixed, so they add none.
+func PassphraseEntropy(opts PassphraseOptions) float64 {
+	size := len(opts.Wordlist)
+	if opts.Wordlist == nil {
+		size = len(EFFWordlist())
+	}
+
+	bits := float64(opts.Words) * math.Log2(float64(size))
+	if opts.AppendDigit {
+		bits += math.Log2(10)
+	}
+	return bits
+}
+
+// randomElement picks a uniformly random element of list
+func randomElement(list []string) (string, error) {
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(len(list))))
+	if err != nil {
+		return "", err
+	}
+	return list[index.Int64()], nil
+}
+
+// capitalize upper-cases the first letter of word
+func capitalize(word string) string {
+	r, size := utf8.DecodeRuneInString(word)
+	return string(unicode.ToUpper(r)) + word[size:]
+}
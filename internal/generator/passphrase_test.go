@@ -0,0 +1,196 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+// numberedWords returns n distinct words "word000", "word001", ...
+func numberedWords(n int) []string {
+	words := make([]string, n)
+	for i := range words {
+		words[i] = fmt.Sprintf("word%03d", i)
+	}
+	return words
+}
+
+func TestEFFWordlist(t *testing.T) {
+	words := EFFWordlist()
+	if len(words) != 7776 {
+		t.Fatalf("EFF wordlist has %d words, want 7776", len(words))
+	}
+	if words[0] != "abacus" || words[len(words)-1] != "zoom" {
+		t.Errorf("EFF wordlist runs from %q to %q, want abacus to zoom", words[0], words[len(words)-1])
+	}
+	for _, word := range words {
+		if strings.ContainsAny(word, "0123456789 \t") {
+			t.Fatalf("word %q kept its dice roll or whitespace", word)
+		}
+	}
+}
+
+func TestParseWordlist(t *testing.T) {
+	var lines []string
+	lines = append(lines, "# a comment", "")
+	for i, word := range numberedWords(minWordlistSize) {
+		if i%2 == 0 {
+			// Diceware style, with the roll before the word
+			lines = append(lines, fmt.Sprintf("%05d\t%s", 11111+i, word))
+		} else {
+			lines = append(lines, "  "+word+"  ")
+		}
+	}
+	lines = append(lines, "word000", "66666 word001")
+
+	words, err := ParseWordlist(strings.NewReader(strings.Join(lines, "\r\n")))
+	if err != nil {
+		t.Fatalf("ParseWordlist: %v", err)
+	}
+	if !slices.Equal(words, numberedWords(minWordlistSize)) {
+		t.Errorf("parsed %d words %v..., want the %d numbered words without duplicates", len(words), words[:3], minWordlistSize)
+	}
+}
+
+func TestParseWordlistRejectsBadLists(t *testing.T) {
+	tests := map[string]string{
+		"too short":       strings.Join(numberedWords(minWordlistSize-1), "\n"),
+		"duplicates only": strings.Repeat("same\n", minWordlistSize),
+		"two words":       "two words\n" + strings.Join(numberedWords(minWordlistSize), "\n"),
+		"three fields":    "11111 abacus extra\n" + strings.Join(numberedWords(minWordlistSize), "\n"),
+	}
+
+	for name, list := range tests {
+		if _, err := ParseWordlist(strings.NewReader(list)); err == nil {
+			t.Errorf("%s: ParseWordlist accepted the list", name)
+		}
+	}
+}
+
+func TestLoadWordlist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(numberedWords(150), "\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	words, err := LoadWordlist(path)
+	if err != nil {
+		t.Fatalf("LoadWordlist: %v", err)
+	}
+	if len(words) != 150 {
+		t.Errorf("loaded %d words, want 150", len(words))
+	}
+
+	if _, err := LoadWordlist(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("loading a missing wordlist succeeded")
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	wordlist := numberedWords(minWordlistSize)
+
+	tests := []struct {
+		name string
+		opts PassphraseOptions
+	}{
+		{"dashes", PassphraseOptions{Words: 5, Separator: "-", Wordlist: wordlist}},
+		{"spaces", PassphraseOptions{Words: 4, Separator: " ", Wordlist: wordlist}},
+		{"no separator", PassphraseOptions{Words: 3, Wordlist: wordlist}},
+		{"capitalized", PassphraseOptions{Words: 6, Separator: ".", Capitalize: true, Wordlist: wordlist}},
+		{"digit", PassphraseOptions{Words: 4, Separator: "_", AppendDigit: true, Wordlist: wordlist}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			passphrase, err := GeneratePassphrase(test.opts)
+			if err != nil {
+				t.Fatalf("GeneratePassphrase: %v", err)
+			}
+
+			if test.opts.AppendDigit {
+				last := passphrase[len(passphrase)-1]
+				if last < '0' || last > '9' {
+					t.Fatalf("passphrase %q does not end in a digit", passphrase)
+				}
+				passphrase = passphrase[:len(passphrase)-1]
+			}
+
+			// Every word is 7 characters, so the separators sit at fixed positions
+			var words []string
+			if test.opts.Separator == "" {
+				for i := 0; i < len(passphrase); i += 7 {
+					words = append(words, passphrase[i:min(i+7, len(passphrase))])
+				}
+			} else {
+				words = strings.Split(passphrase, test.opts.Separator)
+			}
+			if len(words) != test.opts.Words {
+				t.Fatalf("passphrase %q has %d words, want %d", passphrase, len(words), test.opts.Words)
+			}
+
+			for _, word := range words {
+				if test.opts.Capitalize {
+					if !unicode.IsUpper(rune(word[0])) {
+						t.Errorf("word %q is not capitalized", word)
+					}
+					word = strings.ToLower(word[:1]) + word[1:]
+				}
+				if !slices.Contains(wordlist, word) {
+					t.Errorf("word %q is not from the wordlist", word)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePassphraseUsesEFFWordlist(t *testing.T) {
+	passphrase, err := GeneratePassphrase(DefaultPassphraseOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := strings.Split(passphrase, "-")
+	if len(words) != 6 {
+		t.Fatalf("passphrase %q has %d words, want 6", passphrase, len(words))
+	}
+	for _, word := range words {
+		if !slices.Contains(EFFWordlist(), word) {
+			t.Errorf("word %q is not from the EFF wordlist", word)
+		}
+	}
+
+	if _, err := GeneratePassphrase(PassphraseOptions{Words: 0}); err == nil {
+		t.Error("a passphrase without words was generated")
+	}
+}
+
+func TestCapitalize(t *testing.T) {
+	for word, want := range map[string]string{"abacus": "Abacus", "élan": "Élan", "Zoom": "Zoom"} {
+		if got := capitalize(word); got != want {
+			t.Errorf("capitalize(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestPassphraseEntropy(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PassphraseOptions
+		want float64
+	}{
+		{"EFF six words", PassphraseOptions{Words: 6}, 6 * math.Log2(7776)},
+		{"custom list", PassphraseOptions{Words: 4, Wordlist: numberedWords(128)}, 28},
+		{"capitalized", PassphraseOptions{Words: 4, Capitalize: true, Separator: " ", Wordlist: numberedWords(128)}, 28},
+		{"digit", PassphraseOptions{Words: 4, AppendDigit: true, Wordlist: numberedWords(128)}, 28 + math.Log2(10)},
+	}
+
+	for _, test := range tests {
+		if got := PassphraseEntropy(test.opts); math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%s: PassphraseEntropy = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
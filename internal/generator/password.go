@@ -2,6 +2,9 @@ package generator
 
 import (
 	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
 	"math/big"
 	"strings"
 )
@@ -11,8 +14,13 @@ const (
 	uppercase = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
 	numbers   = "0123456789"
 	symbols   = "!@#$%^&*()_+-=[]{}|;:,.<>?"
+	ambiguous = "0O1lI|"
 )
 
+// maxConsecutiveAttempts bounds the retries for a password without
+// too many repeated characters in a row
+const maxConsecutiveAttempts = 1000
+
 // PasswordOptions defines options for password generation
 type PasswordOptions struct {
 	Length           int
@@ -21,63 +29,278 @@ type PasswordOptions struct {
 	IncludeNumbers   bool
 	IncludeSymbols   bool
 	ExcludeAmbiguous bool
+
+	// Minimum number of characters from each class. A minimum above zero
+	// includes the class.
+	MinLower   int
+	MinUpper   int
+	MinNumbers int
+	MinSymbols int
+
+	MinLength int // bounds Length is clamped to, 0 for none
+	MaxLength int
+
+	Symbols        string   // symbols to use instead of the default set
+	Allowed        string   // extra characters that may appear
+	Forbidden      string   // characters that must never appear
+	Required       []string // extra character sets that must each appear at least once
+	MaxConsecutive int      // longest run of one repeated character, 0 for no limit
 }
 
-// DefaultOptions returns sensible default options for password generation
+// Defaults are the options used when none are given. Every class is
+// included and appears at least once; the configuration may change them.
+var Defaults = PasswordOptions{
+	Length:           16,
+	IncludeLower:     true,
+	IncludeUpper:     true,
+	IncludeNumbers:   true,
+	IncludeSymbols:   true,
+	ExcludeAmbiguous: true,
+	MinLower:         1,
+	MinUpper:         1,
+	MinNumbers:       1,
+	MinSymbols:       1,
+}
+
+// DefaultOptions returns the default options for password generation
 func DefaultOptions() PasswordOptions {
-	return PasswordOptions{
-		Length:           16,
-		IncludeLower:     true,
-		IncludeUpper:     true,
-		IncludeNumbers:   true,
-		IncludeSymbols:   true,
-		ExcludeAmbiguous: true,
-	}
+	return Defaults
+}
+
+// requirement is a character set with the number of characters a password
+// must contain from it
+type requirement struct {
+	name  string
+	chars string
+	min   int
 }
 
-// GeneratePassword creates a secure random password based on the given options
+// GeneratePassword creates a secure random password based on the given options.
+// The characters each requirement needs are drawn first, the rest of the
+// password is filled from the whole charset and the result is shuffled.
 func GeneratePassword(opts PasswordOptions) (string, error) {
-	if opts.Length <= 0 {
-		opts.Length = 16
+	opts.Length = opts.EffectiveLength()
+
+	for _, set := range append([]string{opts.Symbols, opts.Allowed, opts.Forbidden}, opts.Required...) {
+		if !isPrintableASCII(set) {
+			return "", fmt.Errorf("only printable ASCII characters are supported, got %q", set)
+		}
+	}
+
+	charset := buildCharset(opts)
+	if charset == "" {
+		return "", errors.New("the policy forbids every character")
+	}
+	requirements, err := buildRequirements(opts)
+	if err != nil {
+		return "", err
+	}
+
+	required := 0
+	for _, req := range requirements {
+		required += req.min
+	}
+	if required > opts.Length {
+		return "", fmt.Errorf("the policy requires %d characters but the length is %d", required, opts.Length)
+	}
+	if opts.MaxConsecutive > 0 && len(charset) == 1 && opts.Length > opts.MaxConsecutive {
+		return "", fmt.Errorf("the policy only allows %q, which cannot appear %d times with at most %d in a row", charset, opts.Length, opts.MaxConsecutive)
 	}
 
+	for attempt := 0; attempt < maxConsecutiveAttempts; attempt++ {
+		password := make([]byte, 0, opts.Length)
+		for _, req := range requirements {
+			for i := 0; i < req.min; i++ {
+				char, err := randomChar(req.chars)
+				if err != nil {
+					return "", err
+				}
+				password = append(password, char)
+			}
+		}
+		for len(password) < opts.Length {
+			char, err := randomChar(charset)
+			if err != nil {
+				return "", err
+			}
+			password = append(password, char)
+		}
+
+		if err := shuffle(password); err != nil {
+			return "", err
+		}
+		if opts.MaxConsecutive <= 0 || maxRun(password) <= opts.MaxConsecutive {
+			return string(password), nil
+		}
+	}
+
+	return "", fmt.Errorf("could not generate a password with at most %d consecutive identical characters", opts.MaxConsecutive)
+}
+
+// PasswordEntropy returns the entropy in bits of a password drawn uniformly
+// from the charset of opts. Required classes lower it only marginally.
+func PasswordEntropy(opts PasswordOptions) float64 {
+	charset := buildCharset(opts)
+	if charset == "" {
+		return 0
+	}
+	return float64(opts.EffectiveLength()) * math.Log2(float64(len(charset)))
+}
+
+// EffectiveLength returns the length generated passwords have: Length, or 16
+// if unset, clamped to MinLength and MaxLength
+func (opts PasswordOptions) EffectiveLength() int {
+	length := opts.Length
+	if length <= 0 {
+		length = 16
+	}
+	if opts.MaxLength > 0 {
+		length = min(length, opts.MaxLength)
+	}
+	if opts.MinLength > 0 {
+		length = max(length, opts.MinLength)
+	}
+	return length
+}
+
+// buildCharset returns the characters passwords are drawn from
+func buildCharset(opts PasswordOptions) string {
 	var charset string
 
-	if opts.IncludeLower {
+	if opts.IncludeLower || opts.MinLower > 0 {
 		charset += lowercase
 	}
-	if opts.IncludeUpper {
+	if opts.IncludeUpper || opts.MinUpper > 0 {
 		charset += uppercase
 	}
-	if opts.IncludeNumbers {
+	if opts.IncludeNumbers || opts.MinNumbers > 0 {
 		charset += numbers
 	}
-	if opts.IncludeSymbols {
-		charset += symbols
+	if opts.IncludeSymbols || opts.MinSymbols > 0 {
+		charset += symbolSet(opts)
 	}
 
 	if opts.ExcludeAmbiguous {
 		// Remove ambiguous characters
-		ambiguous := "0O1lI|"
-		for _, char := range ambiguous {
-			charset = strings.ReplaceAll(charset, string(char), "")
-		}
+		charset = removeChars(charset, ambiguous)
+	}
+
+	// Explicitly allowed and required characters are kept even if ambiguous
+	charset += opts.Allowed
+	for _, set := range opts.Required {
+		charset += set
 	}
 
 	if len(charset) == 0 {
 		charset = lowercase + uppercase + numbers // fallback
 	}
 
-	password := make([]byte, opts.Length)
-	charsetLen := big.NewInt(int64(len(charset)))
+	return removeChars(uniqueChars(charset), opts.Forbidden)
+}
+
+// buildRequirements returns the character sets a password must contain,
+// restricted to the characters that may be used
+func buildRequirements(opts PasswordOptions) ([]requirement, error) {
+	classes := []requirement{
+		{"lowercase letters", lowercase, opts.MinLower},
+		{"uppercase letters", uppercase, opts.MinUpper},
+		{"numbers", numbers, opts.MinNumbers},
+		{"symbols", symbolSet(opts), opts.MinSymbols},
+	}
+	for _, set := range opts.Required {
+		classes = append(classes, requirement{fmt.Sprintf("one of %q", set), set, 1})
+	}
+
+	var requirements []requirement
+	for i, req := range classes {
+		if req.min <= 0 {
+			continue
+		}
+
+		// Standard classes lose their ambiguous characters, custom sets keep them
+		if opts.ExcludeAmbiguous && i < 4 {
+			req.chars = removeChars(req.chars, ambiguous)
+		}
+		req.chars = removeChars(uniqueChars(req.chars), opts.Forbidden)
+		if req.chars == "" {
+			return nil, fmt.Errorf("the policy requires %s but all of them are forbidden", req.name)
+		}
+		requirements = append(requirements, req)
+	}
+	return requirements, nil
+}
+
+// symbolSet returns the symbols a password may contain
+func symbolSet(opts PasswordOptions) string {
+	if opts.Symbols != "" {
+		return opts.Symbols
+	}
+	return symbols
+}
+
+// removeChars removes every character in remove from s
+func removeChars(s, remove string) string {
+	for _, char := range remove {
+		s = strings.ReplaceAll(s, string(char), "")
+	}
+	return s
+}
+
+// uniqueChars drops repeated characters from s so none is more likely than another
+func uniqueChars(s string) string {
+	var b strings.Builder
+	for _, char := range s {
+		if !strings.ContainsRune(b.String(), char) {
+			b.WriteRune(char)
+		}
+	}
+	return b.String()
+}
+
+// isPrintableASCII reports whether s contains only printable ASCII characters
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < ' ' || s[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// randomChar picks a uniformly random byte of charset
+func randomChar(charset string) (byte, error) {
+	if charset == "" {
+		return 0, errors.New("empty character set")
+	}
+	index, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+	if err != nil {
+		return 0, err
+	}
+	return charset[index.Int64()], nil
+}
 
-	for i := range password {
-		randomIndex, err := rand.Int(rand.Reader, charsetLen)
+// shuffle permutes b uniformly (Fisher-Yates)
+func shuffle(b []byte) error {
+	for i := len(b) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
 		if err != nil {
-			return "", err
+			return err
 		}
-		password[i] = charset[randomIndex.Int64()]
+		b[i], b[j.Int64()] = b[j.Int64()], b[i]
 	}
+	return nil
+}
 
-	return string(password), nil
+// maxRun returns the length of the longest run of one repeated character
+func maxRun(b []byte) int {
+	longest, run := 0, 0
+	for i := range b {
+		if i > 0 && b[i] == b[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+	}
+	return longest
 }
@@ -0,0 +1,190 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+)
+
+// checkPassword reports every way password breaks the given limits
+func checkPassword(t *testing.T, password string, minLength, maxLength int, allowed string, required []string, maxConsecutive int) {
+	t.Helper()
+
+	if len(password) < minLength || len(password) > maxLength {
+		t.Errorf("password %q has length %d, want %d to %d", password, len(password), minLength, maxLength)
+	}
+	for _, char := range password {
+		if !strings.ContainsRune(allowed, char) {
+			t.Errorf("password %q contains %q, which is not allowed", password, char)
+		}
+	}
+	for _, set := range required {
+		if !strings.ContainsAny(password, set) {
+			t.Errorf("password %q contains none of %q", password, set)
+		}
+	}
+	if maxConsecutive > 0 {
+		if run := maxRun([]byte(password)); run > maxConsecutive {
+			t.Errorf("password %q repeats a character %d times in a row, want at most %d", password, run, maxConsecutive)
+		}
+	}
+}
+
+func TestApplyPasswordRules(t *testing.T) {
+	opts := DefaultOptions()
+	rules := "required: upper; required: digit; allowed: [-_]; max-consecutive: 2; minlength: 8; maxlength: 20"
+	if err := ApplyPasswordRules(&opts, rules); err != nil {
+		t.Fatalf("ApplyPasswordRules: %v", err)
+	}
+
+	if !opts.IncludeUpper || !opts.IncludeNumbers || opts.IncludeLower || opts.IncludeSymbols {
+		t.Errorf("classes = lower %v, upper %v, numbers %v, symbols %v, want only upper and numbers",
+			opts.IncludeLower, opts.IncludeUpper, opts.IncludeNumbers, opts.IncludeSymbols)
+	}
+	if opts.MinUpper != 1 || opts.MinNumbers != 1 || opts.MinLower != 0 || opts.MinSymbols != 0 {
+		t.Errorf("minimums = %d lower, %d upper, %d numbers, %d symbols, want one upper and one number",
+			opts.MinLower, opts.MinUpper, opts.MinNumbers, opts.MinSymbols)
+	}
+	if opts.Allowed != "-_" {
+		t.Errorf("Allowed = %q, want %q", opts.Allowed, "-_")
+	}
+	if opts.MaxConsecutive != 2 || opts.MinLength != 8 || opts.MaxLength != 20 {
+		t.Errorf("max-consecutive %d, minlength %d, maxlength %d, want 2, 8 and 20", opts.MaxConsecutive, opts.MinLength, opts.MaxLength)
+	}
+
+	// Ambiguous characters are still left out of the named classes
+	allowed := removeChars(uppercase+numbers, ambiguous) + "-_"
+	for i := 0; i < 500; i++ {
+		password, err := GeneratePassword(opts)
+		if err != nil {
+			t.Fatalf("GeneratePassword: %v", err)
+		}
+		checkPassword(t, password, 8, 20, allowed, []string{uppercase, numbers}, 2)
+	}
+}
+
+func TestGeneratePasswordFollowsRules(t *testing.T) {
+	tests := []struct {
+		rules          string
+		length         int
+		minLength      int
+		maxLength      int
+		allowed        string
+		required       []string
+		maxConsecutive int
+	}{
+		{
+			rules:  "required: lower; required: [!#]; maxlength: 10",
+			length: 16, minLength: 10, maxLength: 10,
+			allowed:  removeChars(lowercase, ambiguous) + "!#",
+			required: []string{lowercase, "!#"},
+		},
+		{
+			rules:  "required: upper, digit; max-consecutive: 1",
+			length: 12, minLength: 12, maxLength: 12,
+			allowed:        removeChars(uppercase+numbers, ambiguous),
+			required:       []string{uppercase + numbers},
+			maxConsecutive: 1,
+		},
+		{
+			rules:  "allowed: ascii-printable; minlength: 30",
+			length: 16, minLength: 30, maxLength: 30,
+			allowed: removeChars(lowercase+uppercase+numbers+symbols, ambiguous),
+		},
+		{
+			rules:  "required: [ab]; allowed: [c]; max-consecutive: 3",
+			length: 20, minLength: 20, maxLength: 20,
+			allowed:        "abc",
+			required:       []string{"ab"},
+			maxConsecutive: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.rules, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Length = test.length
+			if err := ApplyPasswordRules(&opts, test.rules); err != nil {
+				t.Fatalf("ApplyPasswordRules: %v", err)
+			}
+
+			for i := 0; i < 200; i++ {
+				password, err := GeneratePassword(opts)
+				if err != nil {
+					t.Fatalf("GeneratePassword: %v", err)
+				}
+				checkPassword(t, password, test.minLength, test.maxLength, test.allowed, test.required, test.maxConsecutive)
+			}
+		})
+	}
+}
+
+func TestApplyPasswordRulesRejectsInvalidRules(t *testing.T) {
+	for _, rules := range []string{
+		"required upper",
+		"required: vowels",
+		"allowed: [abc",
+		"allowed: []",
+		"allowed: [ä]",
+		"minlength: 0",
+		"maxlength: -3",
+		"max-consecutive: many",
+		"colour: blue",
+		"minlength: 20; maxlength: 8",
+	} {
+		opts := DefaultOptions()
+		if err := ApplyPasswordRules(&opts, rules); err == nil {
+			t.Errorf("ApplyPasswordRules(%q) succeeded", rules)
+		}
+	}
+}
+
+func TestContradictoryRulesFail(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     string
+		forbidden string
+	}{
+		{"more required classes than characters", "maxlength: 3; required: upper; required: lower; required: digit; required: special", ""},
+		{"single character with a run limit", "allowed: [a]; max-consecutive: 2; minlength: 8", ""},
+		{"required characters all forbidden", "required: [-_]; allowed: lower", "-_"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			if err := ApplyPasswordRules(&opts, test.rules); err != nil {
+				t.Fatalf("ApplyPasswordRules: %v", err)
+			}
+			opts.Forbidden = test.forbidden
+
+			start := time.Now()
+			if password, err := GeneratePassword(opts); err == nil {
+				t.Errorf("GeneratePassword = %q, want an error", password)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("GeneratePassword took %v to give up", elapsed)
+			}
+		})
+	}
+}
+
+func TestDefaultOptionsIncludeEveryClass(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		password, err := GeneratePassword(DefaultOptions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(password) != 16 {
+			t.Fatalf("password %q has length %d, want 16", password, len(password))
+		}
+		if strings.ContainsAny(password, ambiguous) {
+			t.Errorf("password %q contains an ambiguous character", password)
+		}
+		if !strings.ContainsFunc(password, unicode.IsLower) || !strings.ContainsFunc(password, unicode.IsUpper) ||
+			!strings.ContainsAny(password, numbers) || !strings.ContainsAny(password, symbols) {
+			t.Errorf("password %q misses a character class", password)
+		}
+	}
+}
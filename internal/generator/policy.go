@@ -0,0 +1,51 @@
+package generator
+
+import "github.com/egemengunel/Go-Password-Manager/models"
+
+// Policy returns the options as a policy an entry can store. rules is the
+// passwordrules descriptor the options were built from, if any.
+func (opts PasswordOptions) Policy(rules string) *models.PasswordPolicy {
+	return &models.PasswordPolicy{
+		Rules:            rules,
+		Length:           opts.Length,
+		MinLength:        opts.MinLength,
+		MaxLength:        opts.MaxLength,
+		Lower:            opts.IncludeLower,
+		Upper:            opts.IncludeUpper,
+		Numbers:          opts.IncludeNumbers,
+		Symbols:          opts.IncludeSymbols,
+		MinLower:         opts.MinLower,
+		MinUpper:         opts.MinUpper,
+		MinNumbers:       opts.MinNumbers,
+		MinSymbols:       opts.MinSymbols,
+		SymbolSet:        opts.Symbols,
+		Allowed:          opts.Allowed,
+		Forbidden:        opts.Forbidden,
+		Required:         append([]string(nil), opts.Required...),
+		MaxConsecutive:   opts.MaxConsecutive,
+		ExcludeAmbiguous: opts.ExcludeAmbiguous,
+	}
+}
+
+// OptionsFromPolicy returns the generator options a stored policy describes
+func OptionsFromPolicy(policy *models.PasswordPolicy) PasswordOptions {
+	return PasswordOptions{
+		Length:           policy.Length,
+		MinLength:        policy.MinLength,
+		MaxLength:        policy.MaxLength,
+		IncludeLower:     policy.Lower,
+		IncludeUpper:     policy.Upper,
+		IncludeNumbers:   policy.Numbers,
+		IncludeSymbols:   policy.Symbols,
+		MinLower:         policy.MinLower,
+		MinUpper:         policy.MinUpper,
+		MinNumbers:       policy.MinNumbers,
+		MinSymbols:       policy.MinSymbols,
+		Symbols:          policy.SymbolSet,
+		Allowed:          policy.Allowed,
+		Forbidden:        policy.Forbidden,
+		Required:         append([]string(nil), policy.Required...),
+		MaxConsecutive:   policy.MaxConsecutive,
+		ExcludeAmbiguous: policy.ExcludeAmbiguous,
+	}
+}
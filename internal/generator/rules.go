@@ -0,0 +1,231 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplyPasswordRules applies a password rules descriptor in Apple's
+// passwordrules syntax, as found in the passwordrules HTML attribute and the
+// password-rules quirks database, e.g.
+//
+//	minlength: 8; maxlength: 20; required: upper; required: digit; allowed: [-_]; max-consecutive: 2
+//
+// If the rules name any required or allowed classes they replace the
+// character classes of opts; otherwise the classes are kept.
+func ApplyPasswordRules(opts *PasswordOptions, rules string) error {
+	resetClasses := true
+
+	for _, property := range splitRules(rules) {
+		property = strings.TrimSpace(property)
+		if property == "" {
+			continue
+		}
+
+		name, value, found := strings.Cut(property, ":")
+		if !found {
+			return fmt.Errorf("invalid password rule %q, expected name: value", property)
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+
+		switch name {
+		case "required", "allowed":
+			classes, err := parseRuleClasses(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			if resetClasses {
+				clearClasses(opts)
+				resetClasses = false
+			}
+			if name == "required" {
+				requireClasses(opts, classes)
+			} else {
+				allowClasses(opts, classes)
+			}
+
+		case "minlength", "maxlength", "max-consecutive":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return fmt.Errorf("%s must be a positive integer, got %q", name, value)
+			}
+			switch name {
+			case "minlength":
+				opts.MinLength = n
+			case "maxlength":
+				opts.MaxLength = n
+			default:
+				opts.MaxConsecutive = n
+			}
+
+		default:
+			return fmt.Errorf("unknown password rule %q", name)
+		}
+	}
+
+	if opts.MinLength > 0 && opts.MaxLength > 0 && opts.MinLength > opts.MaxLength {
+		return fmt.Errorf("minlength %d is greater than maxlength %d", opts.MinLength, opts.MaxLength)
+	}
+	return nil
+}
+
+// splitRules splits a descriptor into its properties. Semicolons inside
+// custom character classes do not separate properties.
+func splitRules(rules string) []string {
+	var properties []string
+	start, inClass := 0, false
+	for i := 0; i < len(rules); i++ {
+		switch {
+		case rules[i] == '[' && !inClass:
+			inClass = true
+		case rules[i] == ']' && inClass && closesClass(rules[i+1:]):
+			inClass = false
+		case rules[i] == ';' && !inClass:
+			properties = append(properties, rules[start:i])
+			start = i + 1
+		}
+	}
+	return append(properties, rules[start:])
+}
+
+// closesClass reports whether a ] followed by rest ends a custom character
+// class, which is the case when the class list or property ends after it
+func closesClass(rest string) bool {
+	rest = strings.TrimSpace(rest)
+	return rest == "" || rest[0] == ',' || rest[0] == ';'
+}
+
+// ruleClass is one character class of a passwordrules property: a named
+// class (upper, lower, digit, special, ascii-printable, unicode) or a custom
+// class written as [chars]
+type ruleClass struct {
+	name  string
+	chars string
+}
+
+// parseRuleClasses parses a comma separated list of character classes
+func parseRuleClasses(value string) ([]ruleClass, error) {
+	var classes []ruleClass
+
+	for value != "" {
+		var class ruleClass
+		if strings.HasPrefix(value, "[") {
+			// A custom class ends at the first ] followed by a comma or the end
+			end := -1
+			for i := 1; i < len(value); i++ {
+				if value[i] == ']' && closesClass(value[i+1:]) {
+					end = i
+					break
+				}
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class %q", value)
+			}
+			class.chars = value[1:end]
+			if class.chars == "" {
+				return nil, fmt.Errorf("empty character class")
+			}
+			if !isPrintableASCII(class.chars) {
+				return nil, fmt.Errorf("only printable ASCII characters are supported in %q", value[:end+1])
+			}
+			value = value[end+1:]
+		} else {
+			name, rest, _ := strings.Cut(value, ",")
+			class.name = strings.ToLower(strings.TrimSpace(name))
+			switch class.name {
+			case "upper", "lower", "digit", "special", "ascii-printable", "unicode":
+			default:
+				return nil, fmt.Errorf("unknown character class %q", class.name)
+			}
+			value = rest
+		}
+		classes = append(classes, class)
+
+		value = strings.TrimSpace(value)
+		value = strings.TrimSpace(strings.TrimPrefix(value, ","))
+	}
+
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("no character classes given")
+	}
+	return classes, nil
+}
+
+// clearClasses removes every character class from opts
+func clearClasses(opts *PasswordOptions) {
+	opts.IncludeLower, opts.IncludeUpper, opts.IncludeNumbers, opts.IncludeSymbols = false, false, false, false
+	opts.MinLower, opts.MinUpper, opts.MinNumbers, opts.MinSymbols = 0, 0, 0, 0
+	opts.Allowed = ""
+	opts.Required = nil
+}
+
+// allowClasses lets passwords contain characters of the given classes
+func allowClasses(opts *PasswordOptions, classes []ruleClass) {
+	for _, class := range classes {
+		switch class.name {
+		case "upper":
+			opts.IncludeUpper = true
+		case "lower":
+			opts.IncludeLower = true
+		case "digit":
+			opts.IncludeNumbers = true
+		case "special":
+			opts.IncludeSymbols = true
+		case "ascii-printable", "unicode":
+			opts.IncludeLower, opts.IncludeUpper, opts.IncludeNumbers, opts.IncludeSymbols = true, true, true, true
+		default:
+			opts.Allowed = uniqueChars(opts.Allowed + class.chars)
+		}
+	}
+}
+
+// requireClasses makes passwords contain a character of at least one of the
+// given classes. A single named class raises that class's minimum; a list of
+// classes becomes a required character set.
+func requireClasses(opts *PasswordOptions, classes []ruleClass) {
+	allowClasses(opts, classes)
+
+	if len(classes) == 1 {
+		switch classes[0].name {
+		case "upper":
+			opts.MinUpper = max(opts.MinUpper, 1)
+			return
+		case "lower":
+			opts.MinLower = max(opts.MinLower, 1)
+			return
+		case "digit":
+			opts.MinNumbers = max(opts.MinNumbers, 1)
+			return
+		case "special":
+			opts.MinSymbols = max(opts.MinSymbols, 1)
+			return
+		case "ascii-printable", "unicode":
+			return // any character satisfies it
+		}
+	}
+
+	// Named classes lose their ambiguous characters, custom ones keep them
+	var named, custom string
+	for _, class := range classes {
+		switch class.name {
+		case "upper":
+			named += uppercase
+		case "lower":
+			named += lowercase
+		case "digit":
+			named += numbers
+		case "special":
+			named += symbolSet(*opts)
+		case "ascii-printable", "unicode":
+			return // any character satisfies it
+		default:
+			custom += class.chars
+		}
+	}
+	if opts.ExcludeAmbiguous {
+		named = removeChars(named, ambiguous)
+	}
+	opts.Required = append(opts.Required, uniqueChars(named+custom))
+}
@@ -0,0 +1,240 @@
+// Package gitsync keeps the vault file in a local git repository and
+// exchanges it with a remote. It runs the git command line tool, so remotes,
+// credentials and SSH keys work exactly as they do for git itself.
+package gitsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBranch is the branch new repositories are created with
+const DefaultBranch = "main"
+
+// remoteName is the remote the vault is pushed to and pulled from
+const remoteName = "origin"
+
+// ErrGitNotFound is returned when the git executable is not installed
+var ErrGitNotFound = errors.New("git is not installed or not in PATH")
+
+// Repo is a git working tree holding the vault file
+type Repo struct {
+	Dir    string
+	Branch string
+	env    []string // commit identity
+}
+
+// Open opens the repository in dir, creating it if needed
+func Open(dir string) (*Repo, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrGitNotFound
+	}
+
+	r := &Repo{Dir: dir, Branch: DefaultBranch}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create sync directory: %w", err)
+		}
+		if _, err := r.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+		if _, err := r.git("symbolic-ref", "HEAD", "refs/heads/"+DefaultBranch); err != nil {
+			return nil, err
+		}
+	}
+
+	name, email := r.identity("user.name", "gopassman"), r.identity("user.email", "gopassman@localhost")
+	r.env = []string{
+		"GIT_AUTHOR_NAME=" + name, "GIT_AUTHOR_EMAIL=" + email,
+		"GIT_COMMITTER_NAME=" + name, "GIT_COMMITTER_EMAIL=" + email,
+	}
+
+	branch, err := r.git("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("%s is not on a branch: %w", dir, err)
+	}
+	r.Branch = branch
+	return r, nil
+}
+
+// git runs a git command in the repository and returns its trimmed output.
+// Commits are attributed to gopassman unless the user configured an identity.
+func (r *Repo) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.Dir
+	cmd.Env = append(os.Environ(), r.env...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			message = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], message)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// identity returns a configured git identity value, or fallback if unset
+func (r *Repo) identity(key, fallback string) string {
+	cmd := exec.Command("git", "config", "--get", key)
+	cmd.Dir = r.Dir
+	out, err := cmd.Output()
+	if value := strings.TrimSpace(string(out)); err == nil && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// Remote returns the URL of the remote, or "" if none is configured
+func (r *Repo) Remote() string {
+	url, err := r.git("remote", "get-url", remoteName)
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+// SetRemote configures the remote the vault is synchronized with
+func (r *Repo) SetRemote(url string) error {
+	if r.Remote() == "" {
+		_, err := r.git("remote", "add", remoteName, url)
+		return err
+	}
+	_, err := r.git("remote", "set-url", remoteName, url)
+	return err
+}
+
+// RemoteRef is the ref of the remote branch after Fetch
+func (r *Repo) RemoteRef() string {
+	return "refs/remotes/" + remoteName + "/" + r.Branch
+}
+
+// Fetch downloads the remote branch and reports whether it exists
+func (r *Repo) Fetch() (bool, error) {
+	refspec := fmt.Sprintf("+refs/heads/%s:%s", r.Branch, r.RemoteRef())
+	if _, err := r.git("fetch", "--quiet", remoteName, refspec); err != nil {
+		// An empty remote has no branch to fetch yet
+		if _, lsErr := r.git("ls-remote", "--exit-code", "--heads", remoteName, r.Branch); lsErr != nil {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Push uploads the local branch to the remote
+func (r *Repo) Push() error {
+	_, err := r.git("push", "--quiet", remoteName, "HEAD:refs/heads/"+r.Branch)
+	return err
+}
+
+// Head returns the commit of the local branch, or "" before the first commit
+func (r *Repo) Head() string {
+	return r.resolve("HEAD")
+}
+
+// RemoteHead returns the commit of the remote branch as of the last Fetch
+func (r *Repo) RemoteHead() string {
+	return r.resolve(r.RemoteRef())
+}
+
+// resolve returns the commit a revision points to, or "" if it does not exist
+func (r *Repo) resolve(rev string) string {
+	commit, err := r.git("rev-parse", "--verify", "--quiet", rev+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return commit
+}
+
+// IsAncestor reports whether commit a is an ancestor of, or equal to, commit b
+func (r *Repo) IsAncestor(a, b string) bool {
+	_, err := r.git("merge-base", "--is-ancestor", a, b)
+	return err == nil
+}
+
+// MergeBase returns the best common ancestor of two commits, or "" if their
+// histories are unrelated
+func (r *Repo) MergeBase(a, b string) string {
+	base, err := r.git("merge-base", a, b)
+	if err != nil {
+		return ""
+	}
+	return base
+}
+
+// ReadFile returns the contents of a file at a commit
+func (r *Repo) ReadFile(commit, name string) ([]byte, error) {
+	cmd := exec.Command("git", "show", commit+":"+name)
+	cmd.Dir = r.Dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	data, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show: %s", strings.TrimSpace(stderr.String()))
+	}
+	return data, nil
+}
+
+// Commit writes data to the file name and commits it. It reports whether
+// anything changed.
+func (r *Repo) Commit(name string, data []byte, message string) (bool, error) {
+	if err := r.writeFile(name, data); err != nil {
+		return false, err
+	}
+	if r.Head() != "" {
+		if _, err := r.git("diff", "--quiet", "HEAD", "--", name); err == nil {
+			return false, nil
+		}
+	}
+
+	if _, err := r.git("commit", "--quiet", "-m", message, "--", name); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// FastForward moves the local branch to commit, which must descend from it
+func (r *Repo) FastForward(commit string) error {
+	if r.Head() == "" {
+		_, err := r.git("reset", "--quiet", "--hard", commit)
+		return err
+	}
+	_, err := r.git("merge", "--quiet", "--ff-only", commit)
+	return err
+}
+
+// CommitMerge records a merge of commit into the local branch whose result
+// is data for the file name
+func (r *Repo) CommitMerge(commit, name string, data []byte, message string) error {
+	if _, err := r.git("merge", "--quiet", "--no-ff", "--no-commit", "--strategy", "ours",
+		"--allow-unrelated-histories", commit); err != nil {
+		return err
+	}
+	if err := r.writeFile(name, data); err != nil {
+		r.git("merge", "--abort")
+		return err
+	}
+	if _, err := r.git("commit", "--quiet", "-m", message); err != nil {
+		r.git("merge", "--abort")
+		return err
+	}
+	return nil
+}
+
+// writeFile replaces a file in the working tree and stages it
+func (r *Repo) writeFile(name string, data []byte) error {
+	if err := os.WriteFile(filepath.Join(r.Dir, name), data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	_, err := r.git("add", "--", name)
+	return err
+}
@@ -0,0 +1,262 @@
+package gitsync
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+const vaultFile = "vault.json"
+
+// newRemote creates an empty bare repository to synchronize through. The
+// test is skipped when git is not installed.
+func newRemote(t *testing.T) string {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	// Keep the user's git configuration out of the tests
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+
+	remote := filepath.Join(t.TempDir(), "remote.git")
+	if out, err := exec.Command("git", "init", "--quiet", "--bare", remote).CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v: %s", err, out)
+	}
+	return remote
+}
+
+// newDevice opens a fresh sync repository that uses remote
+func newDevice(t *testing.T, remote string) *Repo {
+	t.Helper()
+
+	repo, err := Open(filepath.Join(t.TempDir(), "sync"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := repo.SetRemote(remote); err != nil {
+		t.Fatalf("SetRemote: %v", err)
+	}
+	if got := repo.Remote(); got != remote {
+		t.Fatalf("Remote() = %q, want %q", got, remote)
+	}
+	return repo
+}
+
+// commit commits data as the vault file
+func commit(t *testing.T, repo *Repo, data string) {
+	t.Helper()
+
+	if _, err := repo.Commit(vaultFile, []byte(data), "Update vault"); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+// fetch fetches the remote branch, which must exist
+func fetch(t *testing.T, repo *Repo) {
+	t.Helper()
+
+	exists, err := repo.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if !exists {
+		t.Fatal("Fetch found no remote branch")
+	}
+}
+
+// checkFile fails the test unless the vault file at commit holds want
+func checkFile(t *testing.T, repo *Repo, commit, want string) {
+	t.Helper()
+
+	got, err := repo.ReadFile(commit, vaultFile)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if string(got) != want {
+		t.Errorf("vault file at %s = %q, want %q", commit, got, want)
+	}
+}
+
+// checkClean fails the test if a merge is left in progress or the working
+// tree differs from the last commit
+func checkClean(t *testing.T, repo *Repo) {
+	t.Helper()
+
+	if _, err := os.Stat(filepath.Join(repo.Dir, ".git", "MERGE_HEAD")); !os.IsNotExist(err) {
+		t.Error("a merge is still in progress")
+	}
+	if status, err := repo.git("status", "--porcelain"); err != nil || status != "" {
+		t.Errorf("working tree is not clean: %q, %v", status, err)
+	}
+}
+
+func TestFastForward(t *testing.T) {
+	remote := newRemote(t)
+	first, second := newDevice(t, remote), newDevice(t, remote)
+
+	// The first push goes to an empty remote
+	if exists, err := first.Fetch(); err != nil || exists {
+		t.Fatalf("Fetch from empty remote = %v, %v, want false, nil", exists, err)
+	}
+	commit(t, first, "one")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	// A device without a vault takes the remote one
+	fetch(t, second)
+	if second.Head() != "" {
+		t.Fatalf("new repository has head %s", second.Head())
+	}
+	if err := second.FastForward(second.RemoteHead()); err != nil {
+		t.Fatalf("FastForward: %v", err)
+	}
+	if second.Head() != first.Head() {
+		t.Errorf("head after clone = %s, want %s", second.Head(), first.Head())
+	}
+
+	commit(t, first, "two")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+
+	// Committing unchanged data records nothing
+	changed, err := second.Commit(vaultFile, []byte("one"), "Update vault")
+	if err != nil || changed {
+		t.Fatalf("Commit of unchanged data = %v, %v, want false, nil", changed, err)
+	}
+	fetch(t, second)
+	if !second.IsAncestor(second.Head(), second.RemoteHead()) {
+		t.Fatal("local head is not an ancestor of the remote head")
+	}
+	if err := second.FastForward(second.RemoteHead()); err != nil {
+		t.Fatalf("FastForward: %v", err)
+	}
+
+	checkFile(t, second, "HEAD", "two")
+	data, err := os.ReadFile(filepath.Join(second.Dir, vaultFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "two" {
+		t.Errorf("working tree file = %q, want %q", data, "two")
+	}
+	checkClean(t, second)
+}
+
+func TestDivergedMerge(t *testing.T) {
+	remote := newRemote(t)
+	first, second := newDevice(t, remote), newDevice(t, remote)
+
+	commit(t, first, "one\ntwo\n")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	fetch(t, second)
+	if err := second.FastForward(second.RemoteHead()); err != nil {
+		t.Fatalf("FastForward: %v", err)
+	}
+	base := second.Head()
+
+	// Each device changes a different line
+	commit(t, first, "ONE\ntwo\n")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	commit(t, second, "one\nTWO\n")
+	if err := second.Push(); err == nil {
+		t.Fatal("Push of a diverged branch succeeded")
+	}
+
+	fetch(t, second)
+	head, remoteHead := second.Head(), second.RemoteHead()
+	if second.IsAncestor(head, remoteHead) || second.IsAncestor(remoteHead, head) {
+		t.Fatal("diverged branches are reported as ancestors")
+	}
+	if got := second.MergeBase(head, remoteHead); got != base {
+		t.Fatalf("MergeBase = %s, want %s", got, base)
+	}
+	checkFile(t, second, base, "one\ntwo\n")
+	checkFile(t, second, remoteHead, "ONE\ntwo\n")
+
+	if err := second.CommitMerge(remoteHead, vaultFile, []byte("ONE\nTWO\n"), "Merge vault changes"); err != nil {
+		t.Fatalf("CommitMerge: %v", err)
+	}
+	checkClean(t, second)
+	if err := second.Push(); err != nil {
+		t.Fatalf("Push after merge: %v", err)
+	}
+
+	// The other device now fast-forwards to the merge
+	fetch(t, first)
+	if !first.IsAncestor(first.Head(), first.RemoteHead()) {
+		t.Fatal("merge does not descend from the other device's head")
+	}
+	if err := first.FastForward(first.RemoteHead()); err != nil {
+		t.Fatalf("FastForward: %v", err)
+	}
+	checkFile(t, first, "HEAD", "ONE\nTWO\n")
+}
+
+func TestConflictingMerge(t *testing.T) {
+	remote := newRemote(t)
+	first, second := newDevice(t, remote), newDevice(t, remote)
+
+	commit(t, first, "one\n")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	fetch(t, second)
+	if err := second.FastForward(second.RemoteHead()); err != nil {
+		t.Fatalf("FastForward: %v", err)
+	}
+
+	// Both devices change the same line, which git cannot merge by itself
+	commit(t, first, "first\n")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	commit(t, second, "second\n")
+	fetch(t, second)
+	remoteHead := second.RemoteHead()
+
+	// The caller's resolution is recorded as is, without conflict markers
+	if err := second.CommitMerge(remoteHead, vaultFile, []byte("resolved\n"), "Merge vault changes"); err != nil {
+		t.Fatalf("CommitMerge: %v", err)
+	}
+	checkClean(t, second)
+	checkFile(t, second, "HEAD", "resolved\n")
+	if !second.IsAncestor(remoteHead, second.Head()) {
+		t.Error("merge commit does not descend from the remote head")
+	}
+	if err := second.Push(); err != nil {
+		t.Fatalf("Push after merge: %v", err)
+	}
+}
+
+func TestUnrelatedHistories(t *testing.T) {
+	remote := newRemote(t)
+	first, second := newDevice(t, remote), newDevice(t, remote)
+
+	// Both devices committed their own vault before the first sync
+	commit(t, first, "first\n")
+	if err := first.Push(); err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	commit(t, second, "second\n")
+	fetch(t, second)
+
+	if base := second.MergeBase(second.Head(), second.RemoteHead()); base != "" {
+		t.Fatalf("MergeBase of unrelated histories = %s, want none", base)
+	}
+	if err := second.CommitMerge(second.RemoteHead(), vaultFile, []byte("both\n"), "Merge vault changes"); err != nil {
+		t.Fatalf("CommitMerge: %v", err)
+	}
+	checkClean(t, second)
+	if err := second.Push(); err != nil {
+		t.Fatalf("Push after merge: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// Bitwarden item types
+const (
+	bitwardenLogin      = 1
+	bitwardenSecureNote = 2
+	bitwardenCard       = 3
+	bitwardenIdentity   = 4
+)
+
+type bitwardenExport struct {
+	Encrypted bool              `json:"encrypted"`
+	Folders   []bitwardenFolder `json:"folders"`
+	Items     []bitwardenItem   `json:"items"`
+}
+
+type bitwardenFolder struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type bitwardenItem struct {
+	FolderID     string              `json:"folderId"`
+	Type         int                 `json:"type"`
+	Name         string              `json:"name"`
+	Notes        string              `json:"notes"`
+	Favorite     bool                `json:"favorite"`
+	Fields       []bitwardenField    `json:"fields"`
+	Login        *bitwardenLoginData `json:"login"`
+	Card         map[string]any      `json:"card"`
+	Identity     map[string]any      `json:"identity"`
+	CreationDate time.Time           `json:"creationDate"`
+	RevisionDate time.Time           `json:"revisionDate"`
+}
+
+type bitwardenField struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type bitwardenLoginData struct {
+	URIs     []bitwardenURI `json:"uris"`
+	Username string         `json:"username"`
+	Password string         `json:"password"`
+	TOTP     string         `json:"totp"`
+}
+
+type bitwardenURI struct {
+	URI string `json:"uri"`
+}
+
+// parseBitwarden reads an unencrypted Bitwarden JSON export
+func parseBitwarden(data []byte) ([]*models.Entry, error) {
+	var export bitwardenExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	if export.Encrypted {
+		return nil, errors.New("encrypted Bitwarden exports are not supported, export as unencrypted JSON")
+	}
+
+	folders := make(map[string]string, len(export.Folders))
+	for _, folder := range export.Folders {
+		folders[folder.ID] = folder.Name
+	}
+
+	entries := make([]*models.Entry, 0, len(export.Items))
+	for _, item := range export.Items {
+		entry := newEntry(item.Name, "", "", "", item.Notes)
+		entry.Folder = folders[item.FolderID]
+		setTimes(entry, item.CreationDate, item.RevisionDate)
+
+		switch item.Type {
+		case bitwardenLogin:
+			addTag(entry, "login")
+		case bitwardenSecureNote:
+			addTag(entry, "note")
+		case bitwardenCard:
+			addTag(entry, "card")
+		case bitwardenIdentity:
+			addTag(entry, "identity")
+		}
+
+		if item.Login != nil {
+			entry.Username = item.Login.Username
+			entry.Password = item.Login.Password
+			for i, uri := range item.Login.URIs {
+				if i == 0 {
+					entry.URL = uri.URI
+				} else {
+					addCustom(entry, "url", uri.URI)
+				}
+			}
+			setOTP(entry, item.Login.TOTP)
+		}
+
+		addObjectFields(entry, item.Card)
+		addObjectFields(entry, item.Identity)
+
+		for _, field := range item.Fields {
+			addCustom(entry, field.Name, field.Value)
+		}
+
+		if item.Favorite {
+			addTag(entry, "favorite")
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// addObjectFields stores the non-empty scalar fields of a card or identity as custom fields
+func addObjectFields(entry *models.Entry, object map[string]any) {
+	for key, value := range object {
+		switch v := value.(type) {
+		case string:
+			addCustom(entry, key, v)
+		case float64, bool:
+			addCustom(entry, key, fmt.Sprint(v))
+		}
+	}
+}
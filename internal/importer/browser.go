@@ -0,0 +1,57 @@
+package importer
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// browserKnownColumns are the columns mapped onto entry fields; anything else
+// in a browser export becomes a custom field
+var browserKnownColumns = map[string]bool{
+	"name": true, "title": true, "url": true, "username": true, "password": true,
+	"note": true, "notes": true, "timecreated": true, "timepasswordchanged": true,
+	"timelastused": true, "guid": true, "httprealm": true, "formactionorigin": true,
+}
+
+// parseBrowserCSV reads a browser password CSV export. Chrome, Edge and Brave
+// write name,url,username,password,note; Firefox and Safari exports with their
+// own column names are understood as well.
+func parseBrowserCSV(data []byte) ([]*models.Entry, error) {
+	records, header, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	if !hasColumns(header, "url", "username", "password") {
+		return nil, errors.New("missing browser export columns (url, username, password)")
+	}
+
+	entries := make([]*models.Entry, 0, len(records))
+	for _, record := range records {
+		entry := newEntry(record.get("name", "title"), record["username"], record["password"],
+			record["url"], record.get("note", "notes"))
+
+		// Firefox records times as milliseconds since the epoch
+		setTimes(entry, millisTime(record["timecreated"]), millisTime(record["timepasswordchanged"]))
+
+		for _, column := range header {
+			if !browserKnownColumns[column] {
+				addCustom(entry, column, record[column])
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func millisTime(value string) time.Time {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || millis <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis)
+}
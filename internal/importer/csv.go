@@ -0,0 +1,70 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+)
+
+// csvRecord is one row of a CSV export keyed by lowercased column name
+type csvRecord map[string]string
+
+// get returns the first non-empty value among the given column names
+func (r csvRecord) get(columns ...string) string {
+	for _, column := range columns {
+		if value := r[column]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// readCSV parses a CSV export with a header row
+func readCSV(data []byte) ([]csvRecord, []string, error) {
+	// Strip a UTF-8 byte order mark, which spreadsheet tools like to add
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, errors.New("empty CSV file")
+	}
+
+	header := make([]string, len(rows[0]))
+	for i, column := range rows[0] {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+	}
+
+	records := make([]csvRecord, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := make(csvRecord, len(header))
+		for i, value := range row {
+			if i < len(header) {
+				record[header[i]] = value
+			}
+		}
+		records = append(records, record)
+	}
+
+	return records, header, nil
+}
+
+// hasColumns reports whether the header contains all the given columns
+func hasColumns(header []string, columns ...string) bool {
+	present := make(map[string]bool, len(header))
+	for _, column := range header {
+		present[column] = true
+	}
+	for _, column := range columns {
+		if !present[column] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,14 @@
+package importer
+
+import "github.com/egemengunel/Go-Password-Manager/models"
+
+// FindDuplicate returns the existing entry that represents the same account
+// as entry, or nil. See models.Entry.IsDuplicate for what counts as the same account.
+func FindDuplicate(entry *models.Entry, existing []*models.Entry) *models.Entry {
+	for _, candidate := range existing {
+		if entry.IsDuplicate(candidate) {
+			return candidate
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// gopassmanExport is the document written by 'gopassman export --format gopassman-json'
+type gopassmanExport struct {
+	Format  string          `json:"format"`
+	Version int             `json:"version"`
+	Entries []*models.Entry `json:"entries"`
+}
+
+// parseGopassman reads gopassman's own JSON export. Entries keep every field
+// and timestamp but get fresh IDs so they never replace entries already in the vault.
+func parseGopassman(data []byte) ([]*models.Entry, error) {
+	var export gopassmanExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, err
+	}
+	if export.Format != "gopassman-export" {
+		return nil, fmt.Errorf("not a gopassman export")
+	}
+	if export.Version > 1 {
+		return nil, fmt.Errorf("unsupported export version %d, please upgrade gopassman", export.Version)
+	}
+
+	entries := make([]*models.Entry, 0, len(export.Entries))
+	for _, entry := range export.Entries {
+		if entry == nil {
+			continue
+		}
+		entry.ID = models.NewID()
+		if entry.Tags == nil {
+			entry.Tags = make([]string, 0)
+		}
+		if entry.Custom == nil {
+			entry.Custom = make(map[string]string)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
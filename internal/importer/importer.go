@@ -0,0 +1,196 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/internal/otp"
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// Supported import formats
+const (
+	FormatBitwardenJSON = "bitwarden-json"
+	Format1PUX          = "1pux"
+	FormatLastPassCSV   = "lastpass-csv"
+	FormatKeePassXML    = "keepass-xml"
+	FormatChromeCSV     = "chrome-csv"
+	FormatGopassmanJSON = "gopassman-json"
+	FormatKDBX          = "kdbx"
+)
+
+// parser converts the contents of an export file into entries
+type parser func(data []byte) ([]*models.Entry, error)
+
+var parsers = map[string]parser{
+	FormatBitwardenJSON: parseBitwarden,
+	Format1PUX:          parse1PUX,
+	FormatLastPassCSV:   parseLastPass,
+	FormatKeePassXML:    parseKeePassXML,
+	FormatChromeCSV:     parseBrowserCSV,
+	FormatGopassmanJSON: parseGopassman,
+}
+
+// passwordParser converts the contents of a password protected file into entries
+type passwordParser func(data []byte, password string) ([]*models.Entry, error)
+
+var passwordParsers = map[string]passwordParser{
+	FormatKDBX: parseKDBX,
+}
+
+// Formats returns the supported import format names
+func Formats() []string {
+	formats := make([]string, 0, len(parsers)+len(passwordParsers))
+	for format := range parsers {
+		formats = append(formats, format)
+	}
+	for format := range passwordParsers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// NeedsPassword reports whether files in the format are encrypted and
+// ParseFile must be given their password
+func NeedsPassword(format string) bool {
+	_, ok := passwordParsers[format]
+	return ok
+}
+
+// ParseFile reads an export file in the given format and returns its entries.
+// The password is only used by encrypted formats.
+func ParseFile(format, path, password string) ([]*models.Entry, error) {
+	parse, ok := parsers[format]
+	if !ok {
+		parseEncrypted, encrypted := passwordParsers[format]
+		if !encrypted {
+			return nil, fmt.Errorf("unsupported format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+		}
+		parse = func(data []byte) ([]*models.Entry, error) {
+			return parseEncrypted(data, password)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	entries, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s export: %w", format, err)
+	}
+
+	for _, entry := range entries {
+		if entry.Title == "" {
+			entry.Title = fallbackTitle(entry)
+		}
+		if entry.OTP == "" {
+			moveOTPField(entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// newEntry creates an entry with the fields every format provides
+func newEntry(title, username, password, url, notes string) *models.Entry {
+	entry := models.NewEntry(strings.TrimSpace(title), username, password)
+	entry.URL = strings.TrimSpace(url)
+	entry.Notes = notes
+	return entry
+}
+
+// setTimes overrides an entry's timestamps with the ones from the export, when known
+func setTimes(entry *models.Entry, created, updated time.Time) {
+	if !created.IsZero() {
+		entry.CreatedAt = created
+		entry.AccessedAt = created
+	}
+	if !updated.IsZero() {
+		entry.UpdatedAt = updated
+	} else if !created.IsZero() {
+		entry.UpdatedAt = created
+	}
+}
+
+// addCustom stores a custom field, keeping fields with repeated names apart
+func addCustom(entry *models.Entry, name, value string) {
+	name = strings.TrimSpace(name)
+	if value == "" {
+		return
+	}
+	if name == "" {
+		name = "field"
+	}
+
+	key := name
+	for i := 2; ; i++ {
+		if _, exists := entry.Custom[key]; !exists {
+			break
+		}
+		key = fmt.Sprintf("%s (%d)", name, i)
+	}
+
+	entry.Custom[key] = value
+}
+
+// setOTP stores a 2FA seed as the entry's otpauth URI. Values that are not
+// a valid seed are kept as a custom field.
+func setOTP(entry *models.Entry, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+
+	uri, err := otp.Normalize(value, entry.Title, entry.Username)
+	if err != nil {
+		addCustom(entry, "totp", value)
+		return
+	}
+	entry.OTP = uri
+}
+
+// moveOTPField promotes a custom field holding an otpauth URI, as formats
+// without a dedicated 2FA field export it, to the entry's OTP seed
+func moveOTPField(entry *models.Entry) {
+	for name, value := range entry.Custom {
+		if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), "otpauth://") {
+			continue
+		}
+		if uri, err := otp.Normalize(value, entry.Title, entry.Username); err == nil {
+			entry.OTP = uri
+			delete(entry.Custom, name)
+			return
+		}
+	}
+}
+
+// addTag adds a tag unless the entry already has it
+func addTag(entry *models.Entry, tag string) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return
+	}
+	for _, existing := range entry.Tags {
+		if strings.EqualFold(existing, tag) {
+			return
+		}
+	}
+	entry.Tags = append(entry.Tags, tag)
+}
+
+// fallbackTitle names entries whose export has no title
+func fallbackTitle(entry *models.Entry) string {
+	if host := entry.Host(); host != "" {
+		return host
+	}
+	if entry.Username != "" {
+		return entry.Username
+	}
+	return "Untitled"
+}
@@ -0,0 +1,54 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLastPassTOTPFillsEntryOTP(t *testing.T) {
+	data := "url,username,password,totp,extra,name,grouping,fav\n" +
+		"https://mail.example.com,me,secret,JBSWY3DPEHPK3PXP,,Mail,,0\n" +
+		"https://bank.example.com,me,secret,backup code 1234,,Bank,,0\n"
+
+	entries, err := parseLastPass([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	mail, bank := entries[0], entries[1]
+	if !strings.HasPrefix(mail.OTP, "otpauth://totp/") || !strings.Contains(mail.OTP, "secret=JBSWY3DPEHPK3PXP") {
+		t.Errorf("Mail OTP = %q, want an otpauth URI with the seed", mail.OTP)
+	}
+	if _, ok := mail.Custom["totp"]; ok {
+		t.Error("Mail kept its seed in a custom field")
+	}
+
+	// A value that is not a seed stays a custom field and is never turned into one later
+	if bank.OTP != "" {
+		t.Errorf("Bank OTP = %q, want none", bank.OTP)
+	}
+	if bank.Custom["totp"] != "backup code 1234" {
+		t.Errorf("Bank custom totp = %q, want the original value", bank.Custom["totp"])
+	}
+}
+
+func TestBitwardenTOTPFillsEntryOTP(t *testing.T) {
+	data := `{"items":[{"type":1,"name":"Mail","login":{"username":"me","password":"secret","totp":"JBSWY3DPEHPK3PXP"}}]}`
+
+	entries, err := parseBitwarden([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if !strings.HasPrefix(entries[0].OTP, "otpauth://totp/") {
+		t.Errorf("OTP = %q, want an otpauth URI", entries[0].OTP)
+	}
+	if len(entries[0].Custom) != 0 {
+		t.Errorf("custom fields = %v, want none", entries[0].Custom)
+	}
+}
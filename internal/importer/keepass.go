@@ -0,0 +1,34 @@
+package importer
+
+import (
+	"github.com/egemengunel/Go-Password-Manager/internal/kdbx"
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// parseKeePassXML reads a KeePass 2.x XML export (as written by KeePass and KeePassXC)
+func parseKeePassXML(data []byte) ([]*models.Entry, error) {
+	db, err := kdbx.ParseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	return keePassEntries(db), nil
+}
+
+// parseKDBX reads a KeePass KDBX 4 database
+func parseKDBX(data []byte, password string) ([]*models.Entry, error) {
+	db, err := kdbx.Decode(data, password)
+	if err != nil {
+		return nil, err
+	}
+	return keePassEntries(db), nil
+}
+
+// keePassEntries converts the entries of a KeePass database. They get fresh
+// IDs so importing the same database twice never replaces existing entries.
+func keePassEntries(db *kdbx.Database) []*models.Entry {
+	entries := db.Entries()
+	for _, entry := range entries {
+		entry.ID = models.NewID()
+	}
+	return entries
+}
@@ -0,0 +1,46 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// lastPassNoteURL marks secure notes in LastPass exports
+const lastPassNoteURL = "http://sn"
+
+// parseLastPass reads a LastPass CSV export
+// (url,username,password,totp,extra,name,grouping,fav)
+func parseLastPass(data []byte) ([]*models.Entry, error) {
+	records, header, err := readCSV(data)
+	if err != nil {
+		return nil, err
+	}
+	if !hasColumns(header, "url", "username", "password", "name") {
+		return nil, errors.New("missing LastPass columns (url, username, password, name)")
+	}
+
+	entries := make([]*models.Entry, 0, len(records))
+	for _, record := range records {
+		url := record["url"]
+		if url == lastPassNoteURL {
+			url = ""
+		}
+
+		entry := newEntry(record["name"], record["username"], record["password"], url, record["extra"])
+		entry.Folder = strings.ReplaceAll(record["grouping"], "\\", "/")
+		setOTP(entry, record["totp"])
+
+		if record["url"] == lastPassNoteURL {
+			addTag(entry, "note")
+		}
+		if record["fav"] == "1" {
+			addTag(entry, "favorite")
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
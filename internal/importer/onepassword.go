@@ -0,0 +1,186 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// onePUXDataFile is the file inside a .1pux archive that holds the items
+const onePUXDataFile = "export.data"
+
+type onePUXExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePUXItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+type onePUXItem struct {
+	FavIndex  int    `json:"favIndex"`
+	CreatedAt int64  `json:"createdAt"`
+	UpdatedAt int64  `json:"updatedAt"`
+	State     string `json:"state"`
+	Details   struct {
+		LoginFields []struct {
+			Value       string `json:"value"`
+			Name        string `json:"name"`
+			Designation string `json:"designation"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Title  string `json:"title"`
+			Fields []struct {
+				Title string                     `json:"title"`
+				Value map[string]json.RawMessage `json:"value"`
+			} `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+	Overview struct {
+		Title string `json:"title"`
+		URL   string `json:"url"`
+		URLs  []struct {
+			URL string `json:"url"`
+		} `json:"urls"`
+		Tags []string `json:"tags"`
+	} `json:"overview"`
+}
+
+// parse1PUX reads a 1Password .1pux export archive
+func parse1PUX(data []byte) ([]*models.Entry, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a 1pux archive: %w", err)
+	}
+
+	file, err := archive.Open(onePUXDataFile)
+	if err != nil {
+		return nil, errors.New("1pux archive has no export.data")
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var export onePUXExport
+	if err := json.Unmarshal(content, &export); err != nil {
+		return nil, err
+	}
+
+	var entries []*models.Entry
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, item := range vault.Items {
+				entry := convert1PUXItem(item)
+				entry.Folder = vault.Attrs.Name
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+func convert1PUXItem(item onePUXItem) *models.Entry {
+	entry := newEntry(item.Overview.Title, "", item.Details.Password, item.Overview.URL, item.Details.NotesPlain)
+	setTimes(entry, unixTime(item.CreatedAt), unixTime(item.UpdatedAt))
+
+	for _, field := range item.Details.LoginFields {
+		switch field.Designation {
+		case "username":
+			entry.Username = field.Value
+		case "password":
+			entry.Password = field.Value
+		default:
+			addCustom(entry, field.Name, field.Value)
+		}
+	}
+
+	for _, u := range item.Overview.URLs {
+		if u.URL != "" && u.URL != entry.URL {
+			if entry.URL == "" {
+				entry.URL = u.URL
+			} else {
+				addCustom(entry, "url", u.URL)
+			}
+		}
+	}
+
+	for _, section := range item.Details.Sections {
+		for _, field := range section.Fields {
+			name := field.Title
+			if section.Title != "" {
+				name = section.Title + ": " + field.Title
+			}
+			addCustom(entry, name, onePUXValue(field.Value))
+		}
+	}
+
+	for _, tag := range item.Overview.Tags {
+		addTag(entry, tag)
+	}
+	if item.FavIndex > 0 {
+		addTag(entry, "favorite")
+	}
+	if item.State == "archived" {
+		addTag(entry, "archived")
+	}
+
+	return entry
+}
+
+// onePUXValue flattens a typed 1Password field value such as {"concealed": "..."}
+// or {"email": {"email_address": "..."}} into a string
+func onePUXValue(value map[string]json.RawMessage) string {
+	// Iterate in a stable order; values normally have a single key
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		raw := value[key]
+
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s
+		}
+
+		var n json.Number
+		if err := json.Unmarshal(raw, &n); err == nil {
+			return n.String()
+		}
+
+		var object map[string]any
+		if err := json.Unmarshal(raw, &object); err == nil {
+			if email, ok := object["email_address"].(string); ok {
+				return email
+			}
+			return string(raw)
+		}
+	}
+
+	return ""
+}
+
+func unixTime(seconds int64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package argon2d
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// TestRFC9106 checks the Argon2d test vector of RFC 9106, section 5.1
+func TestRFC9106(t *testing.T) {
+	password := bytes.Repeat([]byte{0x01}, 32)
+	salt := bytes.Repeat([]byte{0x02}, 16)
+	secret := bytes.Repeat([]byte{0x03}, 8)
+	data := bytes.Repeat([]byte{0x04}, 12)
+	want := []byte{
+		0x51, 0x2b, 0x39, 0x1b, 0x6f, 0x11, 0x62, 0x97,
+		0x53, 0x71, 0xd3, 0x09, 0x19, 0x73, 0x42, 0x94,
+		0xf8, 0x68, 0xe3, 0xbe, 0x39, 0x84, 0xf3, 0xc1,
+		0xa1, 0x3a, 0x4d, 0xb9, 0xfa, 0xbe, 0x4a, 0xcb,
+	}
+
+	hash := deriveKey(password, salt, secret, data, 3, 32, 4, 32)
+	if !bytes.Equal(hash, want) {
+		t.Errorf("derived key does not match - got: %s , want: %s", hex.EncodeToString(hash), hex.EncodeToString(want))
+	}
+}
+
+// TestVectors checks the Argon2d vectors of golang.org/x/crypto/argon2,
+// which use neither a secret nor associated data, like KeePass
+func TestVectors(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt")
+	for i, v := range testVectors {
+		want, err := hex.DecodeString(v.hash)
+		if err != nil {
+			t.Fatalf("Test %d: failed to decode hash: %v", i, err)
+		}
+		hash := Key(password, salt, v.time, v.memory, v.threads, uint32(len(want)))
+		if !bytes.Equal(hash, want) {
+			t.Errorf("Test %d - got: %s want: %s", i, hex.EncodeToString(hash), hex.EncodeToString(want))
+		}
+	}
+}
+
+var testVectors = []struct {
+	time, memory uint32
+	threads      uint8
+	hash         string
+}{
+	{time: 1, memory: 64, threads: 1, hash: "8727405fd07c32c78d64f547f24150d3f2e703a89f981a19"},
+	{time: 2, memory: 64, threads: 1, hash: "3be9ec79a69b75d3752acb59a1fbb8b295a46529c48fbb75"},
+	{time: 2, memory: 64, threads: 2, hash: "68e2462c98b8bc6bb60ec68db418ae2c9ed24fc6748a40e9"},
+	{time: 3, memory: 256, threads: 2, hash: "f4f0669218eaf3641f39cc97efb915721102f4b128211ef2"},
+	{time: 4, memory: 4096, threads: 4, hash: "935598181aa8dc2b720914aa6435ac8d3e3a4210c5b0fb2d"},
+	{time: 4, memory: 1024, threads: 8, hash: "83604fc2ad0589b9d055578f4d3cc55bc616df3578a896e9"},
+	{time: 2, memory: 64, threads: 3, hash: "22474a423bda2ccd36ec9afd5119e5c8949798cadf659f51"},
+	{time: 3, memory: 1024, threads: 6, hash: "a3351b0319a53229152023d9206902f4ef59661cdca89481"},
+}
@@ -0,0 +1,193 @@
+package kdbx
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// Entries maps the database onto gopassman entries. Group paths below the
+// root group become folders, the standard strings map onto entry fields and
+// all other strings become custom fields. Entries in the recycle bin are skipped.
+func (db *Database) Entries() []*models.Entry {
+	var entries []*models.Entry
+
+	// The root group is the database itself, not a folder
+	return db.collectEntries(entries, &db.Root, "")
+}
+
+// collectEntries walks a group tree, appending entries with their folder path
+func (db *Database) collectEntries(entries []*models.Entry, group *Group, folder string) []*models.Entry {
+	if !db.Meta.RecycleBinUUID.IsZero() && group.UUID == db.Meta.RecycleBinUUID {
+		return entries
+	}
+
+	for i := range group.Entries {
+		entry := group.Entries[i].ToEntry()
+		entry.Folder = folder
+		entries = append(entries, entry)
+	}
+
+	for i := range group.Groups {
+		child := &group.Groups[i]
+		path := child.Name
+		if folder != "" {
+			path = folder + "/" + child.Name
+		}
+		entries = db.collectEntries(entries, child, path)
+	}
+
+	return entries
+}
+
+// ToEntry converts a KeePass entry to a gopassman entry with the same ID
+func (e *Entry) ToEntry() *models.Entry {
+	entry := models.NewEntry("", "", "")
+	entry.ID = e.UUID.String()
+
+	for _, field := range e.Strings {
+		value := field.Value.Content
+		switch field.Key {
+		case KeyTitle:
+			entry.Title = strings.TrimSpace(value)
+		case KeyUserName:
+			entry.Username = value
+		case KeyPassword:
+			entry.Password = value
+		case KeyURL:
+			entry.URL = strings.TrimSpace(value)
+		case KeyNotes:
+			entry.Notes = value
+		case KeyOTP:
+			entry.OTP = strings.TrimSpace(value)
+		default:
+			if value != "" {
+				entry.Custom[field.Key] = value
+			}
+		}
+	}
+
+	for _, tag := range strings.FieldsFunc(e.Tags, func(r rune) bool { return r == ';' || r == ',' }) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			entry.Tags = append(entry.Tags, tag)
+		}
+	}
+
+	if created := e.Times.CreationTime; !created.IsZero() {
+		entry.CreatedAt = created.Time
+		entry.UpdatedAt = created.Time
+		entry.AccessedAt = created.Time
+	}
+	if modified := e.Times.LastModificationTime; !modified.IsZero() {
+		entry.UpdatedAt = modified.Time
+	}
+	if accessed := e.Times.LastAccessTime; !accessed.IsZero() {
+		entry.AccessedAt = accessed.Time
+	}
+
+	// History items are complete entries, oldest first. Each one was
+	// replaced when the next one (or the current entry) was saved.
+	for i := range e.History {
+		version := e.History[i].ToEntry().Version()
+		version.ReplacedAt = entry.UpdatedAt
+		if i+1 < len(e.History) {
+			if next := e.History[i+1].Times.LastModificationTime; !next.IsZero() {
+				version.ReplacedAt = next.Time
+			}
+		}
+		entry.History = append(entry.History, version)
+	}
+
+	return entry
+}
+
+// AddEntries adds gopassman entries to the database, creating a group for
+// every folder path below the root group
+func (db *Database) AddEntries(entries []*models.Entry) {
+	for _, entry := range entries {
+		group := &db.Root
+		if entry.Folder != "" {
+			for _, name := range strings.Split(entry.Folder, "/") {
+				group = group.subgroup(name)
+			}
+		}
+		group.Entries = append(group.Entries, db.NewEntry(entry))
+	}
+}
+
+// subgroup returns the child group with the given name, creating it if needed
+func (g *Group) subgroup(name string) *Group {
+	for i := range g.Groups {
+		if g.Groups[i].Name == name {
+			return &g.Groups[i]
+		}
+	}
+
+	g.Groups = append(g.Groups, Group{
+		UUID:   NewUUID(),
+		Name:   name,
+		IconID: 48,
+		Times:  NewTimes(),
+	})
+	return &g.Groups[len(g.Groups)-1]
+}
+
+// NewEntry converts a gopassman entry to a KeePass entry. The entry ID is
+// kept as the KeePass UUID so repeated exports update the same records.
+func (db *Database) NewEntry(entry *models.Entry) Entry {
+	uuid, err := ParseUUID(entry.ID)
+	if err != nil {
+		uuid = NewUUID()
+	}
+
+	protection := db.Meta.MemoryProtection
+	item := Entry{
+		UUID: uuid,
+		Tags: strings.Join(entry.Tags, ";"),
+		Times: Times{
+			CreationTime:         keePassTime(entry.CreatedAt),
+			LastModificationTime: keePassTime(entry.UpdatedAt),
+			LastAccessTime:       keePassTime(entry.AccessedAt),
+			ExpiryTime:           keePassTime(entry.CreatedAt),
+			LocationChanged:      keePassTime(entry.UpdatedAt),
+		},
+	}
+
+	item.Set(KeyTitle, entry.Title, bool(protection.ProtectTitle))
+	item.Set(KeyUserName, entry.Username, bool(protection.ProtectUserName))
+	item.Set(KeyPassword, entry.Password, bool(protection.ProtectPassword))
+	item.Set(KeyURL, entry.URL, bool(protection.ProtectURL))
+	item.Set(KeyNotes, entry.Notes, bool(protection.ProtectNotes))
+	if entry.OTP != "" {
+		item.Set(KeyOTP, entry.OTP, true)
+	}
+
+	keys := make([]string, 0, len(entry.Custom))
+	for key := range entry.Custom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		item.Set(key, entry.Custom[key], false)
+	}
+
+	// Previous versions become history items with the values of that version
+	for _, version := range entry.History {
+		previous := *entry
+		previous.History = nil
+		for _, field := range models.HistoryFields {
+			previous.SetField(field, version.Field(field))
+		}
+		previous.UpdatedAt = version.ModifiedAt
+		item.History = append(item.History, db.NewEntry(&previous))
+	}
+
+	return item
+}
+
+// keePassTime truncates a timestamp to the second precision of KDBX 4
+func keePassTime(t time.Time) Time {
+	return Time{t.UTC().Truncate(time.Second)}
+}
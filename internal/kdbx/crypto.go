@@ -0,0 +1,244 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"golang.org/x/crypto/chacha20"
+	"golang.org/x/crypto/salsa20/salsa"
+	"golang.org/x/crypto/twofish"
+)
+
+// Inner random stream identifiers
+const (
+	innerStreamSalsa20  = 2
+	innerStreamChaCha20 = 3
+)
+
+// salsa20Nonce is the fixed nonce of the Salsa20 inner stream
+var salsa20Nonce = []byte{0xE8, 0x30, 0x09, 0x4B, 0x97, 0x20, 0x5D, 0x2A}
+
+// compositeKey hashes the password into the KeePass composite key
+func compositeKey(password string) []byte {
+	passwordHash := sha256.Sum256([]byte(password))
+	composite := sha256.Sum256(passwordHash[:])
+	return composite[:]
+}
+
+// payloadKeys derives the payload encryption key and the HMAC base key
+func payloadKeys(masterSeed, transformedKey []byte) (encryptionKey, hmacKey []byte) {
+	encryption := sha256.New()
+	encryption.Write(masterSeed)
+	encryption.Write(transformedKey)
+
+	mac := sha512.New()
+	mac.Write(masterSeed)
+	mac.Write(transformedKey)
+	mac.Write([]byte{1})
+
+	return encryption.Sum(nil), mac.Sum(nil)
+}
+
+// blockHMACKey derives the HMAC key of the block with the given index.
+// The header is authenticated as block math.MaxUint64.
+func blockHMACKey(hmacKey []byte, index uint64) []byte {
+	h := sha512.New()
+	binary.Write(h, binary.LittleEndian, index)
+	h.Write(hmacKey)
+	return h.Sum(nil)
+}
+
+// headerHMAC computes the HMAC stored after the header hash
+func headerHMAC(hmacKey, header []byte) []byte {
+	mac := hmac.New(sha256.New, blockHMACKey(hmacKey, math.MaxUint64))
+	mac.Write(header)
+	return mac.Sum(nil)
+}
+
+// blockHMAC authenticates one block of the HMAC block stream
+func blockHMAC(hmacKey []byte, index uint64, data []byte) []byte {
+	mac := hmac.New(sha256.New, blockHMACKey(hmacKey, index))
+	binary.Write(mac, binary.LittleEndian, index)
+	binary.Write(mac, binary.LittleEndian, uint32(len(data)))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// readBlocks verifies and joins the HMAC block stream that follows the header
+func readBlocks(r *bytes.Reader, hmacKey []byte) ([]byte, error) {
+	var payload bytes.Buffer
+	for index := uint64(0); ; index++ {
+		var block struct {
+			MAC  [32]byte
+			Size uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &block); err != nil {
+			return nil, ErrCorrupt
+		}
+		if int64(block.Size) > int64(r.Len()) {
+			return nil, ErrCorrupt
+		}
+
+		data := make([]byte, block.Size)
+		r.Read(data)
+		if !hmac.Equal(block.MAC[:], blockHMAC(hmacKey, index, data)) {
+			return nil, ErrCorrupt
+		}
+
+		if block.Size == 0 {
+			return payload.Bytes(), nil
+		}
+		payload.Write(data)
+	}
+}
+
+// writeBlocks splits the payload into authenticated blocks
+func writeBlocks(buf *bytes.Buffer, payload []byte, hmacKey []byte) {
+	index := uint64(0)
+	for {
+		size := min(len(payload), hmacBlockSize)
+		data := payload[:size]
+		payload = payload[size:]
+
+		buf.Write(blockHMAC(hmacKey, index, data))
+		binary.Write(buf, binary.LittleEndian, uint32(size))
+		buf.Write(data)
+
+		if size == 0 {
+			return
+		}
+		index++
+	}
+}
+
+// decryptPayload decrypts the payload with the outer cipher
+func decryptPayload(cipherID UUID, key, iv, data []byte) ([]byte, error) {
+	switch cipherID {
+	case CipherChaCha20:
+		stream, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(data))
+		stream.XORKeyStream(plaintext, data)
+		return plaintext, nil
+
+	case CipherAES256, CipherTwofish:
+		block, err := newBlockCipher(cipherID, key)
+		if err != nil {
+			return nil, err
+		}
+		if len(iv) != block.BlockSize() || len(data)%block.BlockSize() != 0 || len(data) == 0 {
+			return nil, ErrCorrupt
+		}
+		plaintext := make([]byte, len(data))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, data)
+
+		// Remove PKCS#7 padding
+		padding := int(plaintext[len(plaintext)-1])
+		if padding == 0 || padding > block.BlockSize() {
+			return nil, ErrCorrupt
+		}
+		return plaintext[:len(plaintext)-padding], nil
+
+	default:
+		return nil, fmt.Errorf("unsupported cipher %s", cipherID)
+	}
+}
+
+// encryptPayload encrypts the payload with the outer cipher
+func encryptPayload(cipherID UUID, key, iv, data []byte) ([]byte, error) {
+	switch cipherID {
+	case CipherChaCha20:
+		stream, err := chacha20.NewUnauthenticatedCipher(key, iv)
+		if err != nil {
+			return nil, err
+		}
+		ciphertext := make([]byte, len(data))
+		stream.XORKeyStream(ciphertext, data)
+		return ciphertext, nil
+
+	case CipherAES256, CipherTwofish:
+		block, err := newBlockCipher(cipherID, key)
+		if err != nil {
+			return nil, err
+		}
+
+		// Add PKCS#7 padding
+		padding := block.BlockSize() - len(data)%block.BlockSize()
+		padded := append(append([]byte{}, data...), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+		ciphertext := make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, padded)
+		return ciphertext, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported cipher %s", cipherID)
+	}
+}
+
+// newBlockCipher creates the block cipher of a CBC mode payload cipher
+func newBlockCipher(cipherID UUID, key []byte) (cipher.Block, error) {
+	if cipherID == CipherTwofish {
+		return twofish.NewCipher(key)
+	}
+	return aes.NewCipher(key)
+}
+
+// ivSize returns the IV length a payload cipher expects
+func ivSize(cipherID UUID) int {
+	if cipherID == CipherChaCha20 {
+		return chacha20.NonceSize
+	}
+	return aes.BlockSize
+}
+
+// innerStream is the keystream protected values are XORed with, in document order
+type innerStream interface {
+	XORKeyStream(dst, src []byte)
+}
+
+// newInnerStream creates the inner random stream described by the inner header
+func newInnerStream(id uint32, key []byte) (innerStream, error) {
+	switch id {
+	case innerStreamChaCha20:
+		hash := sha512.Sum512(key)
+		return chacha20.NewUnauthenticatedCipher(hash[:32], hash[32:44])
+	case innerStreamSalsa20:
+		stream := &salsa20Stream{used: 64}
+		stream.key = sha256.Sum256(key)
+		copy(stream.counter[:8], salsa20Nonce)
+		return stream, nil
+	default:
+		return nil, errors.New("unsupported inner random stream")
+	}
+}
+
+// salsa20Stream is a Salsa20 keystream that can be consumed piecewise
+type salsa20Stream struct {
+	key     [32]byte
+	counter [16]byte
+	block   [64]byte
+	used    int
+}
+
+func (s *salsa20Stream) XORKeyStream(dst, src []byte) {
+	for i := range src {
+		if s.used == len(s.block) {
+			var zero [64]byte
+			salsa.XORKeyStream(s.block[:], zero[:], &s.counter, &s.key)
+			binary.LittleEndian.PutUint64(s.counter[8:], binary.LittleEndian.Uint64(s.counter[8:])+1)
+			s.used = 0
+		}
+		dst[i] = src[i] ^ s.block[s.used]
+		s.used++
+	}
+}
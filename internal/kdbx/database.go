@@ -0,0 +1,203 @@
+package kdbx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+
+	"github.com/egemengunel/Go-Password-Manager/crypto"
+)
+
+// Decode reads and decrypts a KDBX 4 database protected by a password
+func Decode(data []byte, password string) (*Database, error) {
+	r := bytes.NewReader(data)
+
+	header, err := readOuterHeader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes struct {
+		SHA256 [32]byte
+		HMAC   [32]byte
+	}
+	if err := binary.Read(r, binary.LittleEndian, &hashes); err != nil {
+		return nil, ErrCorrupt
+	}
+	if sum := sha256.Sum256(header.raw); !hmac.Equal(sum[:], hashes.SHA256[:]) {
+		return nil, ErrCorrupt
+	}
+
+	// Derive the keys; a header HMAC mismatch means the password is wrong
+	transformedKey, err := header.kdf.transform(compositeKey(password))
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, hmacKey := payloadKeys(header.masterSeed, transformedKey)
+	defer crypto.SecureZero(encryptionKey)
+	defer crypto.SecureZero(hmacKey)
+	crypto.SecureZero(transformedKey)
+
+	if !hmac.Equal(headerHMAC(hmacKey, header.raw), hashes.HMAC[:]) {
+		return nil, ErrInvalidCredentials
+	}
+
+	ciphertext, err := readBlocks(r, hmacKey)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := decryptPayload(header.cipher, encryptionKey, header.encryptionIV, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.compressed {
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+		payload, err = io.ReadAll(gz)
+		if err != nil {
+			return nil, ErrCorrupt
+		}
+	}
+
+	body := bytes.NewReader(payload)
+	inner, err := readInnerHeader(body)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := newInnerStream(inner.streamID, inner.streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	document, err = transformProtected(document, stream, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read protected values: %w", err)
+	}
+
+	db, err := ParseXML(document)
+	if err != nil {
+		return nil, err
+	}
+	db.Binaries = inner.binaries
+	db.Settings = Settings{
+		Cipher:   header.cipher,
+		KDF:      header.kdf,
+		Compress: header.compressed,
+	}
+	return db, nil
+}
+
+// ParseXML reads an unencrypted KeePass XML document, such as an XML export.
+// Values are taken as they appear in the document.
+func ParseXML(document []byte) (*Database, error) {
+	var file xmlFile
+	if err := xml.Unmarshal(document, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse database XML: %w", err)
+	}
+
+	return &Database{
+		Settings: DefaultSettings(),
+		Meta:     file.Meta,
+		Root:     file.Root.Group,
+	}, nil
+}
+
+// Encode encrypts the database with a password using its Settings. A fresh
+// master seed, IV, KDF salt and inner stream key are generated on every call.
+func (db *Database) Encode(password string) ([]byte, error) {
+	settings := db.Settings
+	if settings.Cipher.IsZero() {
+		settings = DefaultSettings()
+	}
+	settings.KDF.Salt = randomBytes(32)
+
+	header := &outerHeader{
+		cipher:       settings.Cipher,
+		compressed:   settings.Compress,
+		masterSeed:   randomBytes(32),
+		encryptionIV: randomBytes(ivSize(settings.Cipher)),
+		kdf:          settings.KDF,
+	}
+	rawHeader := header.encode()
+
+	transformedKey, err := header.kdf.transform(compositeKey(password))
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, hmacKey := payloadKeys(header.masterSeed, transformedKey)
+	defer crypto.SecureZero(encryptionKey)
+	defer crypto.SecureZero(hmacKey)
+	crypto.SecureZero(transformedKey)
+
+	// Build the inner header and XML document with protected values encrypted
+	inner := &innerHeader{
+		streamID:  innerStreamChaCha20,
+		streamKey: randomBytes(64),
+		binaries:  db.Binaries,
+	}
+	stream, err := newInnerStream(inner.streamID, inner.streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := db.marshalXML()
+	if err != nil {
+		return nil, err
+	}
+	document, err = transformProtected(document, stream, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var payload bytes.Buffer
+	inner.encode(&payload)
+	payload.Write(document)
+
+	plaintext := payload.Bytes()
+	if header.compressed {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		gz.Write(plaintext)
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		plaintext = compressed.Bytes()
+	}
+
+	ciphertext, err := encryptPayload(header.cipher, encryptionKey, header.encryptionIV, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.Write(rawHeader)
+	headerHash := sha256.Sum256(rawHeader)
+	out.Write(headerHash[:])
+	out.Write(headerHMAC(hmacKey, rawHeader))
+	writeBlocks(&out, ciphertext, hmacKey)
+	return out.Bytes(), nil
+}
+
+// marshalXML serializes the database document with protected values in plaintext
+func (db *Database) marshalXML() ([]byte, error) {
+	file := xmlFile{Meta: db.Meta}
+	file.Root.Group = db.Root
+
+	data, err := xml.Marshal(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal database XML: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
@@ -0,0 +1,132 @@
+package kdbx
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// testEntries returns entries using every field the conversion maps
+func testEntries() []*models.Entry {
+	updated := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mail := models.NewEntry("Mail", "me@example.com", "correct horse")
+	mail.URL = "https://mail.example.com"
+	mail.Notes = "first line\nsecond line"
+	mail.OTP = "otpauth://totp/Mail:me?secret=JBSWY3DPEHPK3PXP&issuer=Mail"
+	mail.Tags = []string{"personal", "email"}
+	mail.Custom = map[string]string{"Recovery code": "1234-5678"}
+	mail.UpdatedAt = updated
+
+	bank := models.NewEntry("Bank", "12345", "<&\"tricky\">")
+	bank.Folder = "Finance/Banks"
+	bank.UpdatedAt = updated
+
+	return []*models.Entry{mail, bank}
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	fastArgon2 := KDFParams{UUID: KDFArgon2d, Salt: randomBytes(32), Iterations: 2, Memory: 64 * 1024, Parallelism: 2, Version: 0x13}
+	fastArgon2id := fastArgon2
+	fastArgon2id.UUID = KDFArgon2id
+	fastAES := KDFParams{UUID: KDFAES, Salt: randomBytes(32), Rounds: 1000}
+
+	tests := []struct {
+		name     string
+		settings Settings
+	}{
+		{"AES-256 Argon2d", Settings{Cipher: CipherAES256, KDF: fastArgon2, Compress: true}},
+		{"ChaCha20 Argon2id", Settings{Cipher: CipherChaCha20, KDF: fastArgon2id, Compress: true}},
+		{"Twofish AES-KDF uncompressed", Settings{Cipher: CipherTwofish, KDF: fastAES}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := NewDatabase("Test")
+			db.Settings = test.settings
+			entries := testEntries()
+			db.AddEntries(entries)
+
+			data, err := db.Encode("master password")
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded, err := Decode(data, "master password")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if decoded.Meta.DatabaseName != "Test" {
+				t.Errorf("database name = %q, want Test", decoded.Meta.DatabaseName)
+			}
+			got := decoded.Entries()
+			if len(got) != len(entries) {
+				t.Fatalf("got %d entries, want %d", len(got), len(entries))
+			}
+			for i, want := range entries {
+				compareEntries(t, got[i], want)
+			}
+		})
+	}
+}
+
+// compareEntries reports the fields of got that differ from want
+func compareEntries(t *testing.T, got, want *models.Entry) {
+	t.Helper()
+
+	fields := []struct{ name, got, want string }{
+		{"ID", got.ID, want.ID},
+		{"title", got.Title, want.Title},
+		{"username", got.Username, want.Username},
+		{"password", got.Password, want.Password},
+		{"URL", got.URL, want.URL},
+		{"notes", got.Notes, want.Notes},
+		{"OTP", got.OTP, want.OTP},
+		{"folder", got.Folder, want.Folder},
+	}
+	for _, field := range fields {
+		if field.got != field.want {
+			t.Errorf("%s: %s = %q, want %q", want.Title, field.name, field.got, field.want)
+		}
+	}
+
+	if !slices.Equal(got.Tags, want.Tags) {
+		t.Errorf("%s: tags = %v, want %v", want.Title, got.Tags, want.Tags)
+	}
+	for key, value := range want.Custom {
+		if got.Custom[key] != value {
+			t.Errorf("%s: custom field %q = %q, want %q", want.Title, key, got.Custom[key], value)
+		}
+	}
+	if !got.UpdatedAt.Equal(want.UpdatedAt) {
+		t.Errorf("%s: updated at %v, want %v", want.Title, got.UpdatedAt, want.UpdatedAt)
+	}
+}
+
+func TestDecodeRejectsWrongPasswordAndTampering(t *testing.T) {
+	db := NewDatabase("Test")
+	db.Settings.KDF = KDFParams{UUID: KDFAES, Salt: randomBytes(32), Rounds: 1000}
+	db.AddEntries(testEntries())
+
+	data, err := db.Encode("master password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Decode(data, "wrong password"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("wrong password gave %v, want ErrInvalidCredentials", err)
+	}
+
+	tampered := slices.Clone(data)
+	tampered[len(tampered)-10] ^= 1
+	if _, err := Decode(tampered, "master password"); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("tampered payload gave %v, want ErrCorrupt", err)
+	}
+
+	if _, err := Decode([]byte("not a database"), "master password"); !errors.Is(err, ErrNotKDBX) {
+		t.Errorf("garbage gave %v, want ErrNotKDBX", err)
+	}
+}
@@ -0,0 +1,226 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Outer header field identifiers
+const (
+	headerEnd              = 0
+	headerCipherID         = 2
+	headerCompression      = 3
+	headerMasterSeed       = 4
+	headerEncryptionIV     = 7
+	headerKDFParameters    = 11
+	headerPublicCustomData = 12
+)
+
+// Inner header field identifiers
+const (
+	innerHeaderEnd       = 0
+	innerHeaderStreamID  = 1
+	innerHeaderStreamKey = 2
+	innerHeaderBinary    = 3
+)
+
+// compressionGzip marks a gzip compressed payload
+const compressionGzip = 1
+
+// outerHeader is the unencrypted header at the start of the file
+type outerHeader struct {
+	cipher       UUID
+	compressed   bool
+	masterSeed   []byte
+	encryptionIV []byte
+	kdf          KDFParams
+	// raw is the serialized header, covered by the header hash and HMAC
+	raw []byte
+}
+
+// readOuterHeader parses the signature, version and header fields
+func readOuterHeader(r *bytes.Reader) (*outerHeader, error) {
+	start := r.Size() - int64(r.Len())
+
+	var preamble struct {
+		Signature1 uint32
+		Signature2 uint32
+		Minor      uint16
+		Major      uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &preamble); err != nil {
+		return nil, ErrNotKDBX
+	}
+	if preamble.Signature1 != signature1 || preamble.Signature2 != signature2 {
+		return nil, ErrNotKDBX
+	}
+	if preamble.Major != majorVersion {
+		return nil, fmt.Errorf("KDBX %d.%d databases are not supported, save the database in KDBX 4 format", preamble.Major, preamble.Minor)
+	}
+	if preamble.Minor > maxMinorVersion {
+		return nil, fmt.Errorf("KDBX %d.%d databases are not supported yet", preamble.Major, preamble.Minor)
+	}
+
+	header := &outerHeader{}
+	seen := make(map[byte]bool)
+	for {
+		id, data, err := readField(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		if id == headerEnd {
+			break
+		}
+		seen[id] = true
+
+		switch id {
+		case headerCipherID:
+			if len(data) != len(header.cipher) {
+				return nil, errors.New("invalid cipher ID in header")
+			}
+			copy(header.cipher[:], data)
+		case headerCompression:
+			if len(data) != 4 {
+				return nil, errors.New("invalid compression flags in header")
+			}
+			header.compressed = binary.LittleEndian.Uint32(data) == compressionGzip
+		case headerMasterSeed:
+			if len(data) != 32 {
+				return nil, errors.New("invalid master seed in header")
+			}
+			header.masterSeed = data
+		case headerEncryptionIV:
+			header.encryptionIV = data
+		case headerKDFParameters:
+			params, err := readVariantDictionary(data)
+			if err != nil {
+				return nil, fmt.Errorf("invalid KDF parameters: %w", err)
+			}
+			if header.kdf, err = kdfFromDictionary(params); err != nil {
+				return nil, err
+			}
+			if err := header.kdf.validate(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, id := range []byte{headerCipherID, headerMasterSeed, headerEncryptionIV, headerKDFParameters} {
+		if !seen[id] {
+			return nil, fmt.Errorf("header field %d is missing", id)
+		}
+	}
+
+	end := r.Size() - int64(r.Len())
+	header.raw = make([]byte, end-start)
+	r.ReadAt(header.raw, start)
+	return header, nil
+}
+
+// encode serializes the header and stores the result in raw
+func (h *outerHeader) encode() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, signature1)
+	binary.Write(&buf, binary.LittleEndian, signature2)
+	binary.Write(&buf, binary.LittleEndian, minorVersion)
+	binary.Write(&buf, binary.LittleEndian, majorVersion)
+
+	compression := make([]byte, 4)
+	if h.compressed {
+		binary.LittleEndian.PutUint32(compression, compressionGzip)
+	}
+
+	writeField(&buf, headerCipherID, h.cipher[:])
+	writeField(&buf, headerCompression, compression)
+	writeField(&buf, headerMasterSeed, h.masterSeed)
+	writeField(&buf, headerEncryptionIV, h.encryptionIV)
+	writeField(&buf, headerKDFParameters, h.kdf.dictionary().encode())
+	writeField(&buf, headerEnd, []byte(headerTerminator))
+
+	h.raw = buf.Bytes()
+	return h.raw
+}
+
+// innerHeader is the header at the start of the decrypted payload
+type innerHeader struct {
+	streamID  uint32
+	streamKey []byte
+	binaries  []Binary
+}
+
+// readInnerHeader parses the inner header and leaves r at the XML document
+func readInnerHeader(r *bytes.Reader) (*innerHeader, error) {
+	header := &innerHeader{}
+	for {
+		id, data, err := readField(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read inner header: %w", err)
+		}
+
+		switch id {
+		case innerHeaderEnd:
+			return header, nil
+		case innerHeaderStreamID:
+			if len(data) != 4 {
+				return nil, errors.New("invalid inner stream ID")
+			}
+			header.streamID = binary.LittleEndian.Uint32(data)
+		case innerHeaderStreamKey:
+			header.streamKey = data
+		case innerHeaderBinary:
+			if len(data) < 1 {
+				return nil, errors.New("invalid binary in inner header")
+			}
+			header.binaries = append(header.binaries, Binary{Protected: data[0]&1 != 0, Data: data[1:]})
+		}
+	}
+}
+
+// encode serializes the inner header
+func (h *innerHeader) encode(buf *bytes.Buffer) {
+	streamID := make([]byte, 4)
+	binary.LittleEndian.PutUint32(streamID, h.streamID)
+
+	writeField(buf, innerHeaderStreamID, streamID)
+	writeField(buf, innerHeaderStreamKey, h.streamKey)
+	for _, binary := range h.binaries {
+		flags := byte(0)
+		if binary.Protected {
+			flags = 1
+		}
+		writeField(buf, innerHeaderBinary, append([]byte{flags}, binary.Data...))
+	}
+	writeField(buf, innerHeaderEnd, nil)
+}
+
+// readField reads a type-length-value field with a 4 byte little-endian length
+func readField(r *bytes.Reader) (byte, []byte, error) {
+	id, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return 0, nil, err
+	}
+	if int64(length) > int64(r.Len()) {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	return id, data, nil
+}
+
+// writeField writes a type-length-value field with a 4 byte little-endian length
+func writeField(buf *bytes.Buffer, id byte, data []byte) {
+	buf.WriteByte(id)
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+}
@@ -0,0 +1,105 @@
+// Package kdbx reads and writes KeePass KDBX 4 databases, the format used by
+// KeePass 2.x and KeePassXC, and maps them onto gopassman entries.
+package kdbx
+
+import (
+	"crypto/rand"
+	"errors"
+)
+
+// File signatures and the format version written by Encode
+const (
+	signature1       uint32 = 0x9AA2D903
+	signature2       uint32 = 0xB54BFB67
+	majorVersion     uint16 = 4
+	minorVersion     uint16 = 0
+	maxMinorVersion  uint16 = 1
+	hmacBlockSize           = 1024 * 1024
+	headerTerminator        = "\r\n\r\n"
+)
+
+var (
+	// ErrInvalidCredentials is returned when the password does not open the database
+	ErrInvalidCredentials = errors.New("invalid KeePass database password")
+	// ErrCorrupt is returned when the file fails an integrity check
+	ErrCorrupt = errors.New("KeePass database is corrupt or was modified")
+	// ErrNotKDBX is returned for files that are not KeePass databases
+	ErrNotKDBX = errors.New("not a KeePass KDBX database")
+)
+
+// Cipher identifiers for the outer payload encryption
+var (
+	CipherAES256   = UUID{0x31, 0xc1, 0xf2, 0xe6, 0xbf, 0x71, 0x43, 0x50, 0xbe, 0x58, 0x05, 0x21, 0x6a, 0xfc, 0x5a, 0xff}
+	CipherChaCha20 = UUID{0xd6, 0x03, 0x8a, 0x2b, 0x8b, 0x6f, 0x4c, 0xb5, 0xa5, 0x24, 0x33, 0x9a, 0x31, 0xdb, 0xb5, 0x9a}
+	CipherTwofish  = UUID{0xad, 0x68, 0xf2, 0x9f, 0x57, 0x6f, 0x4b, 0xb9, 0xa3, 0x6a, 0xd4, 0x7a, 0xf9, 0x65, 0x34, 0x6c}
+)
+
+// Database is a decrypted KDBX database
+type Database struct {
+	// Settings describes how the database is encrypted when written
+	Settings Settings
+	Meta     Meta
+	Root     Group
+	// Binaries holds the attachments entries refer to by index
+	Binaries []Binary
+}
+
+// Binary is an attachment stored in the inner header
+type Binary struct {
+	Protected bool
+	Data      []byte
+}
+
+// Settings controls the encryption of a written database
+type Settings struct {
+	Cipher   UUID
+	KDF      KDFParams
+	Compress bool
+}
+
+// DefaultSettings returns AES-256 encryption with an Argon2d key derivation,
+// the combination every KDBX 4 reader supports
+func DefaultSettings() Settings {
+	return Settings{
+		Cipher: CipherAES256,
+		KDF: KDFParams{
+			UUID:        KDFArgon2d,
+			Salt:        randomBytes(32),
+			Iterations:  10,
+			Memory:      64 * 1024 * 1024,
+			Parallelism: 2,
+			Version:     0x13,
+		},
+		Compress: true,
+	}
+}
+
+// NewDatabase returns an empty database with a root group and default settings
+func NewDatabase(name string) *Database {
+	return &Database{
+		Settings: DefaultSettings(),
+		Meta: Meta{
+			Generator:           "gopassman",
+			DatabaseName:        name,
+			DatabaseNameChanged: Now(),
+			MemoryProtection: MemoryProtection{
+				ProtectPassword: true,
+			},
+			HistoryMaxItems: 10,
+			HistoryMaxSize:  6 * 1024 * 1024,
+		},
+		Root: Group{
+			UUID:   NewUUID(),
+			Name:   "Root",
+			IconID: 48,
+			Times:  NewTimes(),
+		},
+	}
+}
+
+// randomBytes returns n bytes from crypto/rand
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b) // crypto/rand.Read never returns an error
+	return b
+}
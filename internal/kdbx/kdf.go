@@ -0,0 +1,306 @@
+package kdbx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/egemengunel/Go-Password-Manager/internal/kdbx/argon2d"
+)
+
+// Key derivation function identifiers
+var (
+	KDFArgon2d  = UUID{0xef, 0x63, 0x6d, 0xdf, 0x8c, 0x29, 0x44, 0x4b, 0x91, 0xf7, 0xa9, 0xa4, 0x03, 0xe3, 0x0a, 0x0c}
+	KDFArgon2id = UUID{0x9e, 0x29, 0x8b, 0x19, 0x56, 0xdb, 0x47, 0x73, 0xb2, 0x3d, 0xfc, 0x3e, 0xc6, 0xf0, 0xa1, 0xe6}
+	KDFAES      = UUID{0xc9, 0xd9, 0xf3, 0x9a, 0x62, 0x8a, 0x44, 0x60, 0xbf, 0x74, 0x0d, 0x08, 0xc1, 0x8a, 0x4f, 0xea}
+)
+
+// Bounds on KDF parameters read from a file. A crafted header can still
+// make one derivation cost about a gigabyte of memory and some seconds of
+// CPU, but not exhaust the machine or run for hours.
+const (
+	maxArgon2Memory      = 1024 * 1024 * 1024
+	maxArgon2Parallelism = 255
+	// maxArgon2Work caps memory in KiB times iterations, the number of
+	// blocks Argon2 fills; 1 GiB allows 16 passes, 64 MiB allows 256
+	maxArgon2Work = 16 * 1024 * 1024
+	// maxAESRounds is several times what KeePass calibrates to one second
+	maxAESRounds = 100_000_000
+)
+
+// KDFParams describes how the transformed key is derived from the composite key
+type KDFParams struct {
+	UUID UUID
+	Salt []byte
+	// Argon2 parameters; Memory is in bytes
+	Iterations  uint64
+	Memory      uint64
+	Parallelism uint32
+	Version     uint32
+	// AES-KDF parameter
+	Rounds uint64
+}
+
+// validate checks the parameters against the supported ranges, so that a
+// hostile header is rejected before any key derivation starts
+func (p KDFParams) validate() error {
+	switch p.UUID {
+	case KDFArgon2d, KDFArgon2id:
+		if p.Memory < 8*1024 || p.Memory > maxArgon2Memory {
+			return fmt.Errorf("unsupported Argon2 memory %d", p.Memory)
+		}
+		if p.Iterations < 1 || p.Iterations > maxArgon2Work/(p.Memory/1024) {
+			return fmt.Errorf("unsupported Argon2 iterations %d with %d KiB of memory", p.Iterations, p.Memory/1024)
+		}
+		if p.Parallelism < 1 || p.Parallelism > maxArgon2Parallelism {
+			return fmt.Errorf("unsupported Argon2 parallelism %d", p.Parallelism)
+		}
+		if p.Version != 0x13 {
+			return fmt.Errorf("unsupported Argon2 version %#x", p.Version)
+		}
+	case KDFAES:
+		if len(p.Salt) != 32 {
+			return errors.New("invalid AES-KDF seed")
+		}
+		if p.Rounds < 1 || p.Rounds > maxAESRounds {
+			return fmt.Errorf("unsupported AES-KDF rounds %d", p.Rounds)
+		}
+	default:
+		return fmt.Errorf("unsupported key derivation function %s", p.UUID)
+	}
+	return nil
+}
+
+// transform derives the transformed key from the composite key
+func (p KDFParams) transform(compositeKey []byte) ([]byte, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+
+	switch p.UUID {
+	case KDFArgon2d, KDFArgon2id:
+		memory := uint32(p.Memory / 1024)
+		if p.UUID == KDFArgon2d {
+			return argon2d.Key(compositeKey, p.Salt, uint32(p.Iterations), memory, uint8(p.Parallelism), 32), nil
+		}
+		return argon2.IDKey(compositeKey, p.Salt, uint32(p.Iterations), memory, uint8(p.Parallelism), 32), nil
+
+	default:
+		block, err := aes.NewCipher(p.Salt)
+		if err != nil {
+			return nil, err
+		}
+		key := make([]byte, len(compositeKey))
+		copy(key, compositeKey)
+		for i := uint64(0); i < p.Rounds; i++ {
+			block.Encrypt(key[:16], key[:16])
+			block.Encrypt(key[16:], key[16:])
+		}
+		sum := sha256.Sum256(key)
+		return sum[:], nil
+	}
+}
+
+// dictionary returns the KDF parameters as a variant dictionary
+func (p KDFParams) dictionary() variantDictionary {
+	dict := variantDictionary{{"$UUID", p.UUID[:]}}
+	if p.UUID == KDFAES {
+		return append(dict, variantItem{"R", p.Rounds}, variantItem{"S", p.Salt})
+	}
+	return append(dict,
+		variantItem{"S", p.Salt},
+		variantItem{"P", p.Parallelism},
+		variantItem{"M", p.Memory},
+		variantItem{"I", p.Iterations},
+		variantItem{"V", p.Version},
+	)
+}
+
+// kdfFromDictionary reads KDF parameters from a variant dictionary
+func kdfFromDictionary(dict variantDictionary) (KDFParams, error) {
+	var params KDFParams
+
+	id, ok := dict.get("$UUID").([]byte)
+	if !ok || len(id) != len(params.UUID) {
+		return params, errors.New("KDF parameters have no valid $UUID")
+	}
+	copy(params.UUID[:], id)
+
+	params.Salt, _ = dict.get("S").([]byte)
+	params.Rounds, _ = dict.get("R").(uint64)
+	params.Iterations, _ = dict.get("I").(uint64)
+	params.Memory, _ = dict.get("M").(uint64)
+	params.Parallelism, _ = dict.get("P").(uint32)
+	params.Version, _ = dict.get("V").(uint32)
+	return params, nil
+}
+
+// Variant dictionary value types
+const (
+	variantEnd       = 0x00
+	variantUInt32    = 0x04
+	variantUInt64    = 0x05
+	variantBool      = 0x08
+	variantInt32     = 0x0C
+	variantInt64     = 0x0D
+	variantString    = 0x18
+	variantByteArray = 0x42
+)
+
+// variantDictionaryVersion is the only supported major version of the encoding
+const variantDictionaryVersion = 0x0100
+
+// variantItem is one typed key/value pair of a variant dictionary
+type variantItem struct {
+	key   string
+	value any
+}
+
+// variantDictionary is KeePass's typed key/value encoding, kept in file order
+type variantDictionary []variantItem
+
+// get returns the value stored under key, or nil
+func (d variantDictionary) get(key string) any {
+	for _, item := range d {
+		if item.key == key {
+			return item.value
+		}
+	}
+	return nil
+}
+
+// readVariantDictionary parses a serialized variant dictionary
+func readVariantDictionary(data []byte) (variantDictionary, error) {
+	r := bytes.NewReader(data)
+
+	var version uint16
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, err
+	}
+	if version&0xFF00 != variantDictionaryVersion&0xFF00 {
+		return nil, fmt.Errorf("unsupported variant dictionary version %#x", version)
+	}
+
+	var dict variantDictionary
+	for {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if kind == variantEnd {
+			return dict, nil
+		}
+
+		key, err := readSized(r)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := readSized(r)
+		if err != nil {
+			return nil, err
+		}
+
+		var value any
+		switch kind {
+		case variantUInt32:
+			if len(raw) != 4 {
+				return nil, errors.New("invalid uint32 value")
+			}
+			value = binary.LittleEndian.Uint32(raw)
+		case variantUInt64:
+			if len(raw) != 8 {
+				return nil, errors.New("invalid uint64 value")
+			}
+			value = binary.LittleEndian.Uint64(raw)
+		case variantBool:
+			if len(raw) != 1 {
+				return nil, errors.New("invalid bool value")
+			}
+			value = raw[0] != 0
+		case variantInt32:
+			if len(raw) != 4 {
+				return nil, errors.New("invalid int32 value")
+			}
+			value = int32(binary.LittleEndian.Uint32(raw))
+		case variantInt64:
+			if len(raw) != 8 {
+				return nil, errors.New("invalid int64 value")
+			}
+			value = int64(binary.LittleEndian.Uint64(raw))
+		case variantString:
+			value = string(raw)
+		case variantByteArray:
+			value = raw
+		default:
+			return nil, fmt.Errorf("unknown variant type %#x", kind)
+		}
+
+		dict = append(dict, variantItem{string(key), value})
+	}
+}
+
+// encode serializes the dictionary
+func (d variantDictionary) encode() []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint16(variantDictionaryVersion))
+
+	for _, item := range d {
+		var kind byte
+		var raw []byte
+		switch v := item.value.(type) {
+		case uint32:
+			kind, raw = variantUInt32, binary.LittleEndian.AppendUint32(nil, v)
+		case uint64:
+			kind, raw = variantUInt64, binary.LittleEndian.AppendUint64(nil, v)
+		case bool:
+			kind, raw = variantBool, []byte{0}
+			if v {
+				raw[0] = 1
+			}
+		case int32:
+			kind, raw = variantInt32, binary.LittleEndian.AppendUint32(nil, uint32(v))
+		case int64:
+			kind, raw = variantInt64, binary.LittleEndian.AppendUint64(nil, uint64(v))
+		case string:
+			kind, raw = variantString, []byte(v)
+		case []byte:
+			kind, raw = variantByteArray, v
+		default:
+			continue
+		}
+
+		buf.WriteByte(kind)
+		writeSized(&buf, []byte(item.key))
+		writeSized(&buf, raw)
+	}
+
+	buf.WriteByte(variantEnd)
+	return buf.Bytes()
+}
+
+// readSized reads a value prefixed with its 4 byte little-endian length
+func readSized(r *bytes.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	if int64(length) > int64(r.Len()) || length > math.MaxInt32 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, length)
+	_, err := io.ReadFull(r, data)
+	return data, err
+}
+
+// writeSized writes a value prefixed with its 4 byte little-endian length
+func writeSized(buf *bytes.Buffer, data []byte) {
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+}
@@ -0,0 +1,94 @@
+package kdbx
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAESKDFRejectsUnboundedRounds(t *testing.T) {
+	compositeKey := bytes.Repeat([]byte{1}, 32)
+	seed := bytes.Repeat([]byte{2}, 32)
+
+	for _, rounds := range []uint64{0, maxAESRounds + 1, 1<<64 - 1} {
+		params := KDFParams{UUID: KDFAES, Salt: seed, Rounds: rounds}
+		if _, err := params.transform(compositeKey); err == nil {
+			t.Errorf("rounds %d accepted, want an error", rounds)
+		}
+	}
+
+	params := KDFParams{UUID: KDFAES, Salt: seed, Rounds: 1000}
+	if _, err := params.transform(compositeKey); err != nil {
+		t.Errorf("rounds 1000: %v", err)
+	}
+}
+
+func TestKDFParamsValidate(t *testing.T) {
+	argon2 := func(memory, iterations uint64) KDFParams {
+		return KDFParams{UUID: KDFArgon2d, Salt: randomBytes(32), Memory: memory, Iterations: iterations, Parallelism: 2, Version: 0x13}
+	}
+
+	tests := []struct {
+		name   string
+		params KDFParams
+		valid  bool
+	}{
+		{"defaults", DefaultSettings().KDF, true},
+		{"1 GiB, 16 passes", argon2(1<<30, 16), true},
+		{"64 MiB, 256 passes", argon2(64<<20, 256), true},
+		{"too little memory", argon2(4*1024, 1), false},
+		{"over 1 GiB", argon2(1<<30+1024, 1), false},
+		{"4 GiB", argon2(4<<30, 1), false},
+		{"1 GiB, 17 passes", argon2(1<<30, 17), false},
+		{"64 MiB, 257 passes", argon2(64<<20, 257), false},
+		{"no passes", argon2(64<<20, 0), false},
+		{"huge iterations", argon2(64<<20, 1<<40), false},
+		{"AES rounds", KDFParams{UUID: KDFAES, Salt: randomBytes(32), Rounds: maxAESRounds}, true},
+		{"AES too many rounds", KDFParams{UUID: KDFAES, Salt: randomBytes(32), Rounds: maxAESRounds + 1}, false},
+		{"unknown", KDFParams{UUID: NewUUID()}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.params.validate()
+			if test.valid && err != nil {
+				t.Errorf("validate() = %v, want nil", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("validate() accepted the parameters")
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsOverLimitHeaderBeforeDerivation(t *testing.T) {
+	tests := map[string]KDFParams{
+		"argon2 memory": {UUID: KDFArgon2d, Salt: randomBytes(32), Memory: 1 << 40, Iterations: 1, Parallelism: 1, Version: 0x13},
+		"argon2 work":   {UUID: KDFArgon2id, Salt: randomBytes(32), Memory: 1 << 30, Iterations: 1 << 20, Parallelism: 1, Version: 0x13},
+		"aes rounds":    {UUID: KDFAES, Salt: randomBytes(32), Rounds: 1<<64 - 1},
+	}
+
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			header := &outerHeader{
+				cipher:       CipherAES256,
+				masterSeed:   randomBytes(32),
+				encryptionIV: randomBytes(16),
+				kdf:          params,
+			}
+			// Neither hashes nor payload follow: deriving the key first would
+			// take forever, checking the hash first would report ErrCorrupt
+			data := header.encode()
+
+			start := time.Now()
+			_, err := Decode(data, "master password")
+			if err == nil || errors.Is(err, ErrCorrupt) || errors.Is(err, ErrInvalidCredentials) {
+				t.Errorf("Decode = %v, want the KDF parameters rejected", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Decode took %v, the header was not rejected up front", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package kdbx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// transformProtected rewrites the XML document, decrypting (or encrypting)
+// the content of every Value marked Protected="True". The inner stream is
+// consumed in document order, as the format requires.
+func transformProtected(data []byte, stream innerStream, encrypt bool) ([]byte, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	encoder := xml.NewEncoder(&out)
+
+	inProtected := false
+	var text []byte
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "Value" && isProtected(t) {
+				inProtected = true
+				text = text[:0]
+			}
+		case xml.CharData:
+			if inProtected {
+				text = append(text, t...)
+				continue
+			}
+		case xml.EndElement:
+			if inProtected && t.Name.Local == "Value" {
+				inProtected = false
+				value, err := transformValue(text, stream, encrypt)
+				if err != nil {
+					return nil, err
+				}
+				if err := encoder.EncodeToken(xml.CharData(value)); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if err := encoder.EncodeToken(xml.CopyToken(token)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// transformValue XORs one protected value with the next bytes of the stream
+func transformValue(value []byte, stream innerStream, encrypt bool) ([]byte, error) {
+	if encrypt {
+		ciphertext := make([]byte, len(value))
+		stream.XORKeyStream(ciphertext, value)
+		return []byte(base64.StdEncoding.EncodeToString(ciphertext)), nil
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(value)))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	stream.XORKeyStream(ciphertext, ciphertext)
+	return ciphertext, nil
+}
+
+// isProtected reports whether a Value element carries Protected="True"
+func isProtected(element xml.StartElement) bool {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == "Protected" && strings.EqualFold(attr.Value, "true") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package kdbx
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Standard entry string keys; all other strings are custom fields
+const (
+	KeyTitle    = "Title"
+	KeyUserName = "UserName"
+	KeyPassword = "Password"
+	KeyURL      = "URL"
+	KeyNotes    = "Notes"
+	// KeyOTP is the field KeePassXC stores the otpauth URI of a 2FA seed in
+	KeyOTP = "otp"
+)
+
+// xmlFile is the XML document inside a KDBX payload
+type xmlFile struct {
+	XMLName xml.Name `xml:"KeePassFile"`
+	Meta    Meta     `xml:"Meta"`
+	Root    struct {
+		Group Group `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// Meta holds database wide settings
+type Meta struct {
+	Generator              string           `xml:"Generator"`
+	DatabaseName           string           `xml:"DatabaseName"`
+	DatabaseNameChanged    Time             `xml:"DatabaseNameChanged"`
+	DatabaseDescription    string           `xml:"DatabaseDescription"`
+	DefaultUserName        string           `xml:"DefaultUserName"`
+	MaintenanceHistoryDays int              `xml:"MaintenanceHistoryDays"`
+	MemoryProtection       MemoryProtection `xml:"MemoryProtection"`
+	RecycleBinEnabled      Bool             `xml:"RecycleBinEnabled"`
+	RecycleBinUUID         UUID             `xml:"RecycleBinUUID"`
+	HistoryMaxItems        int              `xml:"HistoryMaxItems"`
+	HistoryMaxSize         int64            `xml:"HistoryMaxSize"`
+}
+
+// MemoryProtection lists the standard fields written as protected values
+type MemoryProtection struct {
+	ProtectTitle    Bool `xml:"ProtectTitle"`
+	ProtectUserName Bool `xml:"ProtectUserName"`
+	ProtectPassword Bool `xml:"ProtectPassword"`
+	ProtectURL      Bool `xml:"ProtectURL"`
+	ProtectNotes    Bool `xml:"ProtectNotes"`
+}
+
+// Group is a folder of entries and subgroups
+type Group struct {
+	UUID    UUID    `xml:"UUID"`
+	Name    string  `xml:"Name"`
+	Notes   string  `xml:"Notes,omitempty"`
+	IconID  int     `xml:"IconID"`
+	Times   Times   `xml:"Times"`
+	Entries []Entry `xml:"Entry"`
+	Groups  []Group `xml:"Group"`
+}
+
+// Entry is a single record with its previous versions
+type Entry struct {
+	UUID     UUID        `xml:"UUID"`
+	IconID   int         `xml:"IconID"`
+	Tags     string      `xml:"Tags,omitempty"`
+	Times    Times       `xml:"Times"`
+	Strings  []String    `xml:"String"`
+	Binaries []BinaryRef `xml:"Binary"`
+	History  []Entry     `xml:"History>Entry,omitempty"`
+}
+
+// String is a named entry field
+type String struct {
+	Key   string `xml:"Key"`
+	Value Value  `xml:"Value"`
+}
+
+// Value is a field value. Protected values are encrypted with the inner
+// stream inside the file and held in plaintext in memory.
+type Value struct {
+	Protected Bool   `xml:"Protected,attr,omitempty"`
+	Content   string `xml:",chardata"`
+}
+
+// BinaryRef attaches a binary from Database.Binaries to an entry
+type BinaryRef struct {
+	Key   string `xml:"Key"`
+	Value struct {
+		Ref int `xml:"Ref,attr"`
+	} `xml:"Value"`
+}
+
+// Times holds the timestamps of a group or entry
+type Times struct {
+	CreationTime         Time `xml:"CreationTime"`
+	LastModificationTime Time `xml:"LastModificationTime"`
+	LastAccessTime       Time `xml:"LastAccessTime"`
+	ExpiryTime           Time `xml:"ExpiryTime"`
+	Expires              Bool `xml:"Expires"`
+	UsageCount           int  `xml:"UsageCount"`
+	LocationChanged      Time `xml:"LocationChanged"`
+}
+
+// NewTimes returns timestamps set to the current time
+func NewTimes() Times {
+	now := Now()
+	return Times{
+		CreationTime:         now,
+		LastModificationTime: now,
+		LastAccessTime:       now,
+		ExpiryTime:           now,
+		LocationChanged:      now,
+	}
+}
+
+// Get returns the value of a string field, or "" when the entry has none
+func (e *Entry) Get(key string) string {
+	for _, field := range e.Strings {
+		if field.Key == key {
+			return field.Value.Content
+		}
+	}
+	return ""
+}
+
+// Set adds or replaces a string field
+func (e *Entry) Set(key, value string, protected bool) {
+	for i := range e.Strings {
+		if e.Strings[i].Key == key {
+			e.Strings[i].Value = Value{Content: value, Protected: Bool(protected)}
+			return
+		}
+	}
+	e.Strings = append(e.Strings, String{Key: key, Value: Value{Content: value, Protected: Bool(protected)}})
+}
+
+// UUID is a KeePass identifier, base64 encoded in XML
+type UUID [16]byte
+
+// NewUUID returns a random UUID
+func NewUUID() UUID {
+	var u UUID
+	copy(u[:], randomBytes(len(u)))
+	return u
+}
+
+// ParseUUID parses a textual RFC 4122 UUID such as an entry ID
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	raw, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(raw) != len(u) {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	copy(u[:], raw)
+	return u, nil
+}
+
+// String formats the UUID in the usual dashed hex form
+func (u UUID) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
+
+// IsZero reports whether the UUID is unset
+func (u UUID) IsZero() bool {
+	return u == UUID{}
+}
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(base64.StdEncoding.EncodeToString(u[:])), nil
+}
+
+func (u *UUID) UnmarshalText(text []byte) error {
+	*u = UUID{}
+	if len(text) == 0 {
+		return nil
+	}
+	raw, err := base64.StdEncoding.DecodeString(string(text))
+	if err != nil || len(raw) != len(u) {
+		return fmt.Errorf("invalid UUID %q", text)
+	}
+	copy(u[:], raw)
+	return nil
+}
+
+// Bool is a KeePass boolean, written as True or False
+type Bool bool
+
+func (b Bool) MarshalText() ([]byte, error) {
+	if b {
+		return []byte("True"), nil
+	}
+	return []byte("False"), nil
+}
+
+func (b *Bool) UnmarshalText(text []byte) error {
+	*b = Bool(strings.EqualFold(strings.TrimSpace(string(text)), "true"))
+	return nil
+}
+
+// keePassEpoch is the zero point of KDBX 4 timestamps
+var keePassEpoch = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Time is a KeePass timestamp. KDBX 4 stores seconds since year 1 as a base64
+// encoded little-endian integer; XML exports and older files use ISO 8601.
+type Time struct {
+	time.Time
+}
+
+// Now returns the current time at the second precision KeePass stores
+func Now() Time {
+	return Time{time.Now().UTC().Truncate(time.Second)}
+}
+
+func (t Time) MarshalText() ([]byte, error) {
+	var raw [8]byte
+	if !t.IsZero() {
+		seconds := t.UTC().Unix() - keePassEpoch.Unix()
+		binary.LittleEndian.PutUint64(raw[:], uint64(seconds))
+	}
+	return []byte(base64.StdEncoding.EncodeToString(raw[:])), nil
+}
+
+func (t *Time) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	t.Time = time.Time{}
+	if value == "" {
+		return nil
+	}
+
+	if raw, err := base64.StdEncoding.DecodeString(value); err == nil && len(raw) == 8 {
+		seconds := int64(binary.LittleEndian.Uint64(raw))
+		if seconds != 0 {
+			t.Time = time.Unix(keePassEpoch.Unix()+seconds, 0).UTC()
+		}
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fmt.Errorf("invalid time %q", value)
+	}
+	t.Time = parsed
+	return nil
+}
@@ -0,0 +1,269 @@
+// Package otp implements HOTP (RFC 4226) and TOTP (RFC 6238) one-time
+// passwords described by otpauth:// URIs.
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Key types
+const (
+	TypeTOTP = "totp"
+	TypeHOTP = "hotp"
+)
+
+// Supported hash algorithms
+const (
+	AlgorithmSHA1   = "SHA1"
+	AlgorithmSHA256 = "SHA256"
+	AlgorithmSHA512 = "SHA512"
+)
+
+// Defaults used when the URI leaves a parameter out
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30
+)
+
+var (
+	// ErrInvalidURI is returned for values that are neither an otpauth URI nor a base32 secret
+	ErrInvalidURI = errors.New("invalid otpauth URI")
+	// ErrNotTOTP is returned when a time based code is requested for an HOTP key
+	ErrNotTOTP = errors.New("not a time based (TOTP) key")
+	// ErrNotHOTP is returned when a counter based code is requested for a TOTP key
+	ErrNotHOTP = errors.New("not a counter based (HOTP) key")
+)
+
+// Key is a parsed one-time password seed
+type Key struct {
+	Type      string
+	Issuer    string
+	Account   string
+	Secret    []byte
+	Algorithm string
+	Digits    int
+	Period    int
+	Counter   uint64
+}
+
+// Parse parses an otpauth:// URI. A bare base32 secret, as some password
+// managers export it, is accepted as a TOTP key with default parameters.
+func Parse(value string) (*Key, error) {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(strings.ToLower(value), "otpauth://") {
+		secret, err := decodeSecret(value)
+		if err != nil {
+			return nil, ErrInvalidURI
+		}
+		return &Key{Type: TypeTOTP, Secret: secret, Algorithm: AlgorithmSHA1, Digits: DefaultDigits, Period: DefaultPeriod}, nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, ErrInvalidURI
+	}
+
+	key := &Key{
+		Type:      strings.ToLower(u.Host),
+		Algorithm: AlgorithmSHA1,
+		Digits:    DefaultDigits,
+		Period:    DefaultPeriod,
+	}
+	if key.Type != TypeTOTP && key.Type != TypeHOTP {
+		return nil, fmt.Errorf("unsupported OTP type %q", u.Host)
+	}
+
+	// The label is "issuer:account" or just "account"
+	label := strings.TrimPrefix(u.Path, "/")
+	if issuer, account, found := strings.Cut(label, ":"); found {
+		key.Issuer, key.Account = strings.TrimSpace(issuer), strings.TrimSpace(account)
+	} else {
+		key.Account = strings.TrimSpace(label)
+	}
+
+	query := u.Query()
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	if key.Secret, err = decodeSecret(query.Get("secret")); err != nil {
+		return nil, err
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(strings.ReplaceAll(algorithm, "-", ""))
+	}
+	if digits := query.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil {
+			return nil, fmt.Errorf("invalid OTP digits %q", digits)
+		}
+	}
+	if period := query.Get("period"); period != "" {
+		if key.Period, err = strconv.Atoi(period); err != nil {
+			return nil, fmt.Errorf("invalid OTP period %q", period)
+		}
+	}
+	if counter := query.Get("counter"); counter != "" {
+		if key.Counter, err = strconv.ParseUint(counter, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid HOTP counter %q", counter)
+		}
+	}
+
+	if err := key.validate(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// validate checks the key parameters against what authenticators support
+func (k *Key) validate() error {
+	if _, err := newHash(k.Algorithm); err != nil {
+		return err
+	}
+	if k.Digits < 6 || k.Digits > 8 {
+		return fmt.Errorf("unsupported OTP digits %d (must be 6-8)", k.Digits)
+	}
+	if k.Type == TypeTOTP && (k.Period < 1 || k.Period > 3600) {
+		return fmt.Errorf("unsupported TOTP period %d", k.Period)
+	}
+	return nil
+}
+
+// URI returns the otpauth:// URI describing the key, including the current HOTP counter
+func (k *Key) URI() string {
+	label := k.Account
+	if k.Issuer != "" {
+		label = k.Issuer + ":" + k.Account
+	}
+
+	query := url.Values{}
+	query.Set("secret", strings.TrimRight(base32.StdEncoding.EncodeToString(k.Secret), "="))
+	if k.Issuer != "" {
+		query.Set("issuer", k.Issuer)
+	}
+	query.Set("algorithm", k.Algorithm)
+	query.Set("digits", strconv.Itoa(k.Digits))
+	if k.Type == TypeHOTP {
+		query.Set("counter", strconv.FormatUint(k.Counter, 10))
+	} else {
+		query.Set("period", strconv.Itoa(k.Period))
+	}
+
+	u := url.URL{Scheme: "otpauth", Host: k.Type, Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// Code returns the TOTP code valid at t
+func (k *Key) Code(t time.Time) (string, error) {
+	if k.Type != TypeTOTP {
+		return "", ErrNotTOTP
+	}
+	return k.generate(uint64(t.Unix()) / uint64(k.Period))
+}
+
+// Remaining returns how long the TOTP code valid at t stays valid
+func (k *Key) Remaining(t time.Time) time.Duration {
+	if k.Type != TypeTOTP {
+		return 0
+	}
+	period := int64(k.Period)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+// Next returns the HOTP code for the current counter and advances the counter.
+// The caller must persist the key afterwards so a code is never reused.
+func (k *Key) Next() (string, error) {
+	if k.Type != TypeHOTP {
+		return "", ErrNotHOTP
+	}
+	code, err := k.generate(k.Counter)
+	if err != nil {
+		return "", err
+	}
+	k.Counter++
+	return code, nil
+}
+
+// generate computes the RFC 4226 code for a counter value
+func (k *Key) generate(counter uint64) (string, error) {
+	newHash, err := newHash(k.Algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(newHash, k.Secret)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulus := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, value%modulus), nil
+}
+
+// newHash returns the hash constructor for an algorithm name
+func newHash(algorithm string) (func() hash.Hash, error) {
+	switch algorithm {
+	case AlgorithmSHA1:
+		return sha1.New, nil
+	case AlgorithmSHA256:
+		return sha256.New, nil
+	case AlgorithmSHA512:
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported OTP algorithm %q", algorithm)
+	}
+}
+
+// decodeSecret decodes a base32 secret, ignoring case, spaces and padding
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret))
+	if secret == "" {
+		return nil, errors.New("OTP secret is missing")
+	}
+
+	decoded, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil || len(decoded) == 0 {
+		return nil, errors.New("OTP secret is not valid base32")
+	}
+	return decoded, nil
+}
+
+// FormatCode groups a code for readability, e.g. "123 456"
+func FormatCode(code string) string {
+	if len(code) < 6 {
+		return code
+	}
+	half := len(code) / 2
+	return code[:half] + " " + code[half:]
+}
+
+// Normalize parses an otpauth URI or bare base32 secret and returns the
+// canonical URI. Keys without a label are labelled with issuer and account.
+func Normalize(value, issuer, account string) (string, error) {
+	key, err := Parse(value)
+	if err != nil {
+		return "", err
+	}
+	if key.Issuer == "" && key.Account == "" {
+		key.Issuer, key.Account = issuer, account
+	}
+	return key.URI(), nil
+}
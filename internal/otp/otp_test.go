@@ -0,0 +1,155 @@
+package otp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// RFC 4226 appendix D: the HOTP values of the test secret for counters 0-9
+func TestHOTPRFC4226(t *testing.T) {
+	key := &Key{Type: TypeHOTP, Secret: []byte("12345678901234567890"), Algorithm: AlgorithmSHA1, Digits: 6}
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range want {
+		got, err := key.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != code {
+			t.Errorf("counter %d: got %s, want %s", counter, got, code)
+		}
+	}
+	if key.Counter != uint64(len(want)) {
+		t.Errorf("counter = %d after %d codes", key.Counter, len(want))
+	}
+}
+
+// RFC 6238 appendix B: the TOTP values of the test secrets with 8 digits
+func TestTOTPRFC6238(t *testing.T) {
+	secrets := map[string][]byte{
+		AlgorithmSHA1:   []byte("12345678901234567890"),
+		AlgorithmSHA256: []byte("12345678901234567890123456789012"),
+		AlgorithmSHA512: []byte(strings.Repeat("1234567890", 6) + "1234"),
+	}
+	tests := []struct {
+		unix int64
+		want map[string]string
+	}{
+		{59, map[string]string{AlgorithmSHA1: "94287082", AlgorithmSHA256: "46119246", AlgorithmSHA512: "90693936"}},
+		{1111111109, map[string]string{AlgorithmSHA1: "07081804", AlgorithmSHA256: "68084774", AlgorithmSHA512: "25091201"}},
+		{1111111111, map[string]string{AlgorithmSHA1: "14050471", AlgorithmSHA256: "67062674", AlgorithmSHA512: "99943326"}},
+		{1234567890, map[string]string{AlgorithmSHA1: "89005924", AlgorithmSHA256: "91819424", AlgorithmSHA512: "93441116"}},
+		{2000000000, map[string]string{AlgorithmSHA1: "69279037", AlgorithmSHA256: "90698825", AlgorithmSHA512: "38618901"}},
+		{20000000000, map[string]string{AlgorithmSHA1: "65353130", AlgorithmSHA256: "77737706", AlgorithmSHA512: "47863826"}},
+	}
+
+	for _, test := range tests {
+		for algorithm, want := range test.want {
+			key := &Key{Type: TypeTOTP, Secret: secrets[algorithm], Algorithm: algorithm, Digits: 8, Period: 30}
+			got, err := key.Code(time.Unix(test.unix, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s at %d: got %s, want %s", algorithm, test.unix, got, want)
+			}
+		}
+	}
+}
+
+func TestURIRoundTrip(t *testing.T) {
+	keys := []*Key{
+		{Type: TypeTOTP, Issuer: "ACME Co", Account: "john.doe@example.com", Secret: []byte("12345678901234567890"), Algorithm: AlgorithmSHA1, Digits: 6, Period: 30},
+		{Type: TypeTOTP, Account: "alice", Secret: []byte("secret key"), Algorithm: AlgorithmSHA256, Digits: 8, Period: 60},
+		{Type: TypeHOTP, Issuer: "Bank & Trust", Account: "a b", Secret: []byte{0, 1, 2, 3, 4}, Algorithm: AlgorithmSHA512, Digits: 7, Period: DefaultPeriod, Counter: 42},
+	}
+
+	for _, key := range keys {
+		uri := key.URI()
+		parsed, err := Parse(uri)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", uri, err)
+			continue
+		}
+		if !reflect.DeepEqual(parsed, key) {
+			t.Errorf("Parse(%q) = %+v, want %+v", uri, parsed, key)
+		}
+		if again := parsed.URI(); again != uri {
+			t.Errorf("URI changed on the round trip: %q, then %q", uri, again)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value string
+		want  *Key
+	}{
+		{
+			// The example of the Key Uri Format documentation
+			value: "otpauth://totp/Example:alice@google.com?secret=JBSWY3DPEHPK3PXP&issuer=Example",
+			want:  &Key{Type: TypeTOTP, Issuer: "Example", Account: "alice@google.com", Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: AlgorithmSHA1, Digits: 6, Period: 30},
+		},
+		{
+			// The issuer parameter wins over the label, algorithm names are normalized
+			value: "OTPAUTH://TOTP/Old:bob?secret=jbsw y3dp ehpk 3pxp&issuer=New&algorithm=sha-256&digits=8&period=60",
+			want:  &Key{Type: TypeTOTP, Issuer: "New", Account: "bob", Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: AlgorithmSHA256, Digits: 8, Period: 60},
+		},
+		{
+			value: "otpauth://hotp/carol?secret=JBSWY3DPEHPK3PXP&counter=7",
+			want:  &Key{Type: TypeHOTP, Account: "carol", Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: AlgorithmSHA1, Digits: 6, Period: 30, Counter: 7},
+		},
+		{
+			// A bare secret as some password managers export it
+			value: " JBSWY3DPEHPK3PXP ",
+			want:  &Key{Type: TypeTOTP, Secret: []byte("Hello!\xde\xad\xbe\xef"), Algorithm: AlgorithmSHA1, Digits: 6, Period: 30},
+		},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.value)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse(%q) = %+v, want %+v", test.value, got, test.want)
+		}
+	}
+}
+
+func TestParseRejectsInvalidKeys(t *testing.T) {
+	for _, value := range []string{
+		"",
+		"not base32!",
+		"otpauth://totp/alice",
+		"otpauth://totp/alice?secret=!!!",
+		"otpauth://motp/alice?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&digits=4",
+		"otpauth://totp/alice?secret=JBSWY3DPEHPK3PXP&period=0",
+		"otpauth://hotp/alice?secret=JBSWY3DPEHPK3PXP&counter=-1",
+	} {
+		if key, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", value, key)
+		}
+	}
+}
+
+func TestCodeChecksKeyType(t *testing.T) {
+	totp := &Key{Type: TypeTOTP, Secret: []byte("secret"), Algorithm: AlgorithmSHA1, Digits: 6, Period: 30}
+	hotp := &Key{Type: TypeHOTP, Secret: []byte("secret"), Algorithm: AlgorithmSHA1, Digits: 6}
+
+	if _, err := hotp.Code(time.Now()); !errors.Is(err, ErrNotTOTP) {
+		t.Errorf("Code of an HOTP key: %v, want ErrNotTOTP", err)
+	}
+	if _, err := totp.Next(); !errors.Is(err, ErrNotHOTP) {
+		t.Errorf("Next of a TOTP key: %v, want ErrNotHOTP", err)
+	}
+	if got := totp.Remaining(time.Unix(59, 0)); got != time.Second {
+		t.Errorf("Remaining at 59s = %v, want 1s", got)
+	}
+}
@@ -0,0 +1,110 @@
+package strength
+
+import (
+	"embed"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/egemengunel/Go-Password-Manager/internal/generator"
+)
+
+// The frequency lists are ordered from most to least common. See data/README.md
+// for their origin. The EFF diceware list used for passphrases is added too.
+//
+//go:embed data/*.txt
+var dataFiles embed.FS
+
+// rankedDictionary maps a lowercase word to its rank, 1 being the most common
+type rankedDictionary map[string]int
+
+// Dictionary names, used in matches and feedback
+const (
+	dictPasswords   = "passwords"
+	dictEnglish     = "english"
+	dictFemaleNames = "female_names"
+	dictMaleNames   = "male_names"
+	dictSurnames    = "surnames"
+	dictDiceware    = "diceware"
+	dictUserInputs  = "user_inputs"
+)
+
+// frequencyLists returns the embedded dictionaries by name
+var frequencyLists = sync.OnceValue(func() map[string]rankedDictionary {
+	lists := make(map[string]rankedDictionary)
+	for _, name := range []string{dictPasswords, dictEnglish, dictFemaleNames, dictMaleNames, dictSurnames} {
+		data, err := dataFiles.ReadFile("data/" + name + ".txt")
+		if err != nil {
+			panic("missing embedded frequency list " + name)
+		}
+		lists[name] = buildRankedDictionary(strings.Fields(string(data)))
+	}
+
+	// Passphrases generated from the EFF wordlist are guessed word by word
+	lists[dictDiceware] = buildRankedDictionary(generator.EFFWordlist())
+	return lists
+})
+
+// maxFrequencyWordLen returns the length of the longest word in the
+// embedded dictionaries, in runes
+var maxFrequencyWordLen = sync.OnceValue(func() int {
+	longest := 0
+	for _, dict := range frequencyLists() {
+		for word := range dict {
+			longest = max(longest, len([]rune(word)))
+		}
+	}
+	return longest
+})
+
+// buildRankedDictionary ranks words by their position in the list. A word
+// listed twice keeps its better rank.
+func buildRankedDictionary(words []string) rankedDictionary {
+	dict := make(rankedDictionary, len(words))
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if _, exists := dict[word]; !exists {
+			dict[word] = i + 1
+		}
+	}
+	return dict
+}
+
+// userInputDictionary ranks the user inputs and the words they contain, so
+// "alice@example.com" also penalizes "alice" and "example"
+func userInputDictionary(inputs []string) rankedDictionary {
+	var words []string
+	for _, input := range inputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		if input == "" {
+			continue
+		}
+		words = append(words, input)
+
+		parts := strings.FieldsFunc(input, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, part := range parts {
+			if len([]rune(part)) >= 3 && part != input {
+				words = append(words, part)
+			}
+		}
+	}
+	return buildRankedDictionary(words)
+}
+
+// l33tTable lists the characters commonly substituted for each letter
+var l33tTable = map[rune][]rune{
+	'a': {'4', '@'},
+	'b': {'8'},
+	'c': {'(', '{', '[', '<'},
+	'e': {'3'},
+	'g': {'6', '9'},
+	'i': {'1', '!', '|'},
+	'l': {'1', '|', '7'},
+	'o': {'0'},
+	's': {'$', '5'},
+	't': {'+', '7'},
+	'x': {'%'},
+	'z': {'2'},
+}
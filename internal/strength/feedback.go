@@ -0,0 +1,130 @@
+package strength
+
+import (
+	"strings"
+	"unicode"
+)
+
+// feedback explains the weakest part of a password with a score below 3
+func feedback(score int, sequence []*Match) Feedback {
+	if len(sequence) == 0 {
+		return Feedback{
+			Suggestions: []string{
+				"Use a few words, avoid common phrases",
+				"No need for symbols, digits, or uppercase letters",
+			},
+		}
+	}
+	if score > 2 {
+		return Feedback{}
+	}
+
+	longest := sequence[0]
+	for _, match := range sequence[1:] {
+		if len(match.Token) > len(longest.Token) {
+			longest = match
+		}
+	}
+
+	result := matchFeedback(longest, len(sequence) == 1)
+	result.Suggestions = append([]string{"Add another word or two. Uncommon words are better."}, result.Suggestions...)
+	return result
+}
+
+// matchFeedback returns the warning and suggestions for one match
+func matchFeedback(match *Match, soleMatch bool) Feedback {
+	switch match.Pattern {
+	case PatternDictionary:
+		return dictionaryFeedback(match, soleMatch)
+
+	case PatternSpatial:
+		warning := "Short keyboard patterns are easy to guess"
+		if match.Turns == 1 {
+			warning = "Straight rows of keys are easy to guess"
+		}
+		return Feedback{
+			Warning:     warning,
+			Suggestions: []string{"Use a longer keyboard pattern with more turns"},
+		}
+
+	case PatternRepeat:
+		warning := `Repeats like "abcabcabc" are only slightly harder to guess than "abc"`
+		if len([]rune(match.BaseToken)) == 1 {
+			warning = `Repeats like "aaa" are easy to guess`
+		}
+		return Feedback{
+			Warning:     warning,
+			Suggestions: []string{"Avoid repeated words and characters"},
+		}
+
+	case PatternSequence:
+		return Feedback{
+			Warning:     "Sequences like abc or 6543 are easy to guess",
+			Suggestions: []string{"Avoid sequences"},
+		}
+
+	case PatternYear:
+		return Feedback{
+			Warning:     "Recent years are easy to guess",
+			Suggestions: []string{"Avoid recent years", "Avoid years that are associated with you"},
+		}
+
+	case PatternDate:
+		return Feedback{
+			Warning:     "Dates are often easy to guess",
+			Suggestions: []string{"Avoid dates and years that are associated with you"},
+		}
+	}
+	return Feedback{}
+}
+
+func dictionaryFeedback(match *Match, soleMatch bool) Feedback {
+	var result Feedback
+
+	switch match.DictionaryName {
+	case dictPasswords:
+		switch {
+		case soleMatch && !match.L33t && !match.Reversed && match.Rank <= 10:
+			result.Warning = "This is a top-10 common password"
+		case soleMatch && !match.L33t && !match.Reversed && match.Rank <= 100:
+			result.Warning = "This is a top-100 common password"
+		case soleMatch && !match.L33t && !match.Reversed:
+			result.Warning = "This is a very common password"
+		case match.GuessesLog10 <= 4:
+			result.Warning = "This is similar to a commonly used password"
+		}
+	case dictEnglish:
+		if soleMatch {
+			result.Warning = "A word by itself is easy to guess"
+		}
+	case dictSurnames, dictMaleNames, dictFemaleNames:
+		result.Warning = "Common names and surnames are easy to guess"
+		if soleMatch {
+			result.Warning = "Names and surnames by themselves are easy to guess"
+		}
+	case dictUserInputs:
+		result.Warning = "Passwords based on the entry's title, username or URL are easy to guess"
+	}
+
+	word := []rune(match.Token)
+	upper := 0
+	for _, r := range word {
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	switch {
+	case upper == 1 && unicode.IsUpper(word[0]) && len(word) > 1:
+		result.Suggestions = append(result.Suggestions, "Capitalization doesn't help very much")
+	case upper > 0 && strings.ToUpper(match.Token) == match.Token:
+		result.Suggestions = append(result.Suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+	}
+
+	if match.Reversed && len(word) >= 4 {
+		result.Suggestions = append(result.Suggestions, "Reversed words aren't much harder to guess")
+	}
+	if match.L33t {
+		result.Suggestions = append(result.Suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package strength
+
+import (
+	"strings"
+	"sync"
+)
+
+// Keyboard layouts. Each key lists its unshifted and shifted character; rows
+// of slanted keyboards are indented by one more space than the row above.
+const (
+	qwertyLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+
+    qQ wW eE rR tT yY uU iI oO pP [{ ]} \|
+     aA sS dD fF gG hH jJ kK lL ;: '"
+      zZ xX cC vV bB nN mM ,< .> /?
+`
+
+	dvorakLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) [{ ]}
+    '" ,< .> pP yY fF gG cC rR lL /? =+ \|
+     aA oO eE uU iI dD hH tT nN sS -_
+      ;: qQ jJ kK xX bB mM wW vV zZ
+`
+
+	keypadLayout = `
+  / * -
+7 8 9 +
+4 5 6
+1 2 3
+  0 .
+`
+
+	macKeypadLayout = `
+  = / *
+7 8 9 -
+4 5 6 +
+1 2 3
+  0 .
+`
+)
+
+// adjacencyGraph describes a keyboard layout: for every character the keys
+// around its key, in a fixed direction order, with "" where there is none
+type adjacencyGraph struct {
+	name      string
+	slanted   bool
+	neighbors map[rune][]string
+	shifted   map[rune]bool // characters typed with shift
+
+	startingPositions float64 // number of keys
+	averageDegree     float64 // average number of neighboring keys
+}
+
+// keyboardGraphs returns the adjacency graphs of the supported layouts
+var keyboardGraphs = sync.OnceValue(func() []*adjacencyGraph {
+	return []*adjacencyGraph{
+		buildAdjacencyGraph("qwerty", qwertyLayout, true),
+		buildAdjacencyGraph("dvorak", dvorakLayout, true),
+		buildAdjacencyGraph("keypad", keypadLayout, false),
+		buildAdjacencyGraph("mac_keypad", macKeypadLayout, false),
+	}
+})
+
+// buildAdjacencyGraph builds the graph of a layout drawn as text
+func buildAdjacencyGraph(name, layout string, slanted bool) *adjacencyGraph {
+	type position struct{ x, y int }
+
+	lines := strings.Split(layout, "\n")
+	tokenSize := len(strings.Fields(layout)[0])
+	xUnit := tokenSize + 1 // a key plus the space after it
+
+	keys := make(map[position]string)
+	for y, line := range lines {
+		slant := 0
+		if slanted {
+			slant = y - 1
+		}
+		offset := 0
+		for _, token := range strings.Fields(line) {
+			index := strings.Index(line[offset:], token) + offset
+			offset = index + len(token)
+			keys[position{(index - slant) / xUnit, y}] = token
+		}
+	}
+
+	graph := &adjacencyGraph{
+		name:      name,
+		slanted:   slanted,
+		neighbors: make(map[rune][]string),
+		shifted:   make(map[rune]bool),
+	}
+
+	degrees := 0
+	for pos, token := range keys {
+		var around []position
+		if slanted {
+			around = []position{
+				{pos.x - 1, pos.y}, {pos.x, pos.y - 1}, {pos.x + 1, pos.y - 1},
+				{pos.x + 1, pos.y}, {pos.x, pos.y + 1}, {pos.x - 1, pos.y + 1},
+			}
+		} else {
+			around = []position{
+				{pos.x - 1, pos.y}, {pos.x - 1, pos.y - 1}, {pos.x, pos.y - 1}, {pos.x + 1, pos.y - 1},
+				{pos.x + 1, pos.y}, {pos.x + 1, pos.y + 1}, {pos.x, pos.y + 1}, {pos.x - 1, pos.y + 1},
+			}
+		}
+
+		neighbors := make([]string, len(around))
+		count := 0
+		for i, p := range around {
+			neighbors[i] = keys[p]
+			if neighbors[i] != "" {
+				count++
+			}
+		}
+
+		for i, char := range []rune(token) {
+			graph.neighbors[char] = neighbors
+			graph.shifted[char] = i == 1
+			degrees += count
+		}
+	}
+
+	graph.startingPositions = float64(len(graph.neighbors))
+	graph.averageDegree = float64(degrees) / graph.startingPositions
+	return graph
+}
@@ -0,0 +1,734 @@
+package strength
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Pattern is the kind of weakness a match describes
+type Pattern string
+
+const (
+	PatternDictionary Pattern = "dictionary"
+	PatternSpatial    Pattern = "spatial"
+	PatternRepeat     Pattern = "repeat"
+	PatternSequence   Pattern = "sequence"
+	PatternYear       Pattern = "year"
+	PatternDate       Pattern = "date"
+	PatternBruteforce Pattern = "bruteforce"
+)
+
+// Match is a part of a password that follows a guessable pattern
+type Match struct {
+	Pattern Pattern
+	I, J    int // positions of the first and last character, in runes
+	Token   string
+
+	// Dictionary matches
+	MatchedWord    string
+	Rank           int
+	DictionaryName string
+	Reversed       bool
+	L33t           bool
+	Sub            map[rune]rune // substituted character to the letter it stands for
+
+	// Spatial matches
+	Graph        string
+	Turns        int
+	ShiftedCount int
+
+	// Repeat matches
+	BaseToken   string
+	BaseGuesses float64
+	RepeatCount int
+
+	// Sequence matches
+	SequenceName  string
+	SequenceSpace int
+	Ascending     bool
+
+	// Date and year matches
+	Separator string
+	Year      int
+	Month     int
+	Day       int
+
+	Guesses      float64 // estimated by the scoring, 0 until then
+	GuessesLog10 float64
+}
+
+// Date bounds for date matching
+const (
+	dateMinYear = 1000
+	dateMaxYear = 2050
+)
+
+// dateSplits lists where a date without separators may be split into its
+// three parts, by length
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},         // 1191
+	5: {{1, 3}, {2, 3}},         // 11191
+	6: {{1, 2}, {2, 4}, {4, 5}}, // 111991, 199111
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}}, // 11111991, 19911111
+}
+
+// maxSequenceDelta is the largest step between characters of a sequence
+const maxSequenceDelta = 5
+
+// matcher finds the patterns in a password
+type matcher struct {
+	dictionaries map[string]rankedDictionary
+	maxWordLen   int
+}
+
+// newMatcher returns a matcher using the embedded dictionaries and the user inputs
+func newMatcher(userInputs []string) *matcher {
+	m := &matcher{
+		dictionaries: make(map[string]rankedDictionary),
+		maxWordLen:   maxFrequencyWordLen(),
+	}
+	for name, dict := range frequencyLists() {
+		m.dictionaries[name] = dict
+	}
+
+	inputs := userInputDictionary(userInputs)
+	m.dictionaries[dictUserInputs] = inputs
+	for word := range inputs {
+		m.maxWordLen = max(m.maxWordLen, len([]rune(word)))
+	}
+	return m
+}
+
+// omnimatch returns every match in password, ordered by position
+func (m *matcher) omnimatch(password []rune) []*Match {
+	var matches []*Match
+	matches = append(matches, m.dictionaryMatch(password)...)
+	matches = append(matches, m.reverseDictionaryMatch(password)...)
+	matches = append(matches, m.l33tMatch(password)...)
+	matches = append(matches, spatialMatch(password)...)
+	matches = append(matches, m.repeatMatch(password)...)
+	matches = append(matches, sequenceMatch(password)...)
+	matches = append(matches, yearMatch(password)...)
+	matches = append(matches, dateMatch(password)...)
+	sortMatches(matches)
+	return matches
+}
+
+// sortMatches orders matches by start and then end position
+func sortMatches(matches []*Match) {
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].I != matches[b].I {
+			return matches[a].I < matches[b].I
+		}
+		return matches[a].J < matches[b].J
+	})
+}
+
+// dictionaryMatch finds every substring of password that is a dictionary word
+func (m *matcher) dictionaryMatch(password []rune) []*Match {
+	var matches []*Match
+	lower := toLowerRunes(password)
+
+	names := make([]string, 0, len(m.dictionaries))
+	for name := range m.dictionaries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for i := range password {
+		for j := i; j < len(password) && j-i < m.maxWordLen; j++ {
+			word := string(lower[i : j+1])
+			for _, name := range names {
+				rank, found := m.dictionaries[name][word]
+				if !found {
+					continue
+				}
+				matches = append(matches, &Match{
+					Pattern:        PatternDictionary,
+					I:              i,
+					J:              j,
+					Token:          string(password[i : j+1]),
+					MatchedWord:    word,
+					Rank:           rank,
+					DictionaryName: name,
+				})
+			}
+		}
+	}
+	return matches
+}
+
+// reverseDictionaryMatch finds dictionary words written backwards
+func (m *matcher) reverseDictionaryMatch(password []rune) []*Match {
+	reversed := reverseRunes(password)
+	matches := m.dictionaryMatch(reversed)
+	for _, match := range matches {
+		match.Token = string(reverseRunes([]rune(match.Token)))
+		match.Reversed = true
+		match.I, match.J = len(password)-1-match.J, len(password)-1-match.I
+	}
+	return matches
+}
+
+// l33tMatch finds dictionary words with l33t substitutions, like "p@ssw0rd"
+func (m *matcher) l33tMatch(password []rune) []*Match {
+	var matches []*Match
+
+	for _, sub := range enumerateL33tSubs(relevantL33tTable(password)) {
+		if len(sub) == 0 {
+			break
+		}
+
+		subbed := make([]rune, len(password))
+		for i, char := range password {
+			if letter, ok := sub[char]; ok {
+				char = letter
+			}
+			subbed[i] = char
+		}
+
+		for _, match := range m.dictionaryMatch(subbed) {
+			token := password[match.I : match.J+1]
+			// Only keep matches that contain an actual substitution
+			if string(toLowerRunes(token)) == match.MatchedWord || len(token) < 2 {
+				continue
+			}
+
+			match.Token = string(token)
+			match.L33t = true
+			match.Sub = make(map[rune]rune)
+			for subbedChar, letter := range sub {
+				if strings.ContainsRune(match.Token, subbedChar) {
+					match.Sub[subbedChar] = letter
+				}
+			}
+			matches = append(matches, match)
+		}
+	}
+	return matches
+}
+
+// relevantL33tTable restricts the l33t table to the substitutions that occur in password
+func relevantL33tTable(password []rune) map[rune][]rune {
+	present := make(map[rune]bool, len(password))
+	for _, char := range password {
+		present[char] = true
+	}
+
+	table := make(map[rune][]rune)
+	for letter, subs := range l33tTable {
+		for _, sub := range subs {
+			if present[sub] {
+				table[letter] = append(table[letter], sub)
+			}
+		}
+	}
+	return table
+}
+
+// enumerateL33tSubs returns every way of reading the substituted characters
+// as letters. A character that stands for several letters, like "1" for "i"
+// or "l", is read as each of them in turn.
+func enumerateL33tSubs(table map[rune][]rune) []map[rune]rune {
+	type pair struct{ sub, letter rune }
+
+	letters := make([]rune, 0, len(table))
+	for letter := range table {
+		letters = append(letters, letter)
+	}
+	sort.Slice(letters, func(a, b int) bool { return letters[a] < letters[b] })
+
+	subs := [][]pair{{}}
+	for _, letter := range letters {
+		var next [][]pair
+		seen := make(map[string]bool)
+		add := func(candidate []pair) {
+			sorted := append([]pair(nil), candidate...)
+			sort.Slice(sorted, func(a, b int) bool { return sorted[a].sub < sorted[b].sub })
+			var key strings.Builder
+			for _, p := range sorted {
+				key.WriteRune(p.sub)
+				key.WriteRune(p.letter)
+			}
+			if !seen[key.String()] {
+				seen[key.String()] = true
+				next = append(next, candidate)
+			}
+		}
+
+		for _, l33tChar := range table[letter] {
+			for _, sub := range subs {
+				dup := -1
+				for i, p := range sub {
+					if p.sub == l33tChar {
+						dup = i
+						break
+					}
+				}
+
+				if dup == -1 {
+					add(append(append([]pair(nil), sub...), pair{l33tChar, letter}))
+				} else {
+					alternative := append([]pair(nil), sub[:dup]...)
+					alternative = append(alternative, sub[dup+1:]...)
+					alternative = append(alternative, pair{l33tChar, letter})
+					add(sub)
+					add(alternative)
+				}
+			}
+		}
+		subs = next
+	}
+
+	result := make([]map[rune]rune, 0, len(subs))
+	for _, sub := range subs {
+		dict := make(map[rune]rune, len(sub))
+		for _, p := range sub {
+			dict[p.sub] = p.letter
+		}
+		result = append(result, dict)
+	}
+	return result
+}
+
+// spatialMatch finds runs of adjacent keys on the supported keyboards
+func spatialMatch(password []rune) []*Match {
+	var matches []*Match
+	for _, graph := range keyboardGraphs() {
+		matches = append(matches, spatialMatchGraph(password, graph)...)
+	}
+	return matches
+}
+
+// spatialMatchGraph finds runs of at least three adjacent keys on one keyboard
+func spatialMatchGraph(password []rune, graph *adjacencyGraph) []*Match {
+	var matches []*Match
+
+	i := 0
+	for i < len(password)-1 {
+		j := i + 1
+		lastDirection := -1
+		turns := 0
+		shiftedCount := 0
+		if graph.slanted && graph.shifted[password[i]] {
+			shiftedCount = 1
+		}
+
+		for {
+			found := false
+			if j < len(password) {
+				current := password[j]
+				for direction, neighbor := range graph.neighbors[password[j-1]] {
+					index := strings.IndexRune(neighbor, current)
+					if neighbor == "" || index < 0 {
+						continue
+					}
+					found = true
+					if index > 0 {
+						shiftedCount++
+					}
+					if direction != lastDirection {
+						turns++
+						lastDirection = direction
+					}
+					break
+				}
+			}
+
+			if found {
+				j++
+				continue
+			}
+
+			if j-i > 2 {
+				matches = append(matches, &Match{
+					Pattern:      PatternSpatial,
+					I:            i,
+					J:            j - 1,
+					Token:        string(password[i:j]),
+					Graph:        graph.name,
+					Turns:        turns,
+					ShiftedCount: shiftedCount,
+				})
+			}
+			i = j
+			break
+		}
+	}
+	return matches
+}
+
+// repeatMatch finds repeated characters or strings, like "aaa" or "abcabc"
+func (m *matcher) repeatMatch(password []rune) []*Match {
+	var matches []*Match
+
+	for last := 0; last < len(password); {
+		start := -1
+		var greedyLen, greedyCount, lazyLen, lazyCount int
+		for p := last; p < len(password) && start < 0; p++ {
+			for size := 1; p+2*size <= len(password); size++ {
+				count := repeatCount(password[p:], size)
+				if count < 2 {
+					continue
+				}
+				if start < 0 {
+					start, lazyLen, lazyCount = p, size, count
+				}
+				greedyLen, greedyCount = size, count
+			}
+		}
+		if start < 0 {
+			break
+		}
+
+		// Like zxcvbn, prefer the longer of the greedy and the lazy match
+		var token, base []rune
+		if greedyLen*greedyCount > lazyLen*lazyCount {
+			token = password[start : start+greedyLen*greedyCount]
+			base = token[:shortestPeriod(token)]
+		} else {
+			token = password[start : start+lazyLen*lazyCount]
+			base = token[:lazyLen]
+		}
+
+		baseGuesses, _ := mostGuessableMatchSequence(base, m.omnimatch(base), false)
+		matches = append(matches, &Match{
+			Pattern:     PatternRepeat,
+			I:           start,
+			J:           start + len(token) - 1,
+			Token:       string(token),
+			BaseToken:   string(base),
+			BaseGuesses: baseGuesses,
+			RepeatCount: len(token) / len(base),
+		})
+		last = start + len(token)
+	}
+	return matches
+}
+
+// repeatCount returns how many times the first size runes of s repeat at its start
+func repeatCount(s []rune, size int) int {
+	count := 1
+	for (count+1)*size <= len(s) && slices.Equal(s[:size], s[count*size:(count+1)*size]) {
+		count++
+	}
+	return count
+}
+
+// shortestPeriod returns the length of the shortest string that token is a
+// repetition of
+func shortestPeriod(token []rune) int {
+	for size := 1; size < len(token); size++ {
+		if len(token)%size == 0 && repeatCount(token, size)*size == len(token) {
+			return size
+		}
+	}
+	return len(token)
+}
+
+// sequenceMatch finds runs of characters with a constant step, like "abcd",
+// "7531" or "zyx"
+func sequenceMatch(password []rune) []*Match {
+	if len(password) <= 1 {
+		return nil
+	}
+
+	var matches []*Match
+	update := func(i, j, delta int) {
+		if j-i <= 1 && abs(delta) != 1 {
+			return
+		}
+		if delta == 0 || abs(delta) > maxSequenceDelta {
+			return
+		}
+
+		token := password[i : j+1]
+		name, space := "unicode", 26
+		switch {
+		case allRunes(token, func(r rune) bool { return r >= 'a' && r <= 'z' }):
+			name, space = "lower", 26
+		case allRunes(token, func(r rune) bool { return r >= 'A' && r <= 'Z' }):
+			name, space = "upper", 26
+		case allRunes(token, func(r rune) bool { return r >= '0' && r <= '9' }):
+			name, space = "digits", 10
+		}
+
+		matches = append(matches, &Match{
+			Pattern:       PatternSequence,
+			I:             i,
+			J:             j,
+			Token:         string(token),
+			SequenceName:  name,
+			SequenceSpace: space,
+			Ascending:     delta > 0,
+		})
+	}
+
+	i := 0
+	lastDelta := int(password[1] - password[0])
+	for k := 1; k < len(password); k++ {
+		delta := int(password[k] - password[k-1])
+		if delta == lastDelta {
+			continue
+		}
+		j := k - 1
+		update(i, j, lastDelta)
+		i = j
+		lastDelta = delta
+	}
+	update(i, len(password)-1, lastDelta)
+	return matches
+}
+
+// yearMatch finds recent years, 1900 to 2099
+func yearMatch(password []rune) []*Match {
+	var matches []*Match
+	for i := 0; i+4 <= len(password); {
+		token := password[i : i+4]
+		if allRunes(token, isDigit) && (string(token[:2]) == "19" || string(token[:2]) == "20") {
+			matches = append(matches, &Match{
+				Pattern: PatternYear,
+				I:       i,
+				J:       i + 3,
+				Token:   string(token),
+				Year:    atoi(token),
+			})
+			i += 4
+			continue
+		}
+		i++
+	}
+	return matches
+}
+
+// dateMatch finds dates written with or without separators, like "13.5.1991",
+// "1/1/91" or "19910513". Dates inside longer dates are dropped.
+func dateMatch(password []rune) []*Match {
+	var matches []*Match
+
+	// Dates without separators are between 4 ("1191") and 8 ("11111991") digits
+	for i := 0; i+4 <= len(password); i++ {
+		for j := i + 3; j <= i+7 && j < len(password); j++ {
+			token := password[i : j+1]
+			if !allRunes(token, isDigit) {
+				break
+			}
+
+			var best *Match
+			for _, split := range dateSplits[len(token)] {
+				year, month, day, ok := mapIntsToDMY(atoi(token[:split[0]]), atoi(token[split[0]:split[1]]), atoi(token[split[1]:]))
+				if !ok {
+					continue
+				}
+				// Prefer the year closest to the present
+				if best == nil || abs(year-referenceYear()) < abs(best.Year-referenceYear()) {
+					best = &Match{Year: year, Month: month, Day: day}
+				}
+			}
+			if best != nil {
+				best.Pattern = PatternDate
+				best.I, best.J = i, j
+				best.Token = string(token)
+				matches = append(matches, best)
+			}
+		}
+	}
+
+	// Dates with separators are between 6 ("1/1/91") and 10 ("11/11/1991") characters
+	for i := 0; i+6 <= len(password); i++ {
+		for j := i + 5; j <= i+9 && j < len(password); j++ {
+			token := password[i : j+1]
+			a, b, c, separator, ok := splitSeparatedDate(token)
+			if !ok {
+				continue
+			}
+			year, month, day, ok := mapIntsToDMY(a, b, c)
+			if !ok {
+				continue
+			}
+			matches = append(matches, &Match{
+				Pattern:   PatternDate,
+				I:         i,
+				J:         j,
+				Token:     string(token),
+				Separator: string(separator),
+				Year:      year,
+				Month:     month,
+				Day:       day,
+			})
+		}
+	}
+
+	// Drop dates contained in other dates
+	var result []*Match
+	for _, match := range matches {
+		submatch := false
+		for _, other := range matches {
+			if other != match && other.I <= match.I && other.J >= match.J {
+				submatch = true
+				break
+			}
+		}
+		if !submatch {
+			result = append(result, match)
+		}
+	}
+	return result
+}
+
+// splitSeparatedDate splits a token like "13.5.1991" into its numbers. Both
+// separators must be the same.
+func splitSeparatedDate(token []rune) (a, b, c int, separator rune, ok bool) {
+	isSeparator := func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`/\_.-`, r)
+	}
+
+	var parts [][]rune
+	var separators []rune
+	start := 0
+	for i, char := range token {
+		if isDigit(char) {
+			continue
+		}
+		if !isSeparator(char) {
+			return 0, 0, 0, 0, false
+		}
+		parts = append(parts, token[start:i])
+		separators = append(separators, char)
+		start = i + 1
+	}
+	parts = append(parts, token[start:])
+
+	if len(parts) != 3 || separators[0] != separators[1] {
+		return 0, 0, 0, 0, false
+	}
+	if len(parts[0]) < 1 || len(parts[0]) > 4 || len(parts[1]) < 1 || len(parts[1]) > 2 ||
+		len(parts[2]) < 1 || len(parts[2]) > 4 {
+		return 0, 0, 0, 0, false
+	}
+	return atoi(parts[0]), atoi(parts[1]), atoi(parts[2]), separators[0], true
+}
+
+// mapIntsToDMY reads three numbers as a date in day-month-year, month-day-year,
+// year-month-day or year-day-month order
+func mapIntsToDMY(a, b, c int) (year, month, day int, ok bool) {
+	ints := [3]int{a, b, c}
+
+	// The middle number is never a year
+	if b > 31 || b <= 0 {
+		return 0, 0, 0, false
+	}
+
+	over12, over31, under1 := 0, 0, 0
+	for _, n := range ints {
+		if (n > 99 && n < dateMinYear) || n > dateMaxYear {
+			return 0, 0, 0, false
+		}
+		if n > 31 {
+			over31++
+		}
+		if n > 12 {
+			over12++
+		}
+		if n <= 0 {
+			under1++
+		}
+	}
+	if over31 >= 2 || over12 == 3 || under1 >= 2 {
+		return 0, 0, 0, false
+	}
+
+	// The year comes first or last
+	splits := [][3]int{{c, a, b}, {a, b, c}}
+
+	// A four digit year decides the order
+	for _, split := range splits {
+		if split[0] >= dateMinYear && split[0] <= dateMaxYear {
+			month, day, ok := mapIntsToDM(split[1], split[2])
+			if !ok {
+				return 0, 0, 0, false
+			}
+			return split[0], month, day, true
+		}
+	}
+
+	// Otherwise take the first order that gives a valid day and month
+	for _, split := range splits {
+		if month, day, ok := mapIntsToDM(split[1], split[2]); ok {
+			return twoToFourDigitYear(split[0]), month, day, true
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// mapIntsToDM reads two numbers as a day and month in either order
+func mapIntsToDM(a, b int) (month, day int, ok bool) {
+	for _, pair := range [][2]int{{a, b}, {b, a}} {
+		d, m := pair[0], pair[1]
+		if d >= 1 && d <= 31 && m >= 1 && m <= 12 {
+			return m, d, true
+		}
+	}
+	return 0, 0, false
+}
+
+// twoToFourDigitYear expands two digit years, 51 to 1951 and 12 to 2012
+func twoToFourDigitYear(year int) int {
+	switch {
+	case year > 99:
+		return year
+	case year > 50:
+		return year + 1900
+	default:
+		return year + 2000
+	}
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+// atoi parses a run of ASCII digits
+func atoi(digits []rune) int {
+	n := 0
+	for _, digit := range digits {
+		n = n*10 + int(digit-'0')
+	}
+	return n
+}
+
+func allRunes(s []rune, f func(rune) bool) bool {
+	for _, r := range s {
+		if !f(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func reverseRunes(s []rune) []rune {
+	reversed := make([]rune, len(s))
+	for i, r := range s {
+		reversed[len(s)-1-i] = r
+	}
+	return reversed
+}
+
+// toLowerRunes lowercases each rune, keeping positions aligned with s
+func toLowerRunes(s []rune) []rune {
+	lower := make([]rune, len(s))
+	for i, r := range s {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,341 @@
+package strength
+
+import (
+	"math"
+	"time"
+	"unicode"
+)
+
+// Scoring constants from zxcvbn
+const (
+	bruteforceCardinality          = 10
+	minGuessesBeforeGrowingSeq     = 10000
+	minSubmatchGuessesSingleChar   = 10
+	minSubmatchGuessesMultiChar    = 50
+	minYearSpace                   = 20
+	separatorDateGuessesMultiplier = 4
+)
+
+// referenceYear is the year dates and years are compared against
+func referenceYear() int {
+	return time.Now().Year()
+}
+
+// dpCell is the best way found to cover a password prefix with a given number of matches
+type dpCell struct {
+	match *Match  // last match of the sequence, nil if none was found
+	pi    float64 // product of the guesses of the matches
+	g     float64 // total guesses, including the ordering and length penalties
+}
+
+// mostGuessableMatchSequence finds the sequence of non-overlapping matches
+// covering password that needs the fewest guesses, filling gaps with brute
+// force. An attacker has to guess how many patterns there are and in which
+// order, so the estimate for l matches is l! times their product, plus a
+// penalty for longer sequences unless excludeAdditive is set.
+func mostGuessableMatchSequence(password []rune, matches []*Match, excludeAdditive bool) (float64, []*Match) {
+	n := len(password)
+	if n == 0 {
+		return 1, nil
+	}
+
+	matchesByJ := make([][]*Match, n)
+	for _, match := range matches {
+		matchesByJ[match.J] = append(matchesByJ[match.J], match)
+	}
+
+	// optimal[k][l] covers password[:k+1] with l matches
+	optimal := make([][]dpCell, n)
+	for k := range optimal {
+		optimal[k] = make([]dpCell, n+1)
+	}
+
+	update := func(match *Match, l int) {
+		k := match.J
+		pi := estimateGuesses(match, password)
+		if l > 1 {
+			// Extend the best sequence of l-1 matches ending right before match
+			pi *= optimal[match.I-1][l-1].pi
+		}
+		g := factorial(l) * pi
+		if !excludeAdditive {
+			g += math.Pow(minGuessesBeforeGrowingSeq, float64(l-1))
+		}
+
+		// Keep the candidate unless a sequence with no more matches is at least as good
+		for competingL := 1; competingL <= l; competingL++ {
+			cell := optimal[k][competingL]
+			if cell.match != nil && cell.g <= g {
+				return
+			}
+		}
+		optimal[k][l] = dpCell{match: match, pi: pi, g: g}
+	}
+
+	bruteforceUpdate := func(k int) {
+		// Brute force the whole prefix
+		update(bruteforceMatch(password, 0, k), 1)
+
+		// Or brute force a suffix after any sequence not ending in brute force
+		for i := 1; i <= k; i++ {
+			match := bruteforceMatch(password, i, k)
+			for l, cell := range optimal[i-1] {
+				if cell.match == nil || cell.match.Pattern == PatternBruteforce {
+					continue
+				}
+				update(match, l+1)
+			}
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, match := range matchesByJ[k] {
+			if match.I > 0 {
+				for l, cell := range optimal[match.I-1] {
+					if cell.match != nil {
+						update(match, l+1)
+					}
+				}
+			} else {
+				update(match, 1)
+			}
+		}
+		bruteforceUpdate(k)
+	}
+
+	// Walk back from the best sequence covering the whole password
+	bestL, bestG := 0, math.Inf(1)
+	for l, cell := range optimal[n-1] {
+		if cell.match != nil && cell.g < bestG {
+			bestL, bestG = l, cell.g
+		}
+	}
+
+	sequence := make([]*Match, bestL)
+	for k, l := n-1, bestL; k >= 0 && l > 0; l-- {
+		match := optimal[k][l].match
+		sequence[l-1] = match
+		k = match.I - 1
+	}
+	return bestG, sequence
+}
+
+// bruteforceMatch returns a brute force match of password[i:j+1]
+func bruteforceMatch(password []rune, i, j int) *Match {
+	return &Match{
+		Pattern: PatternBruteforce,
+		I:       i,
+		J:       j,
+		Token:   string(password[i : j+1]),
+	}
+}
+
+// estimateGuesses returns the guesses needed for match, caching the result in it
+func estimateGuesses(match *Match, password []rune) float64 {
+	if match.Guesses != 0 {
+		return match.Guesses
+	}
+
+	// A match covering only part of the password needs a few guesses at least
+	minGuesses := 1.0
+	if length := match.J - match.I + 1; length < len(password) {
+		minGuesses = minSubmatchGuessesMultiChar
+		if length == 1 {
+			minGuesses = minSubmatchGuessesSingleChar
+		}
+	}
+
+	var guesses float64
+	switch match.Pattern {
+	case PatternBruteforce:
+		guesses = bruteforceGuesses(match)
+	case PatternDictionary:
+		guesses = dictionaryGuesses(match)
+	case PatternSpatial:
+		guesses = spatialGuesses(match)
+	case PatternRepeat:
+		guesses = match.BaseGuesses * float64(match.RepeatCount)
+	case PatternSequence:
+		guesses = sequenceGuesses(match)
+	case PatternYear:
+		guesses = float64(max(abs(match.Year-referenceYear()), minYearSpace))
+	case PatternDate:
+		guesses = dateGuesses(match)
+	}
+
+	match.Guesses = math.Max(guesses, minGuesses)
+	match.GuessesLog10 = math.Log10(match.Guesses)
+	return match.Guesses
+}
+
+func bruteforceGuesses(match *Match) float64 {
+	length := len([]rune(match.Token))
+	guesses := math.Pow(bruteforceCardinality, float64(length))
+	if math.IsInf(guesses, 0) {
+		guesses = math.MaxFloat64
+	}
+
+	// Brute force never beats a match of the same length
+	minGuesses := float64(minSubmatchGuessesMultiChar + 1)
+	if length == 1 {
+		minGuesses = minSubmatchGuessesSingleChar + 1
+	}
+	return math.Max(guesses, minGuesses)
+}
+
+func dictionaryGuesses(match *Match) float64 {
+	guesses := float64(match.Rank) * uppercaseVariations(match.Token) * l33tVariations(match)
+	if match.Reversed {
+		guesses *= 2
+	}
+	return guesses
+}
+
+// uppercaseVariations estimates the guesses added by capitalization. The
+// common patterns, first letter, last letter or every letter upper case, only
+// double them.
+func uppercaseVariations(word string) float64 {
+	runes := []rune(word)
+	upper, lower := 0, 0
+	for _, r := range runes {
+		if unicode.IsUpper(r) {
+			upper++
+		} else if unicode.IsLower(r) {
+			lower++
+		}
+	}
+	if upper == 0 {
+		return 1
+	}
+
+	startUpper := unicode.IsUpper(runes[0]) && upper == 1
+	endUpper := unicode.IsUpper(runes[len(runes)-1]) && upper == 1
+	if startUpper || endUpper || lower == 0 {
+		return 2
+	}
+
+	variations := 0.0
+	for i := 1; i <= min(upper, lower); i++ {
+		variations += nCk(upper+lower, i)
+	}
+	return variations
+}
+
+// l33tVariations estimates the guesses added by l33t substitutions
+func l33tVariations(match *Match) float64 {
+	if !match.L33t {
+		return 1
+	}
+
+	variations := 1.0
+	lower := []rune(match.Token)
+	lower = toLowerRunes(lower)
+	for subbed, letter := range match.Sub {
+		s, u := 0, 0
+		for _, r := range lower {
+			switch r {
+			case subbed:
+				s++
+			case letter:
+				u++
+			}
+		}
+
+		if s == 0 || u == 0 {
+			// Every occurrence of the letter is substituted
+			variations *= 2
+			continue
+		}
+		possibilities := 0.0
+		for i := 1; i <= min(s, u); i++ {
+			possibilities += nCk(s+u, i)
+		}
+		variations *= possibilities
+	}
+	return variations
+}
+
+func spatialGuesses(match *Match) float64 {
+	var graph *adjacencyGraph
+	for _, g := range keyboardGraphs() {
+		if g.name == match.Graph {
+			graph = g
+		}
+	}
+
+	length := len([]rune(match.Token))
+	guesses := 0.0
+	// Every shorter pattern with up to the same number of turns could be tried first
+	for i := 2; i <= length; i++ {
+		possibleTurns := min(match.Turns, i-1)
+		for j := 1; j <= possibleTurns; j++ {
+			guesses += nCk(i-1, j-1) * graph.startingPositions * math.Pow(graph.averageDegree, float64(j))
+		}
+	}
+
+	// Shifted keys add guesses like upper case letters do
+	if match.ShiftedCount > 0 {
+		shifted := match.ShiftedCount
+		unshifted := length - shifted
+		if shifted == 0 || unshifted == 0 {
+			guesses *= 2
+		} else {
+			variations := 0.0
+			for i := 1; i <= min(shifted, unshifted); i++ {
+				variations += nCk(shifted+unshifted, i)
+			}
+			guesses *= variations
+		}
+	}
+	return guesses
+}
+
+func sequenceGuesses(match *Match) float64 {
+	first := []rune(match.Token)[0]
+
+	var base float64
+	switch {
+	case first == 'a' || first == 'A' || first == 'z' || first == 'Z' || first == '0' || first == '1' || first == '9':
+		// Obvious starting points
+		base = 4
+	case isDigit(first):
+		base = 10
+	default:
+		base = 26
+	}
+	if !match.Ascending {
+		base *= 2
+	}
+	return base * float64(len([]rune(match.Token)))
+}
+
+func dateGuesses(match *Match) float64 {
+	yearSpace := max(abs(match.Year-referenceYear()), minYearSpace)
+	guesses := float64(yearSpace * 365)
+	if match.Separator != "" {
+		guesses *= separatorDateGuessesMultiplier
+	}
+	return guesses
+}
+
+// nCk returns the binomial coefficient
+func nCk(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+	result := 1.0
+	for d := 1; d <= k; d++ {
+		result *= float64(n)
+		result /= float64(d)
+		n--
+	}
+	return result
+}
+
+func factorial(n int) float64 {
+	result := 1.0
+	for i := 2; i <= n; i++ {
+		result *= float64(i)
+	}
+	return result
+}
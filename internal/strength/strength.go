@@ -0,0 +1,156 @@
+// Package strength estimates how many guesses an attacker needs to find a
+// password. It follows zxcvbn: the password is matched against common
+// passwords, dictionary words and names (also reversed and with l33t
+// substitutions), keyboard patterns, repeats, sequences, years and dates, and
+// the cheapest way to cover it with those patterns and brute force gives the
+// estimate.
+package strength
+
+import (
+	"fmt"
+	"math"
+)
+
+// maxPasswordLength bounds the number of characters analysed; anything
+// beyond it only makes a password stronger
+const maxPasswordLength = 256
+
+// Guesses per second for the attack scenarios
+const (
+	onlineThrottledRate   = 100.0 / 3600 // rate limited login form
+	onlineUnthrottledRate = 10.0         // login form without rate limiting
+	offlineSlowRate       = 1e4          // stolen hashes, slow hash like bcrypt or argon2
+	offlineFastRate       = 1e10         // stolen hashes, fast unsalted hash on GPUs
+)
+
+// Result is the strength estimate of a password
+type Result struct {
+	Guesses      float64 // estimated guesses needed to find the password
+	GuessesLog10 float64
+	Entropy      float64 // log2 of Guesses, in bits
+	Score        int     // 0 (too guessable) to 4 (very unguessable)
+	CrackTimes   CrackTimes
+	Feedback     Feedback
+	Sequence     []*Match // patterns the estimate is based on, in password order
+}
+
+// CrackTimes holds the estimated seconds to find a password for each attack scenario
+type CrackTimes struct {
+	OnlineThrottled   float64
+	OnlineUnthrottled float64
+	OfflineSlow       float64
+	OfflineFast       float64
+}
+
+// Feedback explains a weak password and how to improve it
+type Feedback struct {
+	Warning     string
+	Suggestions []string
+}
+
+// Estimate estimates the strength of password. userInputs are strings the
+// password should not be based on, like the entry's title, username or URL.
+func Estimate(password string, userInputs ...string) Result {
+	runes := []rune(password)
+	if len(runes) > maxPasswordLength {
+		runes = runes[:maxPasswordLength]
+	}
+
+	m := newMatcher(userInputs)
+	guesses, sequence := mostGuessableMatchSequence(runes, m.omnimatch(runes), false)
+
+	score := guessesToScore(guesses)
+	return Result{
+		Guesses:      guesses,
+		GuessesLog10: math.Log10(guesses),
+		Entropy:      math.Log2(guesses),
+		Score:        score,
+		CrackTimes: CrackTimes{
+			OnlineThrottled:   guesses / onlineThrottledRate,
+			OnlineUnthrottled: guesses / onlineUnthrottledRate,
+			OfflineSlow:       guesses / offlineSlowRate,
+			OfflineFast:       guesses / offlineFastRate,
+		},
+		Feedback: feedback(score, sequence),
+		Sequence: sequence,
+	}
+}
+
+// Label returns a short description of the score
+func (r Result) Label() string {
+	return ScoreLabel(r.Score)
+}
+
+// ScoreLabel returns a short description of a score from 0 to 4
+func ScoreLabel(score int) string {
+	switch {
+	case score <= 0:
+		return "Very weak"
+	case score == 1:
+		return "Weak"
+	case score == 2:
+		return "Fair"
+	case score == 3:
+		return "Strong"
+	default:
+		return "Very strong"
+	}
+}
+
+// guessesToScore maps guesses to a score. A small delta keeps passwords just
+// above a threshold, like a bare dictionary word that only has its bruteforce
+// minimum, in the lower bucket.
+func guessesToScore(guesses float64) int {
+	const delta = 5
+	switch {
+	case guesses < 1e3+delta:
+		return 0 // risky password: too guessable
+	case guesses < 1e6+delta:
+		return 1 // modest protection from throttled online attacks
+	case guesses < 1e8+delta:
+		return 2 // modest protection from unthrottled online attacks
+	case guesses < 1e10+delta:
+		return 3 // modest protection from offline attacks with a slow hash
+	default:
+		return 4 // strong protection from offline attacks
+	}
+}
+
+// FormatCrackTime formats a number of seconds as a rough human readable duration
+func FormatCrackTime(seconds float64) string {
+	const (
+		minute  = 60
+		hour    = minute * 60
+		day     = hour * 24
+		month   = day * 31
+		year    = month * 12
+		century = year * 100
+	)
+
+	units := []struct {
+		limit float64
+		size  float64
+		name  string
+	}{
+		{minute, 1, "second"},
+		{hour, minute, "minute"},
+		{day, hour, "hour"},
+		{month, day, "day"},
+		{year, month, "month"},
+		{century, year, "year"},
+	}
+
+	if seconds < 1 {
+		return "less than a second"
+	}
+	for _, unit := range units {
+		if seconds < unit.limit {
+			n := int(math.Round(seconds / unit.size))
+			if n == 1 {
+				return "1 " + unit.name
+			}
+			return fmt.Sprintf("%d %ss", n, unit.name)
+		}
+	}
+	return "centuries"
+}
@@ -0,0 +1,95 @@
+package strength
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWeakPasswords(t *testing.T) {
+	for _, password := range []string{"password", "P@ssw0rd", "qwertyuiop", "19911231"} {
+		result := Estimate(password)
+		if result.Score > 1 {
+			t.Errorf("Estimate(%q) score = %d, want at most 1", password, result.Score)
+		}
+		if result.Feedback.Warning == "" {
+			t.Errorf("Estimate(%q) gives no warning", password)
+		}
+	}
+}
+
+func TestStrongPassphrase(t *testing.T) {
+	result := Estimate("correct-horse-battery-staple")
+	if result.Score != 4 {
+		t.Errorf("score = %d, want 4", result.Score)
+	}
+	if result.Feedback.Warning != "" {
+		t.Errorf("strong passphrase warns %q", result.Feedback.Warning)
+	}
+}
+
+func TestUserInputsLowerScore(t *testing.T) {
+	password := "gunelbank2024"
+	without := Estimate(password)
+	with := Estimate(password, "Gunel", "bank.example.com", "bank")
+
+	if with.Guesses >= without.Guesses {
+		t.Errorf("guesses with user inputs = %g, want fewer than %g", with.Guesses, without.Guesses)
+	}
+	if with.Score >= without.Score {
+		t.Errorf("score with user inputs = %d, want below %d", with.Score, without.Score)
+	}
+}
+
+func TestEstimateOddInput(t *testing.T) {
+	inputs := []string{
+		"",
+		"пароль",
+		"密码密码密码",
+		"🔑🔑🔑",
+		"\xff\xfe\x00",
+		strings.Repeat("a", maxPasswordLength*4),
+		strings.Repeat("ü", maxPasswordLength+1),
+	}
+
+	for _, password := range inputs {
+		result := Estimate(password, "", "ü", password)
+		if result.Score < 0 || result.Score > 4 {
+			t.Errorf("Estimate(%q) score = %d, want 0 to 4", password, result.Score)
+		}
+		if result.Guesses < 1 {
+			t.Errorf("Estimate(%q) guesses = %g, want at least 1", password, result.Guesses)
+		}
+	}
+
+	if result := Estimate(""); result.Score != 0 {
+		t.Errorf("empty password score = %d, want 0", result.Score)
+	}
+}
+
+func TestScoreIncreasesWithLength(t *testing.T) {
+	previous := -1.0
+	for _, password := range []string{"kX9#", "kX9#mQ2$", "kX9#mQ2$vL7@", "kX9#mQ2$vL7@pR4w"} {
+		guesses := Estimate(password).Guesses
+		if guesses <= previous {
+			t.Errorf("Estimate(%q) guesses = %g, want more than %g", password, guesses, previous)
+		}
+		previous = guesses
+	}
+}
+
+func TestFormatCrackTime(t *testing.T) {
+	tests := map[float64]string{
+		0.5:             "less than a second",
+		1:               "1 second",
+		90:              "2 minutes",
+		3 * 3600:        "3 hours",
+		40 * 86400:      "1 month",
+		5 * 372 * 86400: "5 years",
+		1e12:            "centuries",
+	}
+	for seconds, want := range tests {
+		if got := FormatCrackTime(seconds); got != want {
+			t.Errorf("FormatCrackTime(%v) = %q, want %q", seconds, got, want)
+		}
+	}
+}
@@ -1,32 +1,60 @@
 package models
 
 import (
+	"crypto/rand"
+	"crypto/sha1"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 )
 
 // Entry represents a password entry in the vault
 type Entry struct {
-	ID         string            `json:"id"`
-	Title      string            `json:"title"`
-	Username   string            `json:"username"`
-	Password   string            `json:"password"`
-	URL        string            `json:"url,omitempty"`
-	Notes      string            `json:"notes,omitempty"`
-	Tags       []string          `json:"tags,omitempty"`
-	Custom     map[string]string `json:"custom,omitempty"`
-	CreatedAt  time.Time         `json:"created_at"`
-	UpdatedAt  time.Time         `json:"updated_at"`
-	AccessedAt time.Time         `json:"accessed_at"`
+	ID          string            `json:"id"`
+	Title       string            `json:"title"`
+	Username    string            `json:"username"`
+	Password    string            `json:"password"`
+	URL         string            `json:"url,omitempty"`
+	Notes       string            `json:"notes,omitempty"`
+	Folder      string            `json:"folder,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	Custom      map[string]string `json:"custom,omitempty"`
+	OTP         string            `json:"otp,omitempty"`         // otpauth:// URI of the 2FA seed
+	History     []EntryVersion    `json:"history,omitempty"`     // previous versions, oldest first
+	Policy      *PasswordPolicy   `json:"policy,omitempty"`      // rules for generated passwords
+	Compromised *Compromise       `json:"compromised,omitempty"` // set when the password was found in breach data
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	AccessedAt  time.Time         `json:"accessed_at"`
 }
 
 // Vault represents the structure of the password vault
 type Vault struct {
-	Version   string            `json:"version"`
-	CreatedAt time.Time         `json:"created_at"`
-	UpdatedAt time.Time         `json:"updated_at"`
-	Salt      []byte            `json:"salt"`
-	Entries   map[string]*Entry `json:"entries"`
-	Metadata  map[string]string `json:"metadata,omitempty"`
+	ID        string                   `json:"id,omitempty"`
+	Version   string                   `json:"version"`
+	Revision  uint64                   `json:"revision"`
+	CreatedAt time.Time                `json:"created_at"`
+	UpdatedAt time.Time                `json:"updated_at"`
+	Salt      []byte                   `json:"salt"`
+	Entries   map[string]*Entry        `json:"entries"`
+	Trash     map[string]*TrashedEntry `json:"trash,omitempty"`
+	Metadata  map[string]string        `json:"metadata,omitempty"`
+}
+
+// TrashedEntry is a deleted entry kept in the vault until it is restored or purged
+type TrashedEntry struct {
+	Entry     *Entry    `json:"entry"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// Compromise records that an entry's password was found in breach data.
+// It is cleared when the password changes.
+type Compromise struct {
+	Count     int       `json:"count"`  // times the password was seen in breaches
+	Source    string    `json:"source"` // where it was found, e.g. "Pwned Passwords API"
+	CheckedAt time.Time `json:"checked_at"`
 }
 
 // NewEntry creates a new password entry with generated ID and timestamps
@@ -45,8 +73,79 @@ func NewEntry(title, username, password string) *Entry {
 	}
 }
 
-// generateID creates a unique identifier for entries
+// legacyIDPattern matches the timestamp based IDs created by early versions
+var legacyIDPattern = regexp.MustCompile(`^\d{14}_entry$`)
+
+// generateID creates a random RFC 4122 version 4 UUID for entries
 func generateID() string {
-	// This is a placeholder - should use crypto/rand for production
-	return time.Now().Format("20060102150405") + "_" + "entry"
+	var b [16]byte
+	rand.Read(b[:]) // crypto/rand.Read never returns an error
+
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// NewID returns a fresh unique entry identifier
+func NewID() string {
+	return generateID()
+}
+
+// legacyIDNamespace is the UUID namespace legacy IDs are mapped into
+var legacyIDNamespace = [16]byte{0x6b, 0x1e, 0x3f, 0x52, 0x8c, 0x0d, 0x4a, 0x6e, 0x9f, 0x27, 0x5d, 0x33, 0xa1, 0xc4, 0x70, 0x18}
+
+// MigratedID returns the identifier that replaces the legacy ID legacyID. It
+// is a name based UUID (version 5), so every device migrating the same vault
+// assigns the same new ID and merges still match entries up.
+func MigratedID(legacyID string) string {
+	h := sha1.New()
+	h.Write(legacyIDNamespace[:])
+	h.Write([]byte(legacyID))
+
+	var b [16]byte
+	copy(b[:], h.Sum(nil))
+	b[6] = (b[6] & 0x0f) | 0x50 // version 5
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// IsLegacyID reports whether id is an old timestamp based identifier
+func IsLegacyID(id string) bool {
+	return legacyIDPattern.MatchString(id)
+}
+
+// Host returns the lowercased host of the entry's URL without a leading
+// "www.", tolerating URLs without a scheme. It is empty if there is no URL.
+func (e *Entry) Host() string {
+	rawURL := strings.TrimSpace(e.URL)
+	if rawURL == "" {
+		return ""
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
+
+// IsDuplicate reports whether e and other represent the same account: their
+// usernames match and either their URLs point at the same host or, without
+// URLs, their titles match
+func (e *Entry) IsDuplicate(other *Entry) bool {
+	if !strings.EqualFold(strings.TrimSpace(e.Username), strings.TrimSpace(other.Username)) {
+		return false
+	}
+
+	host, otherHost := e.Host(), other.Host()
+	if host != "" && otherHost != "" {
+		return host == otherHost
+	}
+
+	return strings.EqualFold(strings.TrimSpace(e.Title), strings.TrimSpace(other.Title))
 }
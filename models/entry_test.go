@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestIsDuplicate(t *testing.T) {
+	entry := func(title, username, url string) *Entry {
+		e := NewEntry(title, username, "secret")
+		e.URL = url
+		return e
+	}
+
+	tests := []struct {
+		name string
+		a, b *Entry
+		want bool
+	}{
+		{"same host", entry("Mail", "me", "https://mail.example.com/login"), entry("Webmail", "me", "mail.example.com"), true},
+		{"www prefix", entry("Shop", "me", "https://www.shop.com"), entry("Shop", "me", "http://shop.com/cart"), true},
+		{"username case and spaces", entry("Mail", " Me@Example.com", ""), entry("mail ", "me@example.com", ""), true},
+		{"other host", entry("Mail", "me", "https://mail.example.com"), entry("Mail", "me", "https://mail.example.org"), false},
+		{"other username", entry("Mail", "me", "https://mail.example.com"), entry("Mail", "you", "https://mail.example.com"), false},
+		{"titles without URLs", entry("Bank", "me", ""), entry("Bank", "me", ""), true},
+		{"other titles without URLs", entry("Bank", "me", ""), entry("Savings", "me", ""), false},
+		{"one URL missing", entry("Bank", "me", "https://bank.com"), entry("Bank", "me", ""), true},
+	}
+
+	for _, test := range tests {
+		if got := test.a.IsDuplicate(test.b); got != test.want {
+			t.Errorf("%s: IsDuplicate = %v, want %v", test.name, got, test.want)
+		}
+		if got := test.b.IsDuplicate(test.a); got != test.want {
+			t.Errorf("%s: reversed IsDuplicate = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// HistoryFields lists the entry fields kept in history, in display order
+var HistoryFields = []string{"title", "username", "password", "url", "notes", "otp"}
+
+// EntryVersion is a previous state of an entry's fields. Versions are stored
+// inside the entry, so they are encrypted together with the rest of the vault.
+type EntryVersion struct {
+	Title      string    `json:"title"`
+	Username   string    `json:"username"`
+	Password   string    `json:"password"`
+	URL        string    `json:"url,omitempty"`
+	Notes      string    `json:"notes,omitempty"`
+	OTP        string    `json:"otp,omitempty"`
+	ModifiedAt time.Time `json:"modified_at"` // when these values were set
+	ReplacedAt time.Time `json:"replaced_at"` // when they were overwritten
+}
+
+// Version returns a snapshot of the entry's current field values
+func (e *Entry) Version() EntryVersion {
+	return EntryVersion{
+		Title:      e.Title,
+		Username:   e.Username,
+		Password:   e.Password,
+		URL:        e.URL,
+		Notes:      e.Notes,
+		OTP:        e.OTP,
+		ModifiedAt: e.UpdatedAt,
+	}
+}
+
+// AddVersion stores previous as the newest history version if the entry's
+// fields have changed since it was taken, keeping at most limit versions.
+// A limit of zero or less disables history. It reports whether a version was added.
+func (e *Entry) AddVersion(previous EntryVersion, limit int) bool {
+	if limit <= 0 || len(previous.Changed(e.Version())) == 0 {
+		return false
+	}
+
+	previous.ReplacedAt = time.Now()
+	e.History = append(e.History, previous)
+	e.TrimHistory(limit)
+	return true
+}
+
+// PasswordChangedAt returns when the current password was set: when the
+// newest version with a different password was replaced. Without such a
+// version it falls back to the oldest known version, or UpdatedAt.
+func (e *Entry) PasswordChangedAt() time.Time {
+	for i := len(e.History) - 1; i >= 0; i-- {
+		if e.History[i].Password != e.Password {
+			return e.History[i].ReplacedAt
+		}
+	}
+	if len(e.History) > 0 {
+		return e.History[0].ModifiedAt
+	}
+	return e.UpdatedAt
+}
+
+// TrimHistory drops the oldest versions beyond limit
+func (e *Entry) TrimHistory(limit int) {
+	if limit < 0 {
+		limit = 0
+	}
+	if len(e.History) > limit {
+		e.History = append([]EntryVersion(nil), e.History[len(e.History)-limit:]...)
+	}
+}
+
+// Restore sets fields back to their values in version. The values being
+// replaced are kept as a new history version, so a restore can be undone.
+// It returns the fields that changed.
+func (e *Entry) Restore(version EntryVersion, fields []string, limit int) []string {
+	previous := e.Version()
+	for _, field := range fields {
+		e.SetField(field, version.Field(field))
+	}
+
+	changed := previous.Changed(e.Version())
+	if len(changed) == 0 {
+		return nil
+	}
+	e.AddVersion(previous, limit)
+	e.UpdatedAt = time.Now()
+	return changed
+}
+
+// HistoryVersion returns version n of the entry's history, where 1 is the
+// most recently replaced version
+func (e *Entry) HistoryVersion(n int) (EntryVersion, error) {
+	if n < 1 || n > len(e.History) {
+		return EntryVersion{}, fmt.Errorf("version %d not found, the entry has %d previous versions", n, len(e.History))
+	}
+	return e.History[len(e.History)-n], nil
+}
+
+// SetField sets one of the HistoryFields to value
+func (e *Entry) SetField(field, value string) error {
+	switch field {
+	case "title":
+		e.Title = value
+	case "username":
+		e.Username = value
+	case "password":
+		e.Password = value
+	case "url":
+		e.URL = value
+	case "notes":
+		e.Notes = value
+	case "otp":
+		e.OTP = value
+	default:
+		return fmt.Errorf("unknown field '%s'", field)
+	}
+	return nil
+}
+
+// Field returns the value of one of the HistoryFields
+func (v EntryVersion) Field(field string) string {
+	switch field {
+	case "title":
+		return v.Title
+	case "username":
+		return v.Username
+	case "password":
+		return v.Password
+	case "url":
+		return v.URL
+	case "notes":
+		return v.Notes
+	case "otp":
+		return v.OTP
+	}
+	return ""
+}
+
+// Changed returns the fields whose values differ between v and other
+func (v EntryVersion) Changed(other EntryVersion) []string {
+	var fields []string
+	for _, field := range HistoryFields {
+		if v.Field(field) != other.Field(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
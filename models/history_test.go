@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestAddVersionTrimsToLimit(t *testing.T) {
+	entry := NewEntry("Mail", "me", "password-0")
+
+	for i := 1; i <= 5; i++ {
+		previous := entry.Version()
+		entry.Password = fmt.Sprintf("password-%d", i)
+		if !entry.AddVersion(previous, 3) {
+			t.Fatalf("change %d was not added to the history", i)
+		}
+	}
+
+	if len(entry.History) != 3 {
+		t.Fatalf("history has %d versions, want 3", len(entry.History))
+	}
+	// The oldest versions were dropped, version 1 is the most recent
+	for n, want := range map[int]string{1: "password-4", 2: "password-3", 3: "password-2"} {
+		version, err := entry.HistoryVersion(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version.Password != want {
+			t.Errorf("version %d password = %q, want %q", n, version.Password, want)
+		}
+		if version.ReplacedAt.IsZero() {
+			t.Errorf("version %d has no replacement time", n)
+		}
+	}
+	if _, err := entry.HistoryVersion(4); err == nil {
+		t.Error("version 4 exists beyond the limit")
+	}
+}
+
+func TestAddVersionSkipsUnchangedAndDisabled(t *testing.T) {
+	entry := NewEntry("Mail", "me", "secret")
+
+	if entry.AddVersion(entry.Version(), 10) {
+		t.Error("a version without changes was added")
+	}
+
+	previous := entry.Version()
+	entry.Password = "changed"
+	if entry.AddVersion(previous, 0) {
+		t.Error("a version was added with history disabled")
+	}
+	if len(entry.History) != 0 {
+		t.Errorf("history has %d versions, want none", len(entry.History))
+	}
+}
+
+func TestRestoreAddsHistoryVersion(t *testing.T) {
+	entry := NewEntry("Mail", "me", "first")
+	previous := entry.Version()
+	entry.Password = "second"
+	entry.Username = "someone"
+	entry.AddVersion(previous, 10)
+
+	version, err := entry.HistoryVersion(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed := entry.Restore(version, []string{"password"}, 10)
+
+	if len(changed) != 1 || changed[0] != "password" {
+		t.Errorf("changed fields = %v, want [password]", changed)
+	}
+	if entry.Password != "first" || entry.Username != "someone" {
+		t.Errorf("after restore password %q, username %q, want only the password restored", entry.Password, entry.Username)
+	}
+	if len(entry.History) != 2 {
+		t.Fatalf("history has %d versions, want 2", len(entry.History))
+	}
+
+	// The replaced password became version 1, so restoring it undoes the restore
+	replaced, err := entry.HistoryVersion(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced.Password != "second" {
+		t.Errorf("version 1 password = %q, want the replaced %q", replaced.Password, "second")
+	}
+	entry.Restore(replaced, []string{"password"}, 10)
+	if entry.Password != "second" {
+		t.Errorf("undo restored %q, want %q", entry.Password, "second")
+	}
+
+	// Restoring values the entry already has changes nothing
+	versions := len(entry.History)
+	if changed := entry.Restore(entry.Version(), HistoryFields, 10); changed != nil {
+		t.Errorf("restoring the current values changed %v", changed)
+	}
+	if len(entry.History) != versions {
+		t.Error("a no-op restore added a history version")
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+// PasswordPolicy records the rules a site imposes on passwords, so a password
+// generated again for the same entry satisfies them too
+type PasswordPolicy struct {
+	Rules            string   `json:"rules,omitempty"` // passwordrules descriptor the policy came from
+	Length           int      `json:"length"`
+	MinLength        int      `json:"min_length,omitempty"`
+	MaxLength        int      `json:"max_length,omitempty"`
+	Lower            bool     `json:"lower"`
+	Upper            bool     `json:"upper"`
+	Numbers          bool     `json:"numbers"`
+	Symbols          bool     `json:"symbols"`
+	MinLower         int      `json:"min_lower,omitempty"`
+	MinUpper         int      `json:"min_upper,omitempty"`
+	MinNumbers       int      `json:"min_numbers,omitempty"`
+	MinSymbols       int      `json:"min_symbols,omitempty"`
+	SymbolSet        string   `json:"symbol_set,omitempty"`
+	Allowed          string   `json:"allowed,omitempty"`
+	Forbidden        string   `json:"forbidden,omitempty"`
+	Required         []string `json:"required,omitempty"`
+	MaxConsecutive   int      `json:"max_consecutive,omitempty"`
+	ExcludeAmbiguous bool     `json:"exclude_ambiguous"`
+}
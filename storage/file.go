@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileStorage keeps objects as files in a local directory. Conditional
+// writes hold a lock file next to the object, so they are safe across
+// processes.
+type FileStorage struct {
+	Dir string
+}
+
+// Read returns the content of a file and a hash of it as version
+func (s *FileStorage) Read(name string) ([]byte, string, error) {
+	data, err := os.ReadFile(s.path(name))
+	if os.IsNotExist(err) {
+		return nil, "", ErrNotExist
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return data, contentVersion(data), nil
+}
+
+// Write atomically replaces a file if its version matches ifVersion
+func (s *FileStorage) Write(name string, data []byte, ifVersion string) (string, error) {
+	path := s.path(name)
+	if err := os.MkdirAll(s.Dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Unconditional writes are atomic on their own; conditional ones hold the
+	// lock so no other process writes between the check and the rename
+	if ifVersion != AnyVersion {
+		lock, err := lockFile(path)
+		if err != nil {
+			return "", err
+		}
+		defer lock.Unlock()
+
+		current, err := os.ReadFile(path)
+		switch {
+		case os.IsNotExist(err):
+			if ifVersion != NoVersion {
+				return "", ErrVersionMismatch
+			}
+		case err != nil:
+			return "", err
+		case ifVersion == NoVersion || contentVersion(current) != ifVersion:
+			return "", ErrVersionMismatch
+		}
+	}
+
+	if err := writeFileAtomic(path, data, 0600); err != nil {
+		return "", err
+	}
+	return contentVersion(data), nil
+}
+
+// Stat describes a file. Its version is only computed by Read and Write.
+func (s *FileStorage) Stat(name string) (ObjectInfo, error) {
+	info, err := os.Stat(s.path(name))
+	if os.IsNotExist(err) {
+		return ObjectInfo{}, ErrNotExist
+	}
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	return ObjectInfo{Name: name, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// List describes the files in the directory whose names start with prefix
+func (s *FileStorage) List(prefix string) ([]ObjectInfo, error) {
+	files, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []ObjectInfo
+	for _, file := range files {
+		if file.IsDir() || !strings.HasPrefix(file.Name(), prefix) {
+			continue
+		}
+		info, err := file.Info()
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, ObjectInfo{Name: file.Name(), Size: info.Size(), ModTime: info.ModTime()})
+	}
+	return objects, nil
+}
+
+// Remove deletes a file. Its lock file is left in place: another process may
+// be waiting on it, and unlinking it would let a third one lock a new file.
+func (s *FileStorage) Remove(name string) error {
+	err := os.Remove(s.path(name))
+	if os.IsNotExist(err) {
+		return ErrNotExist
+	}
+	return err
+}
+
+// Rename moves a file, replacing newName
+func (s *FileStorage) Rename(oldName, newName string) error {
+	err := os.Rename(s.path(oldName), s.path(newName))
+	if os.IsNotExist(err) {
+		return ErrNotExist
+	}
+	return err
+}
+
+func (s *FileStorage) path(name string) string {
+	return filepath.Join(s.Dir, name)
+}
+
+// contentVersion identifies file content
+func contentVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
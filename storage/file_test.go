@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileConditionalWrites(t *testing.T) {
+	testConditionalWrites(t, &FileStorage{Dir: t.TempDir()})
+}
+
+func TestFileRemoveKeepsLockFile(t *testing.T) {
+	store := &FileStorage{Dir: t.TempDir()}
+
+	if _, err := store.Write("vault.gpv", []byte("data"), NoVersion); err != nil {
+		t.Fatal(err)
+	}
+	lockPath := filepath.Join(store.Dir, "vault.gpv.lock")
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Fatalf("conditional write left no lock file: %v", err)
+	}
+
+	// A process waiting on the lock must keep locking the same file
+	lock, err := lockFile(filepath.Join(store.Dir, "vault.gpv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	if err := store.Remove("vault.gpv"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, _, err := store.Read("vault.gpv"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Read after Remove: %v, want ErrNotExist", err)
+	}
+	if _, err := os.Stat(lockPath); err != nil {
+		t.Errorf("Remove deleted the lock file: %v", err)
+	}
+	if err := store.Remove("vault.gpv"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("second Remove: %v, want ErrNotExist", err)
+	}
+}
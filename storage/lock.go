@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// lockTimeout is how long to wait for another process to release a file
+	lockTimeout = 10 * time.Second
+	// lockRetryInterval is how often a held lock is retried
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+// fileLock is an advisory, cross-process lock on a file. It is held on a
+// companion ".lock" file so the file itself can be replaced by rename.
+type fileLock struct {
+	file *os.File
+}
+
+// lockFile acquires the exclusive lock for the file at path, waiting up to lockTimeout
+func lockFile(path string) (*fileLock, error) {
+	file, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if locked {
+			return &fileLock{file: file}, nil
+		}
+		if time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("%s is locked by another process", path)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// Unlock releases the lock
+func (l *fileLock) Unlock() error {
+	defer l.file.Close()
+	return unlockFile(l.file)
+}
@@ -0,0 +1,372 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Storage keeps objects in a bucket of Amazon S3 or a compatible store such
+// as MinIO. Writes are conditional on the object's ETag.
+type S3Storage struct {
+	Endpoint     string // e.g. https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	Bucket       string
+	Prefix       string // key prefix of the objects, "" or ending in a slash
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	PathStyle    bool // address the bucket in the path instead of the host name
+	Client       *http.Client
+}
+
+// newS3Storage returns the storage for an s3://bucket/key URL. The query
+// parameters region and endpoint select the service; otherwise the usual AWS
+// environment variables are used, as are AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN for credentials.
+func newS3Storage(u *url.URL, dir string) (*S3Storage, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("s3 URL needs a bucket")
+	}
+
+	query := u.Query()
+	s := &S3Storage{
+		Bucket:       u.Host,
+		Prefix:       strings.TrimPrefix(dir, "/"),
+		Region:       firstNonEmpty(query.Get("region"), os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), "us-east-1"),
+		Endpoint:     firstNonEmpty(query.Get("endpoint"), os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")),
+		AccessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		Client:       &http.Client{Timeout: 30 * time.Second},
+	}
+
+	// Custom endpoints are usually self-hosted stores without bucket host names
+	if s.Endpoint == "" {
+		s.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", s.Region)
+	} else {
+		s.PathStyle = true
+	}
+	s.Endpoint = strings.TrimRight(s.Endpoint, "/")
+
+	if s.AccessKey == "" || s.SecretKey == "" {
+		return nil, fmt.Errorf("s3 storage needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	return s, nil
+}
+
+// Read downloads an object
+func (s *S3Storage) Read(name string) ([]byte, string, error) {
+	resp, err := s.do(http.MethodGet, s.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if err := s.check(resp, name, http.StatusOK); err != nil {
+		return nil, "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxObjectSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// Write uploads an object if its ETag matches ifVersion
+func (s *S3Storage) Write(name string, data []byte, ifVersion string) (string, error) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	switch ifVersion {
+	case AnyVersion:
+	case NoVersion:
+		header.Set("If-None-Match", "*")
+	default:
+		header.Set("If-Match", ifVersion)
+	}
+
+	resp, err := s.do(http.MethodPut, s.Prefix+name, nil, header, data)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// A concurrent conditional write makes S3 answer 409 ConditionalRequestConflict,
+	// and If-Match on a deleted object 404 NoSuchKey
+	if resp.StatusCode == http.StatusConflict || (resp.StatusCode == http.StatusNotFound && ifVersion != AnyVersion) {
+		return "", ErrVersionMismatch
+	}
+	if err := s.check(resp, name, http.StatusOK); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// Stat describes an object
+func (s *S3Storage) Stat(name string) (ObjectInfo, error) {
+	resp, err := s.do(http.MethodHead, s.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+	if err := s.check(resp, name, http.StatusOK); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return ObjectInfo{
+		Name:    name,
+		Size:    resp.ContentLength,
+		ModTime: modTime,
+		Version: resp.Header.Get("ETag"),
+	}, nil
+}
+
+// listBucketResult is the part of a ListObjectsV2 response List reads
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List describes the objects next to the vault whose names start with prefix
+func (s *S3Storage) List(prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", s.Prefix+prefix)
+		query.Set("delimiter", "/")
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		resp, err := s.do(http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = s.check(resp, "", http.StatusOK)
+		if err == nil {
+			err = xml.NewDecoder(io.LimitReader(resp.Body, maxObjectSize)).Decode(&result)
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, object := range result.Contents {
+			objects = append(objects, ObjectInfo{
+				Name:    strings.TrimPrefix(object.Key, s.Prefix),
+				Size:    object.Size,
+				ModTime: object.LastModified,
+				Version: object.ETag,
+			})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Remove deletes an object
+func (s *S3Storage) Remove(name string) error {
+	resp, err := s.do(http.MethodDelete, s.Prefix+name, nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return s.check(resp, name, http.StatusOK, http.StatusNoContent)
+}
+
+// do sends a signed request for the object key, or the bucket if key is empty
+func (s *S3Storage) do(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	endpoint, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+
+	target := *endpoint
+	if s.PathStyle {
+		target.Path = endpoint.Path + "/" + s.Bucket + "/" + key
+	} else {
+		target.Host = s.Bucket + "." + endpoint.Host
+		target.Path = endpoint.Path + "/" + key
+	}
+	target.RawPath = s3EscapePath(target.Path) // send the path exactly as it is signed
+	target.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s failed: %w", method, err)
+	}
+	return resp, nil
+}
+
+// s3Error is the error document of S3 responses
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// check maps an unexpected response status to an error
+func (s *S3Storage) check(resp *http.Response, name string, expected ...int) error {
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotExist
+	case http.StatusPreconditionFailed:
+		return ErrVersionMismatch
+	}
+
+	var details s3Error
+	xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&details)
+	if details.Code != "" {
+		return fmt.Errorf("s3 %s s3://%s/%s%s: %s: %s", resp.Request.Method, s.Bucket, s.Prefix, name, details.Code, details.Message)
+	}
+	return fmt.Errorf("s3 %s s3://%s/%s%s returned %s", resp.Request.Method, s.Bucket, s.Prefix, name, resp.Status)
+}
+
+// sign adds an AWS Signature Version 4 to req
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256Hex(body)
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+
+	req.Header.Set("Host", req.URL.Host)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	// Sign every header that is set, sorted by lower case name
+	var names []string
+	for name := range req.Header {
+		names = append(names, strings.ToLower(name))
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		var values []string
+		for _, value := range req.Header.Values(name) {
+			values = append(values, strings.Join(strings.Fields(value), " "))
+		}
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, strings.Join(values, ","))
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", day, s.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), day)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, signature))
+	req.Header.Del("Host") // sent from req.Host
+}
+
+// s3EscapePath encodes a path the way SigV4 expects, keeping slashes
+func s3EscapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3CanonicalQuery encodes query parameters sorted by name
+func s3CanonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// s3Escape percent-encodes everything but unreserved characters
+func s3Escape(value string) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// firstNonEmpty returns the first non-empty value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+// Package storage keeps vault files on the local filesystem, a WebDAV server
+// or an S3 compatible object store. Every backend supports conditional
+// writes, so concurrent writers detect each other instead of overwriting
+// changes.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Version conditions for Write, besides a version returned by Read or Write
+const (
+	AnyVersion = ""         // write whether or not the object exists
+	NoVersion  = "<absent>" // write only if the object does not exist yet
+)
+
+var (
+	// ErrNotExist is returned when an object does not exist
+	ErrNotExist = errors.New("object does not exist")
+	// ErrVersionMismatch is returned when a conditional write finds another version
+	ErrVersionMismatch = errors.New("object was modified by another writer")
+)
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	Version string // opaque tag that changes whenever the content does, like an HTTP ETag
+}
+
+// Storage is a flat collection of named objects, such as the files in a directory
+type Storage interface {
+	// Read returns the content and version of an object
+	Read(name string) ([]byte, string, error)
+	// Write stores an object if its current version matches ifVersion, which
+	// is AnyVersion, NoVersion or a version from Read or Write, and returns
+	// the new version. A mismatch fails with ErrVersionMismatch.
+	Write(name string, data []byte, ifVersion string) (string, error)
+	// Stat describes an object
+	Stat(name string) (ObjectInfo, error)
+	// List describes the objects whose names start with prefix
+	List(prefix string) ([]ObjectInfo, error)
+	// Remove deletes an object
+	Remove(name string) error
+}
+
+// Renamer is implemented by storages that can move an object without copying
+// it, keeping its modification time
+type Renamer interface {
+	// Rename replaces newName with oldName's object
+	Rename(oldName, newName string) error
+}
+
+// Open returns the storage holding the object at location and the object's
+// name in it. location is a local path or a URL:
+//
+//	/home/me/.config/gopassman/vault.gpv
+//	file:///home/me/vault.gpv
+//	webdav://dav.example.com/remote.php/dav/files/me/vault.gpv  (webdavs:// for HTTPS)
+//	s3://bucket/team.gpv?region=eu-central-1&endpoint=http://localhost:9000
+func Open(location string) (Storage, string, error) {
+	if !strings.Contains(location, "://") {
+		return &FileStorage{Dir: filepath.Dir(location)}, filepath.Base(location), nil
+	}
+
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid storage URL: %w", err)
+	}
+	dir, name := path.Split(u.Path)
+	if name == "" {
+		return nil, "", fmt.Errorf("storage URL %s does not name a file", Redact(location))
+	}
+
+	switch u.Scheme {
+	case "file":
+		return &FileStorage{Dir: filepath.FromSlash(dir)}, name, nil
+	case "webdav", "webdavs":
+		store, err := newWebDAVStorage(u, dir)
+		return store, name, err
+	case "s3":
+		store, err := newS3Storage(u, dir)
+		return store, name, err
+	default:
+		return nil, "", fmt.Errorf("unsupported storage scheme %q (supported: file, webdav, webdavs, s3)", u.Scheme)
+	}
+}
+
+// Redact returns location with any password in it replaced, for display
+func Redact(location string) string {
+	u, err := url.Parse(location)
+	if err != nil || u.User == nil {
+		return location
+	}
+	return u.Redacted()
+}
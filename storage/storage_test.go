@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+// memoryObjects is the object store behind the test servers. Versions are
+// quoted counters, like ETags.
+type memoryObjects struct {
+	mutex    sync.Mutex
+	data     map[string][]byte
+	versions map[string]string
+	writes   int
+}
+
+func newMemoryObjects() *memoryObjects {
+	return &memoryObjects{data: make(map[string][]byte), versions: make(map[string]string)}
+}
+
+// put stores an object under a new version; the caller holds the mutex
+func (m *memoryObjects) put(name string, data []byte) string {
+	m.writes++
+	m.data[name] = data
+	m.versions[name] = fmt.Sprintf(`"v%d"`, m.writes)
+	return m.versions[name]
+}
+
+// preconditionFailed reports whether a write with the request's If-Match and
+// If-None-Match headers must be rejected; the caller holds the mutex
+func (m *memoryObjects) preconditionFailed(r *http.Request, name string) bool {
+	version, exists := m.versions[name]
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && (!exists || ifMatch != version) {
+		return true
+	}
+	return r.Header.Get("If-None-Match") == "*" && exists
+}
+
+// testConditionalWrites runs the conditional write checks every storage must pass
+func testConditionalWrites(t *testing.T, store Storage) {
+	t.Helper()
+
+	if _, _, err := store.Read("vault.gpv"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Read of a missing object: %v, want ErrNotExist", err)
+	}
+	if _, err := store.Write("vault.gpv", []byte("first"), "\"v999\""); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Write of a missing object with a version: %v, want ErrVersionMismatch", err)
+	}
+
+	first, err := store.Write("vault.gpv", []byte("first"), NoVersion)
+	if err != nil {
+		t.Fatalf("Write with NoVersion: %v", err)
+	}
+	if _, err := store.Write("vault.gpv", []byte("other"), NoVersion); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Write with NoVersion over an existing object: %v, want ErrVersionMismatch", err)
+	}
+
+	second, err := store.Write("vault.gpv", []byte("second"), first)
+	if err != nil {
+		t.Fatalf("Write with the current version: %v", err)
+	}
+	if second == first {
+		t.Errorf("version did not change on write")
+	}
+	if _, err := store.Write("vault.gpv", []byte("stale"), first); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Write with a stale version: %v, want ErrVersionMismatch", err)
+	}
+
+	data, version, err := store.Read("vault.gpv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "second" || version != second {
+		t.Errorf("Read = %q version %s, want %q version %s", data, version, "second", second)
+	}
+
+	if _, err := store.Write("vault.gpv", []byte("third"), AnyVersion); err != nil {
+		t.Errorf("Write with AnyVersion: %v", err)
+	}
+}
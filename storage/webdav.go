@@ -0,0 +1,325 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// maxObjectSize bounds the objects read from remote storage
+const maxObjectSize = 64 << 20
+
+// WebDAVStorage keeps objects in a WebDAV collection. Conditional writes
+// lock the resource while checking its version, so they are safe even on
+// servers that ignore If-Match.
+type WebDAVStorage struct {
+	BaseURL  string // collection URL ending in a slash
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+// newWebDAVStorage returns the storage for a webdav:// or webdavs:// URL.
+// Credentials come from the URL or GOPASSMAN_WEBDAV_USERNAME and
+// GOPASSMAN_WEBDAV_PASSWORD.
+func newWebDAVStorage(u *url.URL, dir string) (*WebDAVStorage, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("webdav URL needs a host")
+	}
+
+	scheme := "http"
+	if u.Scheme == "webdavs" {
+		scheme = "https"
+	}
+	base := url.URL{Scheme: scheme, Host: u.Host, Path: dir}
+
+	s := &WebDAVStorage{
+		BaseURL:  base.String(),
+		Username: os.Getenv("GOPASSMAN_WEBDAV_USERNAME"),
+		Password: os.Getenv("GOPASSMAN_WEBDAV_PASSWORD"),
+		Client:   &http.Client{Timeout: 30 * time.Second},
+	}
+	if u.User != nil {
+		s.Username = u.User.Username()
+		if password, set := u.User.Password(); set {
+			s.Password = password
+		}
+	}
+	return s, nil
+}
+
+// Read downloads a resource
+func (s *WebDAVStorage) Read(name string) ([]byte, string, error) {
+	resp, err := s.do(http.MethodGet, name, nil, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if err := s.check(resp, name, http.StatusOK); err != nil {
+		return nil, "", err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxObjectSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	return data, resp.Header.Get("ETag"), nil
+}
+
+// Write uploads a resource if its version matches ifVersion
+func (s *WebDAVStorage) Write(name string, data []byte, ifVersion string) (string, error) {
+	header := http.Header{}
+	switch ifVersion {
+	case AnyVersion:
+	case NoVersion:
+		header.Set("If-None-Match", "*")
+	default:
+		// Locking a missing resource would create it, so check it exists first
+		if _, err := s.Stat(name); err != nil {
+			if errors.Is(err, ErrNotExist) {
+				return "", ErrVersionMismatch
+			}
+			return "", err
+		}
+
+		// Hold a lock so nobody writes between the version check and the upload
+		token, err := s.lock(name)
+		if err != nil {
+			return "", err
+		}
+		if token != "" {
+			defer s.unlock(name, token)
+			header.Set("If", "(<"+token+">)")
+		}
+
+		info, err := s.Stat(name)
+		if errors.Is(err, ErrNotExist) || (err == nil && info.Version != ifVersion) {
+			return "", ErrVersionMismatch
+		}
+		if err != nil {
+			return "", err
+		}
+		header.Set("If-Match", ifVersion)
+	}
+
+	resp, err := s.do(http.MethodPut, name, header, data)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	if err := s.check(resp, name, http.StatusOK, http.StatusCreated, http.StatusNoContent); err != nil {
+		return "", err
+	}
+
+	if version := resp.Header.Get("ETag"); version != "" {
+		return version, nil
+	}
+	info, err := s.Stat(name)
+	return info.Version, err
+}
+
+// Stat describes a resource
+func (s *WebDAVStorage) Stat(name string) (ObjectInfo, error) {
+	resp, err := s.do(http.MethodHead, name, nil, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+	if err := s.check(resp, name, http.StatusOK); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return ObjectInfo{
+		Name:    name,
+		Size:    resp.ContentLength,
+		ModTime: modTime,
+		Version: resp.Header.Get("ETag"),
+	}, nil
+}
+
+// propfindBody asks for the properties List needs
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:propfind xmlns:D="DAV:"><D:prop>
+<D:resourcetype/><D:getcontentlength/><D:getlastmodified/><D:getetag/>
+</D:prop></D:propfind>`
+
+// multistatus is the part of a PROPFIND response List reads
+type multistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				ResourceType struct {
+					Collection *struct{} `xml:"collection"`
+				} `xml:"resourcetype"`
+				ContentLength int64  `xml:"getcontentlength"`
+				LastModified  string `xml:"getlastmodified"`
+				ETag          string `xml:"getetag"`
+			} `xml:"prop"`
+			Status string `xml:"status"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// List describes the resources in the collection whose names start with prefix
+func (s *WebDAVStorage) List(prefix string) ([]ObjectInfo, error) {
+	header := http.Header{}
+	header.Set("Depth", "1")
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+	resp, err := s.do("PROPFIND", "", header, []byte(propfindBody))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := s.check(resp, "", http.StatusMultiStatus); err != nil {
+		return nil, err
+	}
+
+	var result multistatus
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, maxObjectSize)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid PROPFIND response: %w", err)
+	}
+
+	var objects []ObjectInfo
+	for _, response := range result.Responses {
+		href, err := url.PathUnescape(response.Href)
+		if err != nil {
+			continue
+		}
+		name := path.Base(strings.TrimSuffix(href, "/"))
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		for _, propstat := range response.Propstat {
+			prop := propstat.Prop
+			if !strings.Contains(propstat.Status, " 200 ") || prop.ResourceType.Collection != nil {
+				continue
+			}
+			modTime, _ := http.ParseTime(prop.LastModified)
+			objects = append(objects, ObjectInfo{
+				Name:    name,
+				Size:    prop.ContentLength,
+				ModTime: modTime,
+				Version: prop.ETag,
+			})
+		}
+	}
+	return objects, nil
+}
+
+// Remove deletes a resource
+func (s *WebDAVStorage) Remove(name string) error {
+	resp, err := s.do(http.MethodDelete, name, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return s.check(resp, name, http.StatusOK, http.StatusNoContent)
+}
+
+// Rename moves a resource, replacing newName
+func (s *WebDAVStorage) Rename(oldName, newName string) error {
+	header := http.Header{}
+	header.Set("Destination", s.BaseURL+url.PathEscape(newName))
+	header.Set("Overwrite", "T")
+	resp, err := s.do("MOVE", oldName, header, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return s.check(resp, oldName, http.StatusCreated, http.StatusNoContent)
+}
+
+// lockBody requests an exclusive write lock
+const lockBody = `<?xml version="1.0" encoding="utf-8"?>
+<D:lockinfo xmlns:D="DAV:"><D:lockscope><D:exclusive/></D:lockscope>
+<D:locktype><D:write/></D:locktype><D:owner>gopassman</D:owner></D:lockinfo>`
+
+// lock takes a short exclusive lock on a resource and returns its token, or
+// "" if the server does not support locking
+func (s *WebDAVStorage) lock(name string) (string, error) {
+	header := http.Header{}
+	header.Set("Timeout", "Second-60")
+	header.Set("Depth", "0")
+	header.Set("Content-Type", "application/xml; charset=utf-8")
+	resp, err := s.do("LOCK", name, header, []byte(lockBody))
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return "", nil
+	case http.StatusLocked:
+		return "", ErrVersionMismatch
+	}
+	if err := s.check(resp, name, http.StatusOK, http.StatusCreated); err != nil {
+		return "", err
+	}
+	return strings.Trim(resp.Header.Get("Lock-Token"), "<>"), nil
+}
+
+// unlock releases a lock taken by lock
+func (s *WebDAVStorage) unlock(name, token string) {
+	header := http.Header{}
+	header.Set("Lock-Token", "<"+token+">")
+	if resp, err := s.do("UNLOCK", name, header, nil); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// do sends a request for the resource name, or the collection if name is empty
+func (s *WebDAVStorage) do(method, name string, header http.Header, body []byte) (*http.Response, error) {
+	target := s.BaseURL + url.PathEscape(name)
+
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if s.Username != "" || s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("webdav %s failed: %w", method, err)
+	}
+	return resp, nil
+}
+
+// check maps an unexpected response status to an error
+func (s *WebDAVStorage) check(resp *http.Response, name string, expected ...int) error {
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return ErrNotExist
+	case http.StatusPreconditionFailed, http.StatusLocked:
+		return ErrVersionMismatch
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("webdav server denied access to %s (%s)", s.BaseURL+name, resp.Status)
+	}
+	return fmt.Errorf("webdav %s %s returned %s", resp.Request.Method, s.BaseURL+name, resp.Status)
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// davServer is a WebDAV collection at /dav/ honoring If-Match and
+// If-None-Match. lockStatus, if set, is the answer to LOCK requests instead
+// of granting the lock. beforePut runs before a conditional PUT is checked.
+type davServer struct {
+	*memoryObjects
+	lockStatus int
+	locked     string // name of the locked resource, "" if none
+	beforePut  func(name string)
+}
+
+func (d *davServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if user, password, _ := r.BasicAuth(); user != "alice" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/dav/")
+	data, exists := d.data[name]
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", d.versions[name])
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Write(data)
+
+	case http.MethodPut:
+		if d.beforePut != nil && r.Header.Get("If-Match") != "" {
+			d.beforePut(name)
+		}
+		if d.locked == name && r.Header.Get("If") != "(<opaquelocktoken:test>)" {
+			w.WriteHeader(http.StatusLocked)
+			return
+		}
+		if d.preconditionFailed(r, name) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("ETag", d.put(name, body))
+		w.WriteHeader(http.StatusCreated)
+
+	case "LOCK":
+		if d.lockStatus != 0 {
+			w.WriteHeader(d.lockStatus)
+			return
+		}
+		if d.locked != "" {
+			w.WriteHeader(http.StatusLocked)
+			return
+		}
+		d.locked = name
+		w.Header().Set("Lock-Token", "<opaquelocktoken:test>")
+		w.WriteHeader(http.StatusOK)
+
+	case "UNLOCK":
+		d.locked = ""
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		delete(d.data, name)
+		delete(d.versions, name)
+		w.WriteHeader(http.StatusNoContent)
+
+	case "MOVE":
+		destination, err := url.Parse(r.Header.Get("Destination"))
+		if err != nil || !exists {
+			http.NotFound(w, r)
+			return
+		}
+		target := strings.TrimPrefix(destination.Path, "/dav/")
+		d.data[target], d.versions[target] = data, d.versions[name]
+		delete(d.data, name)
+		delete(d.versions, name)
+		w.WriteHeader(http.StatusCreated)
+
+	case "PROPFIND":
+		names := make([]string, 0, len(d.data))
+		for name := range d.data {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><D:multistatus xmlns:D="DAV:">`)
+		fmt.Fprint(w, `<D:response><D:href>/dav/</D:href><D:propstat><D:prop><D:resourcetype><D:collection/></D:resourcetype></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`)
+		for _, name := range names {
+			fmt.Fprintf(w, `<D:response><D:href>/dav/%s</D:href><D:propstat><D:prop><D:resourcetype/><D:getcontentlength>%d</D:getcontentlength><D:getetag>%s</D:getetag></D:prop><D:status>HTTP/1.1 200 OK</D:status></D:propstat></D:response>`,
+				url.PathEscape(name), len(d.data[name]), d.versions[name])
+		}
+		fmt.Fprint(w, `</D:multistatus>`)
+
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newDAVStorage starts a davServer and opens the storage of a vault on it
+func newDAVStorage(t *testing.T) (*davServer, Storage) {
+	dav := &davServer{memoryObjects: newMemoryObjects()}
+	server := httptest.NewServer(dav)
+	t.Cleanup(server.Close)
+
+	location := "webdav://alice:secret@" + strings.TrimPrefix(server.URL, "http://") + "/dav/vault.gpv"
+	store, name, err := Open(location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "vault.gpv" {
+		t.Fatalf("Open returned object name %q", name)
+	}
+	return dav, store
+}
+
+func TestWebDAVConditionalWrites(t *testing.T) {
+	_, store := newDAVStorage(t)
+	testConditionalWrites(t, store)
+}
+
+func TestWebDAVWithoutLocking(t *testing.T) {
+	dav, store := newDAVStorage(t)
+	dav.lockStatus = http.StatusMethodNotAllowed
+	testConditionalWrites(t, store)
+}
+
+func TestWebDAVLockedResource(t *testing.T) {
+	dav, store := newDAVStorage(t)
+	version, err := store.Write("vault.gpv", []byte("first"), NoVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dav.locked = "vault.gpv"
+	if _, err := store.Write("vault.gpv", []byte("second"), version); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Write of a resource locked by another client: %v, want ErrVersionMismatch", err)
+	}
+}
+
+func TestWebDAVWriteBetweenCheckAndUpload(t *testing.T) {
+	// A server that ignores locks lets another client write after the version
+	// check; its If-Match still rejects the upload
+	dav, store := newDAVStorage(t)
+	dav.lockStatus = http.StatusMethodNotAllowed
+	version, err := store.Write("vault.gpv", []byte("first"), NoVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dav.beforePut = func(name string) { dav.put(name, []byte("other")) }
+	if _, err := store.Write("vault.gpv", []byte("second"), version); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Write after a concurrent change: %v, want ErrVersionMismatch", err)
+	}
+	if data := dav.data["vault.gpv"]; string(data) != "other" {
+		t.Errorf("stored %q, want the other client's write", data)
+	}
+}
+
+func TestWebDAVListRenameRemove(t *testing.T) {
+	_, store := newDAVStorage(t)
+	for _, name := range []string{"vault.gpv", "vault.gpv.1", "vault.gpv.2", "other.gpv"} {
+		if _, err := store.Write(name, []byte(name), AnyVersion); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	renamer, ok := store.(Renamer)
+	if !ok {
+		t.Fatal("WebDAV storage does not implement Renamer")
+	}
+	if err := renamer.Rename("vault.gpv.2", "vault.gpv.3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove("vault.gpv.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove("vault.gpv.1"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Remove of a missing resource: %v, want ErrNotExist", err)
+	}
+
+	objects, err := store.List("vault.gpv.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 1 || objects[0].Name != "vault.gpv.3" || objects[0].Size != int64(len("vault.gpv.2")) {
+		t.Errorf("List = %+v, want only vault.gpv.3", objects)
+	}
+}
+
+func TestWebDAVRejectsWrongCredentials(t *testing.T) {
+	_, store := newDAVStorage(t)
+	store.(*WebDAVStorage).Password = "wrong"
+
+	_, _, err := store.Read("vault.gpv")
+	if err == nil || errors.Is(err, ErrNotExist) || !strings.Contains(err.Error(), "denied access") {
+		t.Errorf("Read with a wrong password: %v, want an access error", err)
+	}
+}
@@ -0,0 +1,239 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/storage"
+)
+
+// MaxBackups is the number of rotated backups (vault.gpv.1, vault.gpv.2, ...)
+// kept next to the vault, in the same storage. Zero disables backups.
+var MaxBackups = 5
+
+// Backup describes one rotated backup of the vault file
+type Backup struct {
+	Index     int
+	Path      string
+	ModTime   time.Time
+	Size      int64
+	UpdatedAt string // vault timestamp recorded in the backup's header
+}
+
+// backupPath returns the location of the backup with the given index
+func backupPath(location string, index int) string {
+	suffix := fmt.Sprintf(".%d", index)
+
+	// Keep the query of storage URLs such as s3://bucket/team.gpv?region=...
+	if strings.Contains(location, "://") {
+		if base, query, found := strings.Cut(location, "?"); found {
+			return base + suffix + "?" + query
+		}
+	}
+	return location + suffix
+}
+
+// backupName returns the object name of the backup with the given index
+func backupName(name string, index int) string {
+	return fmt.Sprintf("%s.%d", name, index)
+}
+
+// replaceVaultFile replaces the vault file with data if its version still
+// matches ifVersion, then keeps the replaced file as backup 1. Backups are
+// only rotated after the conditional write succeeded, so a rejected write
+// leaves them untouched. It returns the new version; if the write succeeded
+// but the backup did not, it returns the new version together with the error.
+func replaceVaultFile(location string, data []byte, ifVersion string) (string, error) {
+	store, name, err := storage.Open(location)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 1; ; attempt++ {
+		current, version, err := store.Read(name)
+		if errors.Is(err, storage.ErrNotExist) {
+			current, version = nil, storage.NoVersion
+		} else if err != nil {
+			return "", err
+		}
+		if ifVersion != storage.AnyVersion && ifVersion != version {
+			return "", storage.ErrVersionMismatch
+		}
+
+		// Only replace the content just read, so it is exactly what is backed up
+		newVersion, err := store.Write(name, data, version)
+		if errors.Is(err, storage.ErrVersionMismatch) && ifVersion == storage.AnyVersion && attempt < maxSaveAttempts {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if current != nil {
+			if err := rotateBackups(store, name, current); err != nil {
+				return newVersion, fmt.Errorf("vault saved, but backing up the previous version failed: %w", err)
+			}
+		}
+		return newVersion, nil
+	}
+}
+
+// rotateBackups shifts existing backups up by one and stores previous, the
+// vault file that was just replaced, as backup 1. Backups beyond MaxBackups
+// are discarded.
+func rotateBackups(store storage.Storage, name string, previous []byte) error {
+	if MaxBackups <= 0 {
+		return nil
+	}
+
+	// Drop the oldest backup and everything past the retention limit
+	backups, err := listBackups(store, name)
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		if backup.Index >= MaxBackups {
+			if err := store.Remove(backupName(name, backup.Index)); err != nil && !errors.Is(err, storage.ErrNotExist) {
+				return err
+			}
+		}
+	}
+
+	// Shift the remaining backups, oldest first so none is overwritten
+	for i := len(backups) - 1; i >= 0; i-- {
+		index := backups[i].Index
+		if index >= MaxBackups {
+			continue
+		}
+		err := moveObject(store, backupName(name, index), backupName(name, index+1))
+		if err != nil && !errors.Is(err, storage.ErrNotExist) {
+			return err
+		}
+	}
+
+	_, err = store.Write(backupName(name, 1), previous, storage.AnyVersion)
+	return err
+}
+
+// moveObject renames an object, copying it on storages that cannot rename
+func moveObject(store storage.Storage, oldName, newName string) error {
+	if renamer, ok := store.(storage.Renamer); ok {
+		return renamer.Rename(oldName, newName)
+	}
+
+	data, _, err := store.Read(oldName)
+	if err != nil {
+		return err
+	}
+	if _, err := store.Write(newName, data, storage.AnyVersion); err != nil {
+		return err
+	}
+	return store.Remove(oldName)
+}
+
+// ListBackups returns the backups of the vault at location, newest first
+func ListBackups(location string) ([]Backup, error) {
+	store, name, err := storage.Open(location)
+	if err != nil {
+		return nil, err
+	}
+
+	backups, err := listBackups(store, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range backups {
+		backups[i].Path = backupPath(location, backups[i].Index)
+		data, _, err := store.Read(backupName(name, backups[i].Index))
+		if err != nil {
+			continue
+		}
+		if vaultFile, err := ParseVaultFile(data); err == nil {
+			backups[i].UpdatedAt = vaultFile.UpdatedAt
+		}
+	}
+
+	return backups, nil
+}
+
+// listBackups returns the backups of the vault object name without reading them
+func listBackups(store storage.Storage, name string) ([]Backup, error) {
+	objects, err := store.List(name + ".")
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []Backup
+	for _, object := range objects {
+		suffix, ok := strings.CutPrefix(object.Name, name+".")
+		if !ok {
+			continue
+		}
+		index, err := strconv.Atoi(suffix)
+		if err != nil || index < 1 {
+			continue
+		}
+
+		backups = append(backups, Backup{
+			Index:   index,
+			ModTime: object.ModTime,
+			Size:    object.Size,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Index < backups[j].Index
+	})
+
+	return backups, nil
+}
+
+// RestoreBackup replaces the vault at location with the backup with the given index.
+// The current vault is rotated into the backups first, so a restore can be undone.
+func RestoreBackup(location string, index int) error {
+	store, name, err := storage.Open(location)
+	if err != nil {
+		return err
+	}
+
+	data, _, err := store.Read(backupName(name, index))
+	if err != nil {
+		return fmt.Errorf("failed to read backup %d: %w", index, err)
+	}
+
+	// Make sure the backup at least looks like a vault before replacing anything
+	if _, err := ParseVaultFile(data); err != nil {
+		return fmt.Errorf("backup %d is not a valid vault: %w", index, err)
+	}
+
+	_, err = replaceVaultFile(location, data, storage.AnyVersion)
+	return err
+}
+
+// DeleteVault removes the vault at location together with its backups
+func DeleteVault(location string) error {
+	store, name, err := storage.Open(location)
+	if err != nil {
+		return err
+	}
+
+	backups, err := listBackups(store, name)
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		if err := store.Remove(backupName(name, backup.Index)); err != nil && !errors.Is(err, storage.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := store.Remove(name); err != nil && !errors.Is(err, storage.ErrNotExist) {
+		return err
+	}
+	return nil
+}
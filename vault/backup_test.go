@@ -0,0 +1,84 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/egemengunel/Go-Password-Manager/storage"
+)
+
+func TestReplaceVaultFileRotatesBackups(t *testing.T) {
+	defer func(max int) { MaxBackups = max }(MaxBackups)
+	MaxBackups = 3
+
+	path := filepath.Join(t.TempDir(), "vault.gpv")
+	version := storage.NoVersion
+	for i := 0; i < 5; i++ {
+		var err error
+		version, err = replaceVaultFile(path, []byte(fmt.Sprintf("v%d", i)), version)
+		if err != nil {
+			t.Fatalf("write %d: %v", i, err)
+		}
+	}
+
+	// Backup 1 is the newest replaced version, only MaxBackups are kept
+	for index, want := range map[int]string{1: "v3", 2: "v2", 3: "v1"} {
+		data, err := os.ReadFile(backupPath(path, index))
+		if err != nil {
+			t.Fatalf("backup %d: %v", index, err)
+		}
+		if string(data) != want {
+			t.Errorf("backup %d = %q, want %q", index, data, want)
+		}
+	}
+	if _, err := os.Stat(backupPath(path, 4)); !os.IsNotExist(err) {
+		t.Errorf("backup 4 exists, want at most %d backups", MaxBackups)
+	}
+}
+
+func TestReplaceVaultFileMismatchKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.gpv")
+	first, err := replaceVaultFile(path, []byte("v0"), storage.NoVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replaceVaultFile(path, []byte("v1"), first); err != nil {
+		t.Fatal(err)
+	}
+
+	// A stale version must neither write nor rotate, however often it is retried
+	for i := 0; i < maxSaveAttempts; i++ {
+		_, err := replaceVaultFile(path, []byte("stale"), first)
+		if !errors.Is(err, storage.ErrVersionMismatch) {
+			t.Fatalf("stale write: got %v, want ErrVersionMismatch", err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "v1" {
+		t.Errorf("vault = %q, want v1", data)
+	}
+	backups, err := ListBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("got %d backups, want 1", len(backups))
+	}
+	if data, _ := os.ReadFile(backups[0].Path); string(data) != "v0" {
+		t.Errorf("backup 1 = %q, want v0", data)
+	}
+}
+
+func TestReplaceVaultFileNoVersionRejectsExisting(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vault.gpv")
+	if _, err := replaceVaultFile(path, []byte("v0"), storage.NoVersion); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := replaceVaultFile(path, []byte("v1"), storage.NoVersion); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Fatalf("got %v, want ErrVersionMismatch", err)
+	}
+}
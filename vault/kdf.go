@@ -2,46 +2,110 @@ package vault
 
 import (
 	"fmt"
-	"log"
 
-	"github.com/alexedwards/argon2id"
+	"github.com/egemengunel/Go-Password-Manager/crypto"
 )
 
-var Parameters = &argon2id.Params{
+// Supported key derivation algorithms for the vault data key
+const (
+	KDFArgon2id = "argon2id"
+	KDFPBKDF2   = "pbkdf2-sha256"
+)
+
+// Upper bounds for KDF parameters read from a vault file, so a crafted
+// file cannot make unlocking exhaust memory or CPU
+const (
+	maxKDFMemory      = 4 * 1024 * 1024 // 4 GiB in KiB
+	maxKDFIterations  = 100
+	maxPBKDF2Rounds   = 10000000
+	minKDFSaltLength  = 16
+	maxKDFParallelism = 64
+)
+
+// DefaultKDF holds the cost parameters used for new vaults and when upgrading old ones
+var DefaultKDF = KDFParams{
+	Algorithm:   KDFArgon2id,
 	Memory:      64 * 1024,
 	Iterations:  3,
-	Parallelism: 2,
-	SaltLength:  16,
-	KeyLength:   32,
-}
-
-// Hash Master Password takes users master password to enter the app and returns an encoded argon2id string
-func HashMasterPassword(password string) (encodedHash string, err error) {
-	return argon2id.CreateHash(password, Parameters)
+	Parallelism: 4,
 }
 
-func VerifyMasterPassword(password, encodedHash string) (match bool, err error) {
-	return argon2id.ComparePasswordAndHash(password, encodedHash)
+// KDFParams describes how the vault data key is derived from the master password
+type KDFParams struct {
+	Algorithm   string `json:"algorithm"`
+	Memory      uint32 `json:"memory,omitempty"` // KiB, Argon2id only
+	Iterations  uint32 `json:"iterations"`
+	Parallelism uint8  `json:"parallelism,omitempty"` // Argon2id only
+	Salt        []byte `json:"salt"`
 }
 
-// this is a test func to see how the package works
-func PasswordHashCheck() {
-	password := "hunter2"
-	hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+// NewKDFParams returns the default KDF parameters with a fresh random salt
+func NewKDFParams() (*KDFParams, error) {
+	salt, err := crypto.GenerateSalt()
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
 	}
-	fmt.Println("Hashed Password:", hash)
 
-	passwordToCheck := []string{"hunter1", "hunter2", "hunter3"}
+	params := DefaultKDF
+	params.Salt = salt
+	return &params, nil
+}
 
-	for _, password := range passwordToCheck {
-		match, err := argon2id.ComparePasswordAndHash(password, hash)
+// legacyKDFParams describes the PBKDF2 derivation used by vaults without a KDF header
+func legacyKDFParams(salt []byte) *KDFParams {
+	return &KDFParams{
+		Algorithm:  KDFPBKDF2,
+		Iterations: crypto.LegacyPBKDF2Iterations,
+		Salt:       salt,
+	}
+}
+
+// Validate checks that the parameters are supported and within sane bounds
+func (p *KDFParams) Validate() error {
+	if len(p.Salt) < minKDFSaltLength {
+		return fmt.Errorf("kdf salt too short")
+	}
 
-		if err != nil {
-			log.Fatal(err)
+	switch p.Algorithm {
+	case KDFArgon2id:
+		if p.Memory == 0 || p.Memory > maxKDFMemory {
+			return fmt.Errorf("argon2id memory out of range: %d KiB", p.Memory)
 		}
-		fmt.Printf("\"%s\":\t%v\n", password, match)
+		if p.Iterations == 0 || p.Iterations > maxKDFIterations {
+			return fmt.Errorf("argon2id iterations out of range: %d", p.Iterations)
+		}
+		if p.Parallelism == 0 || p.Parallelism > maxKDFParallelism {
+			return fmt.Errorf("argon2id parallelism out of range: %d", p.Parallelism)
+		}
+	case KDFPBKDF2:
+		if p.Iterations == 0 || p.Iterations > maxPBKDF2Rounds {
+			return fmt.Errorf("pbkdf2 iterations out of range: %d", p.Iterations)
+		}
+	default:
+		return fmt.Errorf("unsupported kdf algorithm %q", p.Algorithm)
+	}
+
+	return nil
+}
+
+// DeriveKey derives the vault data key from the master password
+func (p *KDFParams) DeriveKey(masterPassword string) ([]byte, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
 	}
 
+	switch p.Algorithm {
+	case KDFArgon2id:
+		return crypto.DeriveKeyArgon2id(masterPassword, p.Salt, p.Memory, p.Iterations, p.Parallelism), nil
+	default:
+		return crypto.DeriveKeyPBKDF2(masterPassword, p.Salt, int(p.Iterations)), nil
+	}
+}
+
+// NeedsUpgrade reports whether the parameters are weaker than DefaultKDF
+func (p *KDFParams) NeedsUpgrade() bool {
+	return p.Algorithm != DefaultKDF.Algorithm ||
+		p.Memory < DefaultKDF.Memory ||
+		p.Iterations < DefaultKDF.Iterations ||
+		p.Parallelism < DefaultKDF.Parallelism
 }
@@ -0,0 +1,143 @@
+package vault
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/crypto"
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// useFastKDF makes key derivation cheap for the duration of a test
+func useFastKDF(t *testing.T) {
+	saved := DefaultKDF
+	DefaultKDF.Memory = 1024
+	DefaultKDF.Iterations = 1
+	DefaultKDF.Parallelism = 1
+	t.Cleanup(func() { DefaultKDF = saved })
+}
+
+// legacyPasswordHash stands in for the argon2id hash old vault files stored
+// next to the data. It is never checked, only carried along.
+const legacyPasswordHash = "$argon2id$v=19$m=65536,t=3,p=2$c29tZXNhbHRzb21lc2FsdA$4xVnbQiYbcpW3X1Ji1F1KcB4BBA5uG2NGOWfHNyAGXc"
+
+// writeLegacyVault writes vault to path the way releases before the KDF
+// header did: PBKDF2 with the file salt, and a password hash alongside
+func writeLegacyVault(t *testing.T, path, masterPassword string, vault *models.Vault) {
+	t.Helper()
+
+	encKey := crypto.DeriveKey(masterPassword, nil)
+
+	plaintext, err := json.Marshal(vault)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryptedData, err := crypto.Encrypt(plaintext, encKey.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fileData, err := json.Marshal(map[string]any{
+		"version":        "1.0.0",
+		"password_hash":  legacyPasswordHash,
+		"salt":           encKey.Salt,
+		"encrypted_data": encryptedData,
+		"created_at":     vault.CreatedAt.Format(time.RFC3339),
+		"updated_at":     vault.UpdatedAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, fileData, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// legacyVaultWithEntry returns a vault holding a single entry with a current-style ID
+func legacyVaultWithEntry() (*models.Vault, *models.Entry) {
+	now := time.Now().UTC().Truncate(time.Second)
+	entry := models.NewEntry("Mail", "me", "hunter2")
+	return &models.Vault{
+		Version:   VaultVersion,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Entries:   map[string]*models.Entry{entry.ID: entry},
+		Metadata:  map[string]string{},
+	}, entry
+}
+
+func TestNeedsUpgrade(t *testing.T) {
+	salt := make([]byte, minKDFSaltLength)
+
+	tests := []struct {
+		name   string
+		modify func(p *KDFParams)
+		want   bool
+	}{
+		{"default", func(p *KDFParams) {}, false},
+		{"stronger", func(p *KDFParams) { p.Memory *= 2; p.Iterations++; p.Parallelism++ }, false},
+		{"pbkdf2", func(p *KDFParams) { *p = *legacyKDFParams(salt) }, true},
+		{"less memory", func(p *KDFParams) { p.Memory /= 2 }, true},
+		{"fewer iterations", func(p *KDFParams) { p.Iterations-- }, true},
+		{"less parallelism", func(p *KDFParams) { p.Parallelism-- }, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := DefaultKDF
+			params.Salt = salt
+			tt.modify(&params)
+
+			if got := params.NeedsUpgrade(); got != tt.want {
+				t.Errorf("NeedsUpgrade() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnlockUpgradesPBKDF2Vault(t *testing.T) {
+	useFastKDF(t)
+	path := filepath.Join(t.TempDir(), "vault.json")
+	legacy, entry := legacyVaultWithEntry()
+	writeLegacyVault(t, path, "master-password", legacy)
+
+	session, err := UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	if session.KDF.Algorithm != KDFArgon2id {
+		t.Errorf("session KDF = %q, want %q", session.KDF.Algorithm, KDFArgon2id)
+	}
+	session.Close()
+
+	vaultFile, err := ReadVaultFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vaultFile.KDF == nil {
+		t.Fatal("upgraded vault has no KDF header")
+	}
+	kdf := *vaultFile.KDF
+	if kdf.Algorithm != KDFArgon2id || kdf.Memory != DefaultKDF.Memory ||
+		kdf.Iterations != DefaultKDF.Iterations || kdf.Parallelism != DefaultKDF.Parallelism {
+		t.Errorf("upgraded KDF = %+v, want the DefaultKDF parameters", kdf)
+	}
+	if err := kdf.Validate(); err != nil {
+		t.Errorf("upgraded KDF is invalid: %v", err)
+	}
+
+	// The data must still decrypt, now with the Argon2id key
+	session, err = UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatalf("UnlockVault after upgrade: %v", err)
+	}
+	defer session.Close()
+
+	got, ok := session.Vault.Entries[entry.ID]
+	if !ok || got.Password != entry.Password {
+		t.Errorf("entry after upgrade = %+v, want %+v", got, entry)
+	}
+}
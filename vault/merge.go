@@ -0,0 +1,240 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/crypto"
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// Conflict describes an entry changed differently on both sides of a merge.
+// Local or Remote is nil when that side deleted the entry.
+type Conflict struct {
+	ID     string
+	Local  *models.Entry
+	Remote *models.Entry
+}
+
+// Title returns a human readable name for the conflicting entry
+func (c Conflict) Title() string {
+	if c.Local != nil {
+		return c.Local.Title
+	}
+	if c.Remote != nil {
+		return c.Remote.Title
+	}
+	return c.ID
+}
+
+// ConflictError is returned when a save cannot be merged automatically
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	titles := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		titles[i] = fmt.Sprintf("'%s'", conflict.Title())
+	}
+	return fmt.Sprintf("vault was modified by another process with conflicting changes to %s", strings.Join(titles, ", "))
+}
+
+// MergeVaults performs a three-way merge of entries. base is the common
+// ancestor of local and remote. Entries changed on only one side take that
+// side's version; entries changed on both sides are reported as conflicts and
+// keep the remote version in the result. Access times and breach marks are
+// not treated as changes; the most recent access time is kept and breach
+// marks are merged separately.
+func MergeVaults(base, local, remote *models.Vault) (*models.Vault, []Conflict) {
+	merged := *remote
+	merged.Entries = make(map[string]*models.Entry)
+	merged.Metadata = mergeMetadata(base.Metadata, local.Metadata, remote.Metadata)
+
+	var conflicts []Conflict
+	for id := range entryIDs(base, local, remote) {
+		b, l, r := base.Entries[id], local.Entries[id], remote.Entries[id]
+
+		var result *models.Entry
+		switch {
+		case sameContent(b, l):
+			result = r
+		case sameContent(b, r), sameContent(l, r):
+			result = l
+		default:
+			conflicts = append(conflicts, Conflict{ID: id, Local: l, Remote: r})
+			result = r
+		}
+
+		if result != nil {
+			merged.Entries[id] = mergeUsage(result, b, l, r)
+		}
+	}
+
+	// An entry restored on one side but still in the other side's trash stays restored
+	merged.Trash = mergeTrash(base.Trash, local.Trash, remote.Trash)
+	for id := range merged.Entries {
+		delete(merged.Trash, id)
+	}
+
+	return &merged, conflicts
+}
+
+// mergeTrash merges the trash item by item, preferring local changes.
+// Trashed entries are not edited, so changes never conflict.
+func mergeTrash(base, local, remote map[string]*models.TrashedEntry) map[string]*models.TrashedEntry {
+	ids := make(map[string]struct{})
+	for _, trash := range []map[string]*models.TrashedEntry{base, local, remote} {
+		for id := range trash {
+			ids[id] = struct{}{}
+		}
+	}
+
+	merged := make(map[string]*models.TrashedEntry)
+	for id := range ids {
+		b, l, r := base[id], local[id], remote[id]
+
+		result := r
+		if !trashedEqual(b, l) {
+			result = l
+		}
+		if result != nil {
+			merged[id] = result
+		}
+	}
+	return merged
+}
+
+// entryIDs returns the union of entry IDs in the given vaults
+func entryIDs(vaults ...*models.Vault) map[string]struct{} {
+	ids := make(map[string]struct{})
+	for _, vault := range vaults {
+		for id := range vault.Entries {
+			ids[id] = struct{}{}
+		}
+	}
+	return ids
+}
+
+// mergeMetadata merges vault metadata key by key, preferring local changes
+func mergeMetadata(base, local, remote map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for key, value := range remote {
+		merged[key] = value
+	}
+
+	for key := range base {
+		if _, kept := local[key]; !kept {
+			delete(merged, key)
+		}
+	}
+	for key, value := range local {
+		if base[key] != value {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// entriesEqual reports whether two entries have identical contents
+func entriesEqual(a, b *models.Entry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	aData, aErr := json.Marshal(a)
+	bData, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aData, bData)
+}
+
+// sameContent reports whether two entries are equal apart from their access
+// time and breach mark, which change without the entry being edited
+func sameContent(a, b *models.Entry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	aContent, bContent := *a, *b
+	aContent.AccessedAt, bContent.AccessedAt = time.Time{}, time.Time{}
+	aContent.Compromised, bContent.Compromised = nil, nil
+	return entriesEqual(&aContent, &bContent)
+}
+
+// mergeUsage returns a copy of result with the latest access time of local
+// and remote, and the breach mark merged by mergeCompromise
+func mergeUsage(result, base, local, remote *models.Entry) *models.Entry {
+	merged := *result
+	for _, side := range []*models.Entry{local, remote} {
+		if side != nil && side.AccessedAt.After(merged.AccessedAt) {
+			merged.AccessedAt = side.AccessedAt
+		}
+	}
+	merged.Compromised = mergeCompromise(result, base, local, remote)
+	return &merged
+}
+
+// mergeCompromise merges the breach marks of an entry against base. Setting
+// or clearing the mark on one side wins over an unchanged other side. If both
+// sides changed it, a mark wins over a cleared one and otherwise the most
+// recent check wins. Marks made for another password than result's are
+// ignored, as they no longer apply.
+func mergeCompromise(result, base, local, remote *models.Entry) *models.Compromise {
+	mark := func(entry *models.Entry) *models.Compromise {
+		if entry == nil || entry.Password != result.Password {
+			return nil
+		}
+		return entry.Compromised
+	}
+	b, l, r := mark(base), mark(local), mark(remote)
+
+	switch {
+	case compromiseEqual(l, b):
+		return r
+	case compromiseEqual(r, b):
+		return l
+	case l == nil:
+		return r
+	case r == nil:
+		return l
+	case r.CheckedAt.After(l.CheckedAt):
+		return r
+	default:
+		return l
+	}
+}
+
+// compromiseEqual reports whether two breach marks are the same
+func compromiseEqual(a, b *models.Compromise) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Count == b.Count && a.Source == b.Source && a.CheckedAt.Equal(b.CheckedAt)
+}
+
+// trashedEqual reports whether two trashed entries have identical contents
+func trashedEqual(a, b *models.TrashedEntry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.DeletedAt.Equal(b.DeletedAt) && entriesEqual(a.Entry, b.Entry)
+}
+
+// cloneVault returns a deep copy of a vault
+func cloneVault(vault *models.Vault) *models.Vault {
+	data, err := json.Marshal(vault)
+	if err != nil {
+		return nil
+	}
+
+	var clone models.Vault
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil
+	}
+	crypto.SecureZero(data)
+
+	return &clone
+}
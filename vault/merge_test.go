@@ -0,0 +1,230 @@
+package vault
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// mergeBase returns a vault with entries "a" and "b" and entry "t" in the trash
+func mergeBase() *models.Vault {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := func(id string) *models.Entry {
+		return &models.Entry{ID: id, Title: id, Password: "base", CreatedAt: created, UpdatedAt: created}
+	}
+	return &models.Vault{
+		Entries: map[string]*models.Entry{"a": entry("a"), "b": entry("b")},
+		Trash:   map[string]*models.TrashedEntry{"t": {Entry: entry("t"), DeletedAt: created}},
+	}
+}
+
+// editEntry changes the password of an entry as an edit would
+func editEntry(vault *models.Vault, id, password string) {
+	entry := vault.Entries[id]
+	entry.Password = password
+	entry.UpdatedAt = entry.UpdatedAt.Add(time.Hour)
+}
+
+// trashEntry moves an entry to the trash as TrashEntry would
+func trashEntry(vault *models.Vault, id string) {
+	vault.Trash[id] = &models.TrashedEntry{Entry: vault.Entries[id], DeletedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)}
+	delete(vault.Entries, id)
+}
+
+// restoreEntry moves an entry back from the trash as RestoreEntry would
+func restoreEntry(vault *models.Vault, id string) {
+	vault.Entries[id] = vault.Trash[id].Entry
+	delete(vault.Trash, id)
+}
+
+func TestMergeVaults(t *testing.T) {
+	tests := []struct {
+		name          string
+		local, remote func(*models.Vault)
+		entries       map[string]string // ID to password
+		trash         []string
+		conflicts     []string
+	}{
+		{
+			name:    "no changes",
+			entries: map[string]string{"a": "base", "b": "base"},
+			trash:   []string{"t"},
+		},
+		{
+			name:    "edited locally",
+			local:   func(v *models.Vault) { editEntry(v, "a", "local") },
+			entries: map[string]string{"a": "local", "b": "base"},
+			trash:   []string{"t"},
+		},
+		{
+			name:    "edited remotely",
+			remote:  func(v *models.Vault) { editEntry(v, "a", "remote") },
+			entries: map[string]string{"a": "remote", "b": "base"},
+			trash:   []string{"t"},
+		},
+		{
+			name:    "different entries edited",
+			local:   func(v *models.Vault) { editEntry(v, "a", "local") },
+			remote:  func(v *models.Vault) { editEntry(v, "b", "remote") },
+			entries: map[string]string{"a": "local", "b": "remote"},
+			trash:   []string{"t"},
+		},
+		{
+			name:    "same edit on both sides",
+			local:   func(v *models.Vault) { editEntry(v, "a", "same") },
+			remote:  func(v *models.Vault) { editEntry(v, "a", "same") },
+			entries: map[string]string{"a": "same", "b": "base"},
+			trash:   []string{"t"},
+		},
+		{
+			name:      "conflicting edits",
+			local:     func(v *models.Vault) { editEntry(v, "a", "local") },
+			remote:    func(v *models.Vault) { editEntry(v, "a", "remote") },
+			entries:   map[string]string{"a": "remote", "b": "base"},
+			trash:     []string{"t"},
+			conflicts: []string{"a"},
+		},
+		{
+			name:    "trashed locally",
+			local:   func(v *models.Vault) { trashEntry(v, "a") },
+			entries: map[string]string{"b": "base"},
+			trash:   []string{"a", "t"},
+		},
+		{
+			name:    "trashed remotely",
+			remote:  func(v *models.Vault) { trashEntry(v, "a") },
+			entries: map[string]string{"b": "base"},
+			trash:   []string{"a", "t"},
+		},
+		{
+			name:      "trashed locally and edited remotely",
+			local:     func(v *models.Vault) { trashEntry(v, "a") },
+			remote:    func(v *models.Vault) { editEntry(v, "a", "remote") },
+			entries:   map[string]string{"a": "remote", "b": "base"},
+			trash:     []string{"t"},
+			conflicts: []string{"a"},
+		},
+		{
+			name:    "restored locally",
+			local:   func(v *models.Vault) { restoreEntry(v, "t") },
+			entries: map[string]string{"a": "base", "b": "base", "t": "base"},
+		},
+		{
+			name:    "restored remotely",
+			remote:  func(v *models.Vault) { restoreEntry(v, "t") },
+			entries: map[string]string{"a": "base", "b": "base", "t": "base"},
+		},
+		{
+			name:    "restored locally and trashed again remotely",
+			local:   func(v *models.Vault) { restoreEntry(v, "t") },
+			remote:  func(v *models.Vault) { restoreEntry(v, "t"); trashEntry(v, "t") },
+			entries: map[string]string{"a": "base", "b": "base", "t": "base"},
+		},
+		{
+			name:    "purged locally",
+			local:   func(v *models.Vault) { delete(v.Trash, "t") },
+			entries: map[string]string{"a": "base", "b": "base"},
+		},
+		{
+			name: "added on both sides",
+			local: func(v *models.Vault) {
+				v.Entries["l"] = &models.Entry{ID: "l", Title: "l", Password: "local"}
+			},
+			remote: func(v *models.Vault) {
+				v.Entries["r"] = &models.Entry{ID: "r", Title: "r", Password: "remote"}
+			},
+			entries: map[string]string{"a": "base", "b": "base", "l": "local", "r": "remote"},
+			trash:   []string{"t"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base, local, remote := mergeBase(), mergeBase(), mergeBase()
+			if test.local != nil {
+				test.local(local)
+			}
+			if test.remote != nil {
+				test.remote(remote)
+			}
+
+			merged, conflicts := MergeVaults(base, local, remote)
+
+			var conflictIDs []string
+			for _, conflict := range conflicts {
+				conflictIDs = append(conflictIDs, conflict.ID)
+			}
+			if !slices.Equal(conflictIDs, test.conflicts) {
+				t.Errorf("conflicts = %v, want %v", conflictIDs, test.conflicts)
+			}
+
+			entries := make(map[string]string)
+			for id, entry := range merged.Entries {
+				entries[id] = entry.Password
+			}
+			if !maps.Equal(entries, test.entries) {
+				t.Errorf("entries = %v, want %v", entries, test.entries)
+			}
+
+			var trash []string
+			for id := range merged.Trash {
+				trash = append(trash, id)
+			}
+			slices.Sort(trash)
+			if !slices.Equal(trash, test.trash) {
+				t.Errorf("trash = %v, want %v", trash, test.trash)
+			}
+		})
+	}
+}
+
+func TestMergeVaultsBreachMarks(t *testing.T) {
+	checked := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	older := &models.Compromise{Count: 3, Source: "online", CheckedAt: checked}
+	newer := &models.Compromise{Count: 5, Source: "online", CheckedAt: checked.Add(time.Hour)}
+
+	tests := []struct {
+		name                string
+		base, local, remote *models.Compromise
+		localPassword       string // "" keeps the base password
+		want                *models.Compromise
+	}{
+		{name: "unchanged", base: older, local: older, remote: older, want: older},
+		{name: "marked locally", local: newer, want: newer},
+		{name: "marked remotely", remote: newer, want: newer},
+		{name: "cleared locally", base: older, remote: older, want: nil},
+		{name: "cleared remotely", base: older, local: older, want: nil},
+		{name: "rechecked on both sides", base: older, local: older, remote: newer, want: newer},
+		{name: "marked on both sides", local: older, remote: newer, want: newer},
+		{name: "cleared and marked", base: older, local: nil, remote: newer, want: newer},
+		{name: "password changed", remote: newer, localPassword: "changed", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entry := models.NewEntry("Mail", "me", "secret")
+			vaults := make([]*models.Vault, 3)
+			for i, mark := range []*models.Compromise{test.base, test.local, test.remote} {
+				copied := *entry
+				copied.Compromised = mark
+				vaults[i] = &models.Vault{Entries: map[string]*models.Entry{entry.ID: &copied}}
+			}
+			if test.localPassword != "" {
+				local := vaults[1].Entries[entry.ID]
+				local.Password = test.localPassword
+				local.UpdatedAt = local.UpdatedAt.Add(time.Minute)
+			}
+
+			merged, conflicts := MergeVaults(vaults[0], vaults[1], vaults[2])
+			if len(conflicts) != 0 {
+				t.Fatalf("got %d conflicts, want none", len(conflicts))
+			}
+			if got := merged.Entries[entry.ID].Compromised; !compromiseEqual(got, test.want) {
+				t.Errorf("breach mark = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
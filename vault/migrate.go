@@ -0,0 +1,47 @@
+package vault
+
+import (
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// migrateVault upgrades vault data written by older versions in place.
+// It reports whether anything changed so the caller can persist the result.
+func migrateVault(vault *models.Vault) bool {
+	changed := migrateLegacyIDs(vault)
+	changed = migrateVaultID(vault) || changed
+	return changed
+}
+
+// migrateVaultID gives vaults created before vault IDs existed an identifier,
+// which the file header binds to the encrypted payload
+func migrateVaultID(vault *models.Vault) bool {
+	if vault.ID != "" {
+		return false
+	}
+
+	vault.ID = models.NewID()
+	return true
+}
+
+// migrateLegacyIDs re-keys entries that still use timestamp based IDs,
+// which could collide when two entries were added in the same second. The new
+// IDs are derived from the old ones, so devices that migrate the same vault,
+// or sync decrypting a legacy base version, agree on them.
+func migrateLegacyIDs(vault *models.Vault) bool {
+	var legacy []string
+	for id := range vault.Entries {
+		if models.IsLegacyID(id) {
+			legacy = append(legacy, id)
+		}
+	}
+
+	for _, oldID := range legacy {
+		entry := vault.Entries[oldID]
+		delete(vault.Entries, oldID)
+
+		entry.ID = models.MigratedID(oldID)
+		vault.Entries[entry.ID] = entry
+	}
+
+	return len(legacy) > 0
+}
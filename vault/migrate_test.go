@@ -0,0 +1,104 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// legacyVault returns a vault as written before random entry IDs existed
+func legacyVault() *models.Vault {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &models.Vault{
+		Version: "1.0",
+		Entries: map[string]*models.Entry{
+			"20240301120000_entry": {ID: "20240301120000_entry", Title: "Mail", Username: "me", Password: "one", CreatedAt: created, UpdatedAt: created},
+			"20240301120005_entry": {ID: "20240301120005_entry", Title: "Bank", Username: "me", Password: "two", CreatedAt: created, UpdatedAt: created},
+		},
+	}
+}
+
+func TestMigrateLegacyIDsIsDeterministic(t *testing.T) {
+	first, second := legacyVault(), legacyVault()
+	if !migrateLegacyIDs(first) || !migrateLegacyIDs(second) {
+		t.Fatal("legacy IDs were not migrated")
+	}
+
+	for id, entry := range first.Entries {
+		if models.IsLegacyID(id) {
+			t.Errorf("entry %q kept its legacy ID", entry.Title)
+		}
+		if entry.ID != id {
+			t.Errorf("entry %q has ID %q but is stored under %q", entry.Title, entry.ID, id)
+		}
+		if other, ok := second.Entries[id]; !ok || other.Title != entry.Title {
+			t.Errorf("entry %q got a different ID on the second migration", entry.Title)
+		}
+	}
+}
+
+func TestSeparateMigrationsAgree(t *testing.T) {
+	// Two devices migrating the same legacy vault must end up with the same IDs
+	local, remote := legacyVault(), legacyVault()
+	migrateLegacyIDs(local)
+	migrateLegacyIDs(remote)
+
+	if len(local.Entries) != len(remote.Entries) {
+		t.Fatalf("got %d and %d entries", len(local.Entries), len(remote.Entries))
+	}
+	for id := range local.Entries {
+		if _, ok := remote.Entries[id]; !ok {
+			t.Errorf("entry %s was given a different ID on the other device", id)
+		}
+	}
+}
+
+func TestMergeOfSeparatelyMigratedVaults(t *testing.T) {
+	// Two devices migrate the same legacy vault, one of them then edits an entry
+	base, local, remote := legacyVault(), legacyVault(), legacyVault()
+	migrateLegacyIDs(base)
+	migrateLegacyIDs(local)
+	migrateLegacyIDs(remote)
+
+	edited := remote.Entries[models.MigratedID("20240301120000_entry")]
+	edited.Password = "changed"
+	edited.UpdatedAt = edited.UpdatedAt.Add(time.Hour)
+
+	merged, conflicts := MergeVaults(base, local, remote)
+	if len(conflicts) != 0 {
+		t.Fatalf("got %d conflicts, want none", len(conflicts))
+	}
+	if len(merged.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(merged.Entries))
+	}
+	if got := merged.Entries[edited.ID].Password; got != "changed" {
+		t.Errorf("merged password = %q, want the remote edit", got)
+	}
+}
+
+func TestMigratedIDFormat(t *testing.T) {
+	id := models.MigratedID("20240301120000_entry")
+	if len(id) != 36 || id[14] != '5' {
+		t.Errorf("MigratedID = %q, want a version 5 UUID", id)
+	}
+	if id != models.MigratedID("20240301120000_entry") {
+		t.Error("MigratedID is not deterministic")
+	}
+	if id == models.MigratedID("20240301120001_entry") {
+		t.Error("different legacy IDs map to the same ID")
+	}
+}
+
+func TestMigrateVaultKeepsCustomTOTPField(t *testing.T) {
+	entry := models.NewEntry("Bank", "me", "secret")
+	entry.Custom = map[string]string{"totp": "JBSWY3DPEHPK3PXP"}
+	vault := &models.Vault{ID: models.NewID(), Entries: map[string]*models.Entry{entry.ID: entry}}
+
+	if migrateVault(vault) {
+		t.Error("migrateVault changed a current vault")
+	}
+	if entry.OTP != "" || entry.Custom["totp"] != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("custom totp field was rewritten: OTP %q, custom %v", entry.OTP, entry.Custom)
+	}
+}
@@ -0,0 +1,169 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/egemengunel/Go-Password-Manager/crypto"
+	"github.com/egemengunel/Go-Password-Manager/storage"
+)
+
+var (
+	// ErrBackupsNotRekeyed is returned by Rekey when the master password was
+	// changed but some backups could not be re-encrypted
+	ErrBackupsNotRekeyed = errors.New("master password changed, but backups could not be re-encrypted")
+
+	// errOtherPassword marks a backup whose key check block shows it was made
+	// under a different master password
+	errOtherPassword = errors.New("backup was made under a different master password")
+)
+
+// Rekey changes the master password of the vault at path. It verifies the old
+// password, derives a new key from a fresh salt, re-encrypts the vault and
+// atomically replaces the file, so a crash leaves either the old or the new
+// vault in storage. The backups are then re-encrypted with the new key too, so
+// the old password no longer opens any copy of the vault. If another process
+// writes the vault in the meantime nothing is written and ErrVaultChanged is
+// returned.
+func Rekey(path, oldPassword, newPassword string) error {
+	vaultFile, version, err := readVaultFile(path)
+	if err != nil {
+		return err
+	}
+
+	// Verify the old password by opening the vault with it
+	oldKDF := vaultFile.kdfParams()
+	oldKey, err := oldKDF.DeriveKey(oldPassword)
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+	defer crypto.SecureZero(oldKey)
+	vault, _, err := loadVault(vaultFile, oldKey)
+	if err != nil {
+		return err
+	}
+
+	kdf, err := NewKDFParams()
+	if err != nil {
+		return err
+	}
+	key, err := kdf.DeriveKey(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+	defer crypto.SecureZero(key)
+
+	vault.Revision++
+	fileData, err := encodeVaultFile(vault, key, kdf)
+	if err != nil {
+		return err
+	}
+
+	written, err := writeVaultData(path, fileData, version)
+	if written == "" {
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			return ErrVaultChanged
+		}
+		return err
+	}
+
+	// err may report that backing up the replaced file failed
+	if backupErr := reencryptBackups(path, oldPassword, oldKDF, oldKey, key, kdf); err == nil {
+		err = backupErr
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBackupsNotRekeyed, err)
+	}
+	return nil
+}
+
+// reencryptBackups re-encrypts the backups of the vault at path that open with
+// oldPassword under key. Backups whose key check block proves they were made
+// under a different password are deleted, as that password may be the reason
+// for the change. Backups that cannot be read, parsed or decrypted for any
+// other reason are left alone and reported. oldKey is the key derived with
+// oldKDF, reused for backups with the same parameters.
+func reencryptBackups(path, oldPassword string, oldKDF *KDFParams, oldKey, key []byte, kdf *KDFParams) error {
+	store, name, err := storage.Open(path)
+	if err != nil {
+		return err
+	}
+	backups, err := listBackups(store, name)
+	if err != nil {
+		return fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var errs []error
+	for _, backup := range backups {
+		objectName := backupName(name, backup.Index)
+		data, version, err := store.Read(objectName)
+		if errors.Is(err, storage.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read backup %d: %w", backup.Index, err))
+			continue
+		}
+
+		sealed, err := reencryptBackup(data, oldPassword, oldKDF, oldKey, key, kdf)
+		if errors.Is(err, errOtherPassword) {
+			if err := store.Remove(objectName); err != nil && !errors.Is(err, storage.ErrNotExist) {
+				errs = append(errs, fmt.Errorf("failed to delete backup %d: %w", backup.Index, err))
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("backup %d: %w", backup.Index, err))
+			continue
+		}
+
+		if _, err := store.Write(objectName, sealed, version); err != nil {
+			errs = append(errs, fmt.Errorf("failed to re-encrypt backup %d: %w", backup.Index, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// reencryptBackup opens backup data with the old password and seals it with
+// key, keeping its timestamps. It returns errOtherPassword only when the
+// backup's key check block rejects the old password.
+func reencryptBackup(data []byte, oldPassword string, oldKDF *KDFParams, oldKey, key []byte, kdf *KDFParams) ([]byte, error) {
+	vaultFile, err := ParseVaultFile(data)
+	if err != nil {
+		return nil, err
+	}
+
+	backupKDF := vaultFile.kdfParams()
+	backupKey := oldKey
+	if !sameKDF(backupKDF, oldKDF) {
+		backupKey, err = backupKDF.DeriveKey(oldPassword)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key: %w", err)
+		}
+		defer crypto.SecureZero(backupKey)
+	}
+
+	// Without a key check block a failed decryption could just as well be a
+	// damaged file, so only a rejected key check counts as another password
+	if err := verifyKey(vaultFile, backupKey); err != nil {
+		if errors.Is(err, ErrInvalidPassword) {
+			return nil, errOtherPassword
+		}
+		return nil, err
+	}
+
+	vault, err := decryptVaultFile(vaultFile, backupKey)
+	if err != nil {
+		return nil, err
+	}
+	migrateVault(vault)
+
+	return sealVaultFile(vault, key, kdf)
+}
+
+// sameKDF reports whether two parameter sets derive the same key
+func sameKDF(a, b *KDFParams) bool {
+	return a.Algorithm == b.Algorithm && a.Memory == b.Memory && a.Iterations == b.Iterations &&
+		a.Parallelism == b.Parallelism && bytes.Equal(a.Salt, b.Salt)
+}
@@ -0,0 +1,241 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+func TestRekey(t *testing.T) {
+	path := newTestVault(t, "first-password")
+
+	session, err := UnlockVault("first-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := models.NewEntry("Mail", "me", "hunter2")
+	if err := session.AddEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Save(); err != nil {
+		t.Fatal(err)
+	}
+	oldSalt := session.KDF.Salt
+	session.Close()
+
+	if err := Rekey(path, "first-password", "second-password"); err != nil {
+		t.Fatalf("Rekey: %v", err)
+	}
+
+	if _, err := OpenVault("first-password", path); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("old password gave %v, want ErrInvalidPassword", err)
+	}
+
+	session, err = UnlockVault("second-password", path)
+	if err != nil {
+		t.Fatalf("new password: %v", err)
+	}
+	defer session.Close()
+
+	if bytes.Equal(session.KDF.Salt, oldSalt) {
+		t.Error("Rekey kept the old salt")
+	}
+	if got, ok := session.Vault.Entries[entry.ID]; !ok || got.Password != entry.Password {
+		t.Errorf("entry after rekey = %+v, want %+v", got, entry)
+	}
+}
+
+func TestRekeyRejectsWrongPassword(t *testing.T) {
+	path := newTestVault(t, "first-password")
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Rekey(path, "wrong-password", "second-password"); !errors.Is(err, ErrInvalidPassword) {
+		t.Fatalf("Rekey with wrong password = %v, want ErrInvalidPassword", err)
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("failed Rekey changed the vault file")
+	}
+}
+
+// saveVersions saves the vault at path once per title, adding an entry each
+// time, so the earlier versions end up in the backups
+func saveVersions(t *testing.T, path, masterPassword string, titles ...string) {
+	t.Helper()
+
+	session, err := UnlockVault(masterPassword, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	for _, title := range titles {
+		if err := session.AddEntry(models.NewEntry(title, "user", "secret")); err != nil {
+			t.Fatal(err)
+		}
+		if err := session.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRekeyReencryptsBackups(t *testing.T) {
+	path := newTestVault(t, "first-password")
+	saveVersions(t, path, "first-password", "one", "two")
+
+	if err := Rekey(path, "first-password", "second-password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Rekey(path, "second-password", "third-password"); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := ListBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) < 3 {
+		t.Fatalf("got %d backups, want at least 3", len(backups))
+	}
+
+	for _, backup := range backups {
+		for _, old := range []string{"first-password", "second-password"} {
+			if _, err := OpenVault(old, backup.Path); !errors.Is(err, ErrInvalidPassword) {
+				t.Errorf("backup %d: old password %q gave %v, want ErrInvalidPassword", backup.Index, old, err)
+			}
+		}
+		if _, err := OpenVault("third-password", backup.Path); err != nil {
+			t.Errorf("backup %d: new password: %v", backup.Index, err)
+		}
+	}
+
+	vault, err := OpenVault("third-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vault.Entries) != 2 {
+		t.Errorf("got %d entries, want 2", len(vault.Entries))
+	}
+}
+
+func TestRekeyDeletesBackupsOfOtherPasswords(t *testing.T) {
+	path := newTestVault(t, "first-password")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Rekey(path, "first-password", "second-password"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A backup that only the first password opens
+	stale := backupPath(path, 4)
+	if err := os.WriteFile(stale, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Rekey(path, "second-password", "third-password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("backup of the first password was kept: %v", err)
+	}
+	backups, err := ListBackups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, backup := range backups {
+		if _, err := OpenVault("third-password", backup.Path); err != nil {
+			t.Errorf("backup %d does not open with the new password: %v", backup.Index, err)
+		}
+	}
+}
+
+func TestRekeyKeepsUnreadableBackups(t *testing.T) {
+	path := newTestVault(t, "first-password")
+	saveVersions(t, path, "first-password", "one")
+
+	vaultFile, err := ReadVaultFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A backup without a key check block under another password cannot be
+	// told apart from a damaged one
+	legacyPath := filepath.Join(t.TempDir(), "legacy.json")
+	legacy, _ := legacyVaultWithEntry()
+	writeLegacyVault(t, legacyPath, "other-password", legacy)
+	legacyData, err := os.ReadFile(legacyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	damaged := *vaultFile
+	damaged.EncryptedData = append([]byte(nil), vaultFile.EncryptedData...)
+	damaged.EncryptedData[len(damaged.EncryptedData)-1] ^= 0xff
+	badKDF := *vaultFile
+	badKDF.KDF = &KDFParams{Algorithm: "scrypt", Iterations: 1, Salt: vaultFile.KDF.Salt}
+
+	unreadable := map[int][]byte{
+		2: []byte("not a vault"),
+		3: mustMarshal(t, &damaged),
+		4: mustMarshal(t, &badKDF),
+		5: legacyData,
+	}
+	for index, data := range unreadable {
+		if err := os.WriteFile(backupPath(path, index), data, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Rotation would push the test backups out, so keep them in place
+	saved := MaxBackups
+	MaxBackups = 0
+	defer func() { MaxBackups = saved }()
+
+	err = Rekey(path, "first-password", "second-password")
+	if !errors.Is(err, ErrBackupsNotRekeyed) {
+		t.Fatalf("Rekey = %v, want ErrBackupsNotRekeyed", err)
+	}
+
+	for index, want := range unreadable {
+		got, err := os.ReadFile(backupPath(path, index))
+		if err != nil {
+			t.Errorf("backup %d was removed: %v", index, err)
+			continue
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("backup %d was modified", index)
+		}
+	}
+
+	// The readable backup was still re-encrypted
+	if _, err := OpenVault("second-password", backupPath(path, 1)); err != nil {
+		t.Errorf("backup 1 does not open with the new password: %v", err)
+	}
+	if _, err := OpenVault("second-password", path); err != nil {
+		t.Errorf("vault does not open with the new password: %v", err)
+	}
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -1,12 +1,16 @@
 package vault
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/egemengunel/Go-Password-Manager/crypto"
 	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/storage"
 )
 
 // Session represents an active vault session
@@ -14,30 +18,41 @@ type Session struct {
 	Vault          *models.Vault
 	VaultPath      string
 	EncryptionKey  []byte
-	PasswordHash   string
+	KDF            *KDFParams
+	Base           *models.Vault // vault as last read from or written to storage
 	LastAccessed   time.Time
 	SessionTimeout time.Duration
 	mutex          sync.RWMutex
 }
 
+// minIDPrefixLength is the shortest ID prefix accepted when looking up entries
+const minIDPrefixLength = 4
+
+// ErrAmbiguousID is returned when an ID prefix matches more than one entry
+var ErrAmbiguousID = errors.New("ambiguous entry ID prefix")
+
 var (
 	currentSession *Session
 	sessionMutex   sync.RWMutex
 )
 
-// StartSession creates a new vault session
-func StartSession(vault *models.Vault, vaultPath string, masterPassword string, passwordHash string) {
+// ActivateSession registers an unlocked session as the current session
+func ActivateSession(session *Session) {
 	sessionMutex.Lock()
 	defer sessionMutex.Unlock()
 
-	// Derive encryption key
-	encKey := crypto.DeriveKey(masterPassword, vault.Salt)
+	currentSession = session
+}
 
-	currentSession = &Session{
+// NewSession creates a standalone session that is not registered as the current session.
+// It is used by long-running processes such as the unlock agent.
+func NewSession(vault *models.Vault, vaultPath string, key []byte, kdf *KDFParams) *Session {
+	return &Session{
 		Vault:          vault,
 		VaultPath:      vaultPath,
-		EncryptionKey:  encKey.Key,
-		PasswordHash:   passwordHash,
+		EncryptionKey:  key,
+		KDF:            kdf,
+		Base:           cloneVault(vault),
 		LastAccessed:   time.Now(),
 		SessionTimeout: 15 * time.Minute, // Default 15 minute timeout
 	}
@@ -45,22 +60,23 @@ func StartSession(vault *models.Vault, vaultPath string, masterPassword string,
 
 // GetSession returns the current active session
 func GetSession() *Session {
-	sessionMutex.RLock()
-	defer sessionMutex.RUnlock()
+	sessionMutex.Lock()
+	defer sessionMutex.Unlock()
 
 	if currentSession == nil {
 		return nil
 	}
 
 	// Check if session has expired
-	if time.Since(currentSession.LastAccessed) > currentSession.SessionTimeout {
+	if currentSession.Expired() {
 		// Session expired, clear it
-		ClearSession()
+		currentSession.Close()
+		currentSession = nil
 		return nil
 	}
 
 	// Update last accessed time
-	currentSession.LastAccessed = time.Now()
+	currentSession.Touch()
 	return currentSession
 }
 
@@ -71,34 +87,178 @@ func ClearSession() {
 
 	if currentSession != nil {
 		// Clear sensitive data from memory
-		crypto.SecureZero(currentSession.EncryptionKey)
+		currentSession.Close()
 		currentSession = nil
 	}
 }
 
+// Touch records activity on the session, postponing its expiry
+func (s *Session) Touch() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.LastAccessed = time.Now()
+}
+
+// Expired reports whether the session has been idle for longer than its timeout
+func (s *Session) Expired() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return time.Since(s.LastAccessed) > s.SessionTimeout
+}
+
+// ExpiresAt returns the time at which the session will expire if left idle
+func (s *Session) ExpiresAt() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.LastAccessed.Add(s.SessionTimeout)
+}
+
+// Close wipes the session's key material. The session must not be used afterwards.
+func (s *Session) Close() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	crypto.SecureZero(s.EncryptionKey)
+	s.EncryptionKey = nil
+	s.Vault = nil
+	s.Base = nil
+}
+
 // IsSessionActive checks if there's an active session
 func IsSessionActive() bool {
 	return GetSession() != nil
 }
 
-// SaveCurrentSession saves the current session's vault to disk
+// SaveCurrentSession saves the current session's vault to its storage
 func SaveCurrentSession() error {
 	session := GetSession()
 	if session == nil {
 		return fmt.Errorf("no active session")
 	}
 
-	session.mutex.Lock()
-	defer session.mutex.Unlock()
+	return session.Save()
+}
+
+// maxSaveAttempts bounds how often Save retries when another process writes
+// the vault between reading and writing it
+const maxSaveAttempts = 3
 
-	return SaveVault(session.Vault, session.VaultPath, session.EncryptionKey, session.PasswordHash)
+// Save writes the session's vault to its storage with a conditional write.
+// If another process saved since this session read the vault, entry changes
+// that do not overlap are merged; overlapping changes fail with a *ConflictError
+// and nothing is written.
+func (s *Session) Save() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		err := s.save()
+		if errors.Is(err, storage.ErrVersionMismatch) && attempt < maxSaveAttempts {
+			continue
+		}
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			return fmt.Errorf("vault kept changing while saving, please retry: %w", err)
+		}
+		return err
+	}
+}
+
+// save makes one attempt at merging and writing the session's vault
+func (s *Session) save() error {
+	// Read what is stored now
+	vaultFile, version, err := readVaultFile(s.VaultPath)
+	if err != nil {
+		return err
+	}
+	if err := verifyKey(vaultFile, s.EncryptionKey); err != nil {
+		return fmt.Errorf("vault was re-encrypted by another process, please retry")
+	}
+	remote, err := decryptVaultFile(vaultFile, s.EncryptionKey)
+	if err != nil {
+		return err
+	}
+
+	// Someone else saved in the meantime: merge at entry granularity
+	result := s.Vault
+	if remote.Revision != s.Base.Revision {
+		merged, conflicts := MergeVaults(s.Base, s.Vault, remote)
+		if len(conflicts) > 0 {
+			return &ConflictError{Conflicts: conflicts}
+		}
+		result = merged
+	}
+
+	purgeExpiredTrash(result, time.Now())
+	result.Revision = remote.Revision + 1
+	fileData, err := encodeVaultFile(result, s.EncryptionKey, s.KDF)
+	if err != nil {
+		return err
+	}
+	written, err := writeVaultData(s.VaultPath, fileData, version)
+	if written == "" {
+		return err
+	}
+
+	s.Vault = result
+	s.Base = cloneVault(result)
+	s.LastAccessed = time.Now()
+	return err
 }
 
-// AddEntryToSession adds an entry to the current session
+// commitUpgrade writes an in-memory upgrade of the session's vault (a data
+// migration or a new key) to storage, but only if the file has not changed
+// since loaded was read. On success the session switches to key and kdf. It
+// reports whether the file was written; if not, the upgrade is retried on the
+// next unlock.
+func (s *Session) commitUpgrade(loaded *VaultFile, key []byte, kdf *KDFParams) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	current, version, err := readVaultFile(s.VaultPath)
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(current.EncryptedData, loaded.EncryptedData) {
+		return false, nil
+	}
+
+	s.Vault.Revision++
+	fileData, err := encodeVaultFile(s.Vault, key, kdf)
+	written := ""
+	if err == nil {
+		written, err = writeVaultData(s.VaultPath, fileData, version)
+	}
+	if written == "" {
+		s.Vault.Revision--
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if !bytes.Equal(key, s.EncryptionKey) {
+		crypto.SecureZero(s.EncryptionKey)
+		s.EncryptionKey = key
+		s.KDF = kdf
+	}
+	s.Base = cloneVault(s.Vault)
+	return true, err
+}
+
+// AddEntry adds a new entry to the session. It fails if the vault or its
+// trash already holds an entry with the same ID.
 func (s *Session) AddEntry(entry *models.Entry) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	_, exists := s.Vault.Entries[entry.ID]
+	if _, trashed := s.Vault.Trash[entry.ID]; exists || trashed {
+		return fmt.Errorf("an entry with ID %s already exists", entry.ID)
+	}
+
 	if s.Vault.Entries == nil {
 		s.Vault.Entries = make(map[string]*models.Entry)
 	}
@@ -110,8 +270,8 @@ func (s *Session) AddEntry(entry *models.Entry) error {
 
 // GetEntryFromSession retrieves an entry from the current session
 func (s *Session) GetEntry(id string) (*models.Entry, error) {
-	s.mutex.RLock()
-	defer s.mutex.RUnlock()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
 
 	entry, exists := s.Vault.Entries[id]
 	if !exists {
@@ -122,11 +282,48 @@ func (s *Session) GetEntry(id string) (*models.Entry, error) {
 	return entry, nil
 }
 
-// ListEntriesFromSession returns all entries from the current session
-func (s *Session) ListEntries() []*models.Entry {
+// FindEntry retrieves an entry by its full ID or by an unambiguous ID prefix
+func (s *Session) FindEntry(idOrPrefix string) (*models.Entry, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	return findByID(s.Vault.Entries, idOrPrefix)
+}
+
+// findByID looks up an item by its full ID or by an unambiguous ID prefix
+func findByID[T any](items map[string]T, idOrPrefix string) (T, error) {
+	var zero T
+	if item, exists := items[idOrPrefix]; exists {
+		return item, nil
+	}
+
+	if len(idOrPrefix) < minIDPrefixLength {
+		return zero, fmt.Errorf("entry not found")
+	}
+
+	prefix := strings.ToLower(idOrPrefix)
+	var matches []T
+	for id, item := range items {
+		if strings.HasPrefix(strings.ToLower(id), prefix) {
+			matches = append(matches, item)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return zero, fmt.Errorf("entry not found")
+	case 1:
+		return matches[0], nil
+	default:
+		return zero, fmt.Errorf("%w: %q matches %d entries", ErrAmbiguousID, idOrPrefix, len(matches))
+	}
+}
+
+// ListEntriesFromSession returns all entries from the current session
+func (s *Session) ListEntries() []*models.Entry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	entries := make([]*models.Entry, 0, len(s.Vault.Entries))
 	for _, entry := range s.Vault.Entries {
 		entries = append(entries, entry)
@@ -151,7 +348,55 @@ func (s *Session) UpdateEntry(entry *models.Entry) error {
 	return nil
 }
 
-// DeleteEntryFromSession removes an entry from the current session
+// SetOTP replaces an entry's one-time password URI without changing its
+// UpdatedAt, so advancing an HOTP counter does not count as an edit
+func (s *Session) SetOTP(id, uri string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.Vault.Entries[id]
+	if !exists {
+		return fmt.Errorf("entry not found")
+	}
+
+	entry.OTP = uri
+	s.LastAccessed = time.Now()
+	return nil
+}
+
+// MarkAccessed records that an entry was viewed without changing its UpdatedAt
+func (s *Session) MarkAccessed(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.Vault.Entries[id]
+	if !exists {
+		return fmt.Errorf("entry not found")
+	}
+
+	entry.AccessedAt = time.Now()
+	s.LastAccessed = time.Now()
+	return nil
+}
+
+// SetCompromised records or, with a nil mark, clears an entry's breach
+// status without changing its UpdatedAt
+func (s *Session) SetCompromised(id string, mark *models.Compromise) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.Vault.Entries[id]
+	if !exists {
+		return fmt.Errorf("entry not found")
+	}
+
+	entry.Compromised = mark
+	s.LastAccessed = time.Now()
+	return nil
+}
+
+// DeleteEntryFromSession permanently removes an entry from the current session.
+// Use TrashEntry to delete it recoverably.
 func (s *Session) DeleteEntry(id string) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
@@ -0,0 +1,318 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/storage"
+)
+
+func TestSaveMergesConcurrentSessions(t *testing.T) {
+	path := newTestVault(t, "master-password")
+	saveVersions(t, path, "master-password", "shared")
+
+	first, err := UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	added := models.NewEntry("Added", "me", "secret")
+	if err := first.AddEntry(added); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Save(); err != nil {
+		t.Fatalf("first Save: %v", err)
+	}
+
+	// The second session never saw the first one's entry
+	shared := second.ListEntries()[0]
+	edited := *shared
+	edited.Password = "changed"
+	if err := second.UpdateEntry(&edited); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(); err != nil {
+		t.Fatalf("second Save: %v", err)
+	}
+
+	vault, err := OpenVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := vault.Entries[added.ID]; !ok {
+		t.Error("entry added by the first session was lost")
+	}
+	if got := vault.Entries[shared.ID].Password; got != "changed" {
+		t.Errorf("edited password = %q, want %q", got, "changed")
+	}
+}
+
+func TestSaveReportsConflicts(t *testing.T) {
+	path := newTestVault(t, "master-password")
+	saveVersions(t, path, "master-password", "shared")
+
+	sessions := make([]*Session, 2)
+	for i := range sessions {
+		session, err := UnlockVault("master-password", path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer session.Close()
+		sessions[i] = session
+	}
+
+	for i, password := range []string{"first", "second"} {
+		entry := *sessions[i].ListEntries()[0]
+		entry.Password = password
+		if err := sessions[i].UpdateEntry(&entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sessions[0].Save(); err != nil {
+		t.Fatalf("first Save: %v", err)
+	}
+
+	var conflictErr *ConflictError
+	if err := sessions[1].Save(); !errors.As(err, &conflictErr) {
+		t.Fatalf("second Save = %v, want a *ConflictError", err)
+	}
+	if len(conflictErr.Conflicts) != 1 {
+		t.Errorf("got %d conflicts, want 1", len(conflictErr.Conflicts))
+	}
+
+	vault, err := OpenVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range vault.Entries {
+		if entry.Password != "first" {
+			t.Errorf("password = %q, want the first session's save to stand", entry.Password)
+		}
+	}
+}
+
+// davServer is an in-memory WebDAV collection honoring If-Match and
+// If-None-Match, without locking or listing. beforePut, if set, runs before
+// a conditional write is checked, to simulate another writer.
+type davServer struct {
+	mutex     sync.Mutex
+	objects   map[string][]byte
+	versions  map[string]string
+	writes    int
+	beforePut func(name string)
+}
+
+// put stores an object under a new version; the caller holds the mutex
+func (d *davServer) put(name string, data []byte) {
+	d.writes++
+	d.objects[name] = data
+	d.versions[name] = fmt.Sprintf(`"%d"`, d.writes)
+}
+
+func (d *davServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	data, exists := d.objects[name]
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("ETag", d.versions[name])
+		w.Write(data)
+	case http.MethodPut:
+		ifMatch := r.Header.Get("If-Match")
+		if ifMatch != "" && d.beforePut != nil {
+			d.beforePut(name)
+			data, exists = d.objects[name]
+		}
+		if (ifMatch != "" && (!exists || ifMatch != d.versions[name])) || (r.Header.Get("If-None-Match") == "*" && exists) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		d.put(name, body)
+		w.Header().Set("ETag", d.versions[name])
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// newDAVVault creates a vault on a davServer and returns the server and the
+// vault's location. Backups are disabled, as the server cannot list them.
+func newDAVVault(t *testing.T) (*davServer, string) {
+	useFastKDF(t)
+	saved := MaxBackups
+	MaxBackups = 0
+	t.Cleanup(func() { MaxBackups = saved })
+
+	dav := &davServer{objects: make(map[string][]byte), versions: make(map[string]string)}
+	server := httptest.NewServer(dav)
+	t.Cleanup(server.Close)
+
+	location := "webdav://" + strings.TrimPrefix(server.URL, "http://") + "/vault.gpv"
+	if err := CreateVault("password", location); err != nil {
+		t.Fatal(err)
+	}
+	return dav, location
+}
+
+func TestSaveMergesWriteBetweenReadAndWrite(t *testing.T) {
+	dav, location := newDAVVault(t)
+
+	local, err := UnlockVault("password", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+	other, err := UnlockVault("password", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+
+	// Prepare another process's save, then undo it until local is about to write
+	before := dav.objects["vault.gpv"]
+	if err := other.AddEntry(models.NewEntry("Other", "me", "secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Save(); err != nil {
+		t.Fatal(err)
+	}
+	concurrent := dav.objects["vault.gpv"]
+	dav.put("vault.gpv", before)
+
+	interrupted := 0
+	dav.beforePut = func(name string) {
+		if interrupted == 0 {
+			dav.put(name, concurrent)
+		}
+		interrupted++
+	}
+
+	if err := local.AddEntry(models.NewEntry("Local", "me", "secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := local.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if interrupted != 2 {
+		t.Errorf("got %d conditional writes, want a rejected one and a retry", interrupted)
+	}
+
+	vault, err := OpenVault("password", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := make(map[string]bool)
+	for _, entry := range vault.Entries {
+		titles[entry.Title] = true
+	}
+	if len(vault.Entries) != 2 || !titles["Local"] || !titles["Other"] {
+		t.Errorf("saved entries %v, want Local and Other", titles)
+	}
+}
+
+func TestSaveGivesUpWhenVaultKeepsChanging(t *testing.T) {
+	dav, location := newDAVVault(t)
+
+	session, err := UnlockVault("password", location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	attempts := 0
+	dav.beforePut = func(name string) {
+		attempts++
+		dav.put(name, dav.objects[name])
+	}
+
+	if err := session.AddEntry(models.NewEntry("Local", "me", "secret")); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Save(); !errors.Is(err, storage.ErrVersionMismatch) {
+		t.Fatalf("Save returned %v, want ErrVersionMismatch", err)
+	}
+	if attempts != maxSaveAttempts {
+		t.Errorf("got %d attempts, want %d", attempts, maxSaveAttempts)
+	}
+}
+
+func TestAddEntryRejectsExistingID(t *testing.T) {
+	entry := models.NewEntry("Mail", "me", "secret")
+	trashed := models.NewEntry("Bank", "me", "secret")
+	session := NewSession(&models.Vault{
+		Entries: map[string]*models.Entry{entry.ID: entry},
+		Trash:   map[string]*models.TrashedEntry{trashed.ID: {Entry: trashed}},
+	}, "", nil, nil)
+
+	for _, existing := range []*models.Entry{entry, trashed} {
+		duplicate := models.NewEntry("Other", "me", "other")
+		duplicate.ID = existing.ID
+		if err := session.AddEntry(duplicate); err == nil {
+			t.Errorf("adding a second entry with ID %s succeeded", existing.ID)
+		}
+	}
+	if session.Vault.Entries[entry.ID] != entry {
+		t.Error("existing entry was replaced")
+	}
+
+	if err := session.AddEntry(models.NewEntry("New", "me", "secret")); err != nil {
+		t.Errorf("adding a new entry: %v", err)
+	}
+}
+
+func TestSetOTPKeepsUpdatedAt(t *testing.T) {
+	path := newTestVault(t, "master-password")
+	saveVersions(t, path, "master-password", "Counter")
+
+	session, err := UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	entry := session.ListEntries()[0]
+	updated := entry.UpdatedAt
+	uri := "otpauth://hotp/Counter?secret=JBSWY3DPEHPK3PXP&counter=5"
+	if err := session.SetOTP(entry.ID, uri); err != nil {
+		t.Fatalf("SetOTP: %v", err)
+	}
+	if err := session.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	vault, err := OpenVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := vault.Entries[entry.ID]
+	if got.OTP != uri {
+		t.Errorf("OTP = %q, want %q", got.OTP, uri)
+	}
+	if !got.UpdatedAt.Equal(updated) {
+		t.Errorf("UpdatedAt changed from %v to %v", updated, got.UpdatedAt)
+	}
+
+	if err := session.SetOTP(models.NewID(), uri); err == nil {
+		t.Error("SetOTP on a missing entry succeeded")
+	}
+}
@@ -0,0 +1,90 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/storage"
+)
+
+// ErrVaultChanged is returned by InstallVaultFile when the vault file no
+// longer holds the contents the caller based its changes on
+var ErrVaultChanged = errors.New("vault was modified by another process")
+
+// ParseVaultFile parses serialized vault file data without decrypting it
+func ParseVaultFile(data []byte) (*VaultFile, error) {
+	var vaultFile VaultFile
+	if err := json.Unmarshal(data, &vaultFile); err != nil {
+		return nil, fmt.Errorf("failed to parse vault file: %w", err)
+	}
+
+	return &vaultFile, nil
+}
+
+// KeyParams returns how the file's key is derived from the master password
+func (f *VaultFile) KeyParams() *KDFParams {
+	return f.kdfParams()
+}
+
+// Decrypt decrypts the vault with key, migrating data written by older versions
+func (f *VaultFile) Decrypt(key []byte) (*models.Vault, error) {
+	vault, _, err := loadVault(f, key)
+	return vault, err
+}
+
+// Equal reports whether both parameters derive the same key from a password
+func (p *KDFParams) Equal(other *KDFParams) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return p.Algorithm == other.Algorithm &&
+		p.Memory == other.Memory &&
+		p.Iterations == other.Iterations &&
+		p.Parallelism == other.Parallelism &&
+		bytes.Equal(p.Salt, other.Salt)
+}
+
+// EncodeVault encrypts a vault and returns the serialized vault file
+func EncodeVault(vault *models.Vault, key []byte, kdf *KDFParams) ([]byte, error) {
+	return encodeVaultFile(vault, key, kdf)
+}
+
+// InstallVaultFile replaces the vault file at path with data produced
+// elsewhere, such as a version received by sync. expected is the file
+// content the data is based on, or nil if no vault should exist yet. If the
+// file changed in the meantime nothing is written and ErrVaultChanged is
+// returned.
+func InstallVaultFile(path string, expected, data []byte) error {
+	if _, err := ParseVaultFile(data); err != nil {
+		return err
+	}
+
+	store, name, err := storage.Open(path)
+	if err != nil {
+		return err
+	}
+
+	current, version, err := store.Read(name)
+	switch {
+	case errors.Is(err, storage.ErrNotExist):
+		if expected != nil {
+			return ErrVaultChanged
+		}
+		version = storage.NoVersion
+	case err != nil:
+		return fmt.Errorf("failed to read vault file: %w", err)
+	case expected == nil || !bytes.Equal(current, expected):
+		return ErrVaultChanged
+	}
+
+	if _, err := writeVaultData(path, data, version); err != nil {
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			return ErrVaultChanged
+		}
+		return err
+	}
+	return nil
+}
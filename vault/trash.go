@@ -0,0 +1,116 @@
+package vault
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// TrashRetention is how long deleted entries stay in the trash. Expired
+// entries are purged when the vault is saved. Zero keeps them until purged manually.
+var TrashRetention = 30 * 24 * time.Hour
+
+// TrashEntry moves an entry from the vault to its trash
+func (s *Session) TrashEntry(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, exists := s.Vault.Entries[id]
+	if !exists {
+		return fmt.Errorf("entry not found")
+	}
+
+	if s.Vault.Trash == nil {
+		s.Vault.Trash = make(map[string]*models.TrashedEntry)
+	}
+	s.Vault.Trash[id] = &models.TrashedEntry{Entry: entry, DeletedAt: time.Now()}
+	delete(s.Vault.Entries, id)
+	s.LastAccessed = time.Now()
+	return nil
+}
+
+// ListTrash returns the entries in the trash
+func (s *Session) ListTrash() []*models.TrashedEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	trashed := make([]*models.TrashedEntry, 0, len(s.Vault.Trash))
+	for _, item := range s.Vault.Trash {
+		trashed = append(trashed, item)
+	}
+
+	s.LastAccessed = time.Now()
+	return trashed
+}
+
+// FindTrashedEntry retrieves a trashed entry by its full ID or by an unambiguous ID prefix
+func (s *Session) FindTrashedEntry(idOrPrefix string) (*models.TrashedEntry, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return findByID(s.Vault.Trash, idOrPrefix)
+}
+
+// RestoreEntry moves an entry from the trash back into the vault
+func (s *Session) RestoreEntry(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	item, exists := s.Vault.Trash[id]
+	if !exists {
+		return fmt.Errorf("entry not found in trash")
+	}
+	if _, exists := s.Vault.Entries[id]; exists {
+		return fmt.Errorf("an entry with ID %s already exists", id)
+	}
+
+	if s.Vault.Entries == nil {
+		s.Vault.Entries = make(map[string]*models.Entry)
+	}
+	s.Vault.Entries[id] = item.Entry
+	delete(s.Vault.Trash, id)
+	s.LastAccessed = time.Now()
+	return nil
+}
+
+// PurgeEntry permanently removes an entry from the trash
+func (s *Session) PurgeEntry(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.Vault.Trash[id]; !exists {
+		return fmt.Errorf("entry not found in trash")
+	}
+
+	delete(s.Vault.Trash, id)
+	s.LastAccessed = time.Now()
+	return nil
+}
+
+// EmptyTrash permanently removes every entry from the trash and returns how many were removed
+func (s *Session) EmptyTrash() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := len(s.Vault.Trash)
+	s.Vault.Trash = nil
+	s.LastAccessed = time.Now()
+	return count
+}
+
+// purgeExpiredTrash removes trashed entries older than TrashRetention
+func purgeExpiredTrash(vault *models.Vault, now time.Time) int {
+	if TrashRetention <= 0 {
+		return 0
+	}
+
+	purged := 0
+	for id, item := range vault.Trash {
+		if now.After(item.DeletedAt.Add(TrashRetention)) {
+			delete(vault.Trash, id)
+			purged++
+		}
+	}
+	return purged
+}
@@ -0,0 +1,206 @@
+package vault
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// newTrashSession returns a session over an in-memory vault holding entries
+func newTrashSession(entries ...*models.Entry) *Session {
+	vault := &models.Vault{Entries: map[string]*models.Entry{}}
+	for _, entry := range entries {
+		vault.Entries[entry.ID] = entry
+	}
+	return NewSession(vault, "", nil, nil)
+}
+
+func TestTrashAndRestoreEntry(t *testing.T) {
+	entry := models.NewEntry("Mail", "me", "secret")
+	session := newTrashSession(entry)
+
+	if err := session.TrashEntry(entry.ID); err != nil {
+		t.Fatalf("TrashEntry: %v", err)
+	}
+	if _, err := session.GetEntry(entry.ID); err == nil {
+		t.Error("trashed entry is still in the vault")
+	}
+	trashed := session.ListTrash()
+	if len(trashed) != 1 || trashed[0].Entry != entry || trashed[0].DeletedAt.IsZero() {
+		t.Fatalf("trash = %+v, want the entry with a deletion time", trashed)
+	}
+	if found, err := session.FindTrashedEntry(entry.ID[:minIDPrefixLength]); err != nil || found.Entry != entry {
+		t.Errorf("FindTrashedEntry by prefix = %v, %v", found, err)
+	}
+	if err := session.TrashEntry(entry.ID); err == nil {
+		t.Error("trashing an entry twice succeeded")
+	}
+
+	if err := session.RestoreEntry(entry.ID); err != nil {
+		t.Fatalf("RestoreEntry: %v", err)
+	}
+	if got, err := session.GetEntry(entry.ID); err != nil || got != entry {
+		t.Errorf("restored entry = %v, %v", got, err)
+	}
+	if len(session.ListTrash()) != 0 {
+		t.Error("restored entry is still in the trash")
+	}
+	if err := session.RestoreEntry(entry.ID); err == nil {
+		t.Error("restoring an entry that is not in the trash succeeded")
+	}
+}
+
+func TestRestoreEntryKeepsNewerEntry(t *testing.T) {
+	entry := models.NewEntry("Mail", "me", "secret")
+	session := newTrashSession(entry)
+	if err := session.TrashEntry(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry with the same ID came back in the meantime, e.g. by a merge
+	replacement := *entry
+	replacement.Password = "newer"
+	session.Vault.Entries[entry.ID] = &replacement
+
+	if err := session.RestoreEntry(entry.ID); err == nil {
+		t.Fatal("restore replaced an existing entry")
+	}
+	if session.Vault.Entries[entry.ID].Password != "newer" {
+		t.Error("existing entry was overwritten")
+	}
+	if len(session.ListTrash()) != 1 {
+		t.Error("failed restore removed the entry from the trash")
+	}
+}
+
+func TestPurgeEntryAndEmptyTrash(t *testing.T) {
+	first := models.NewEntry("Mail", "me", "secret")
+	second := models.NewEntry("Bank", "me", "secret")
+	third := models.NewEntry("Shop", "me", "secret")
+	session := newTrashSession(first, second, third)
+	for _, entry := range []*models.Entry{first, second, third} {
+		if err := session.TrashEntry(entry.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := session.PurgeEntry(first.ID); err != nil {
+		t.Fatalf("PurgeEntry: %v", err)
+	}
+	if _, err := session.FindTrashedEntry(first.ID); err == nil {
+		t.Error("purged entry is still in the trash")
+	}
+	if err := session.RestoreEntry(first.ID); err == nil {
+		t.Error("purged entry could be restored")
+	}
+	if err := session.PurgeEntry(first.ID); err == nil {
+		t.Error("purging an entry twice succeeded")
+	}
+
+	if count := session.EmptyTrash(); count != 2 {
+		t.Errorf("EmptyTrash removed %d entries, want 2", count)
+	}
+	if len(session.ListTrash()) != 0 || len(session.ListEntries()) != 0 {
+		t.Error("entries are left after emptying the trash")
+	}
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	saved := TrashRetention
+	TrashRetention = 30 * 24 * time.Hour
+	defer func() { TrashRetention = saved }()
+
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+	trashedAt := func(age time.Duration) *models.TrashedEntry {
+		return &models.TrashedEntry{Entry: models.NewEntry("Old", "me", "secret"), DeletedAt: now.Add(-age)}
+	}
+	newVault := func() *models.Vault {
+		return &models.Vault{Trash: map[string]*models.TrashedEntry{
+			"expired":  trashedAt(31 * 24 * time.Hour),
+			"boundary": trashedAt(30 * 24 * time.Hour),
+			"recent":   trashedAt(time.Hour),
+		}}
+	}
+
+	vault := newVault()
+	if purged := purgeExpiredTrash(vault, now); purged != 1 {
+		t.Errorf("purged %d entries, want 1", purged)
+	}
+	if _, ok := vault.Trash["expired"]; ok {
+		t.Error("expired entry was kept")
+	}
+	for _, id := range []string{"boundary", "recent"} {
+		if _, ok := vault.Trash[id]; !ok {
+			t.Errorf("entry %q was purged before it expired", id)
+		}
+	}
+
+	// A retention of zero keeps everything until purged manually
+	TrashRetention = 0
+	vault = newVault()
+	if purged := purgeExpiredTrash(vault, now); purged != 0 || len(vault.Trash) != 3 {
+		t.Errorf("purged %d entries without retention, want none", purged)
+	}
+}
+
+func TestSavePurgesExpiredTrash(t *testing.T) {
+	path := newTestVault(t, "master-password")
+	saveVersions(t, path, "master-password", "Old", "Recent")
+
+	session, err := UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	ids := map[string]string{}
+	for _, entry := range session.ListEntries() {
+		ids[entry.Title] = entry.ID
+		if err := session.TrashEntry(entry.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	session.Vault.Trash[ids["Old"]].DeletedAt = time.Now().Add(-TrashRetention - time.Hour)
+
+	if err := session.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	vault, err := OpenVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := vault.Trash[ids["Old"]]; ok {
+		t.Error("expired entry was saved")
+	}
+	if _, ok := vault.Trash[ids["Recent"]]; !ok {
+		t.Error("recently trashed entry was purged")
+	}
+}
+
+// Run with -race: reading entries counts as activity and must not write
+// LastAccessed under a read lock
+func TestConcurrentReadsRecordActivity(t *testing.T) {
+	entry := models.NewEntry("Mail", "me", "secret")
+	session := newTrashSession(entry, models.NewEntry("Bank", "me", "secret"))
+	if err := session.TrashEntry(entry.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				session.ListEntries()
+				session.ListTrash()
+				session.GetEntry(entry.ID)
+				session.Expired()
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -1,94 +1,334 @@
 package vault
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 	"time"
 
 	"github.com/egemengunel/Go-Password-Manager/crypto"
 	"github.com/egemengunel/Go-Password-Manager/models"
+	"github.com/egemengunel/Go-Password-Manager/storage"
 )
 
 const VaultVersion = "1.0.0"
 
+// FormatVersion is the layout version of the vault file. Format 2 binds the
+// plaintext header into the AES-GCM associated data of the payload.
+const FormatVersion = 2
+
+// headerADPrefix domain-separates the header associated data
+const headerADPrefix = "gopassman-vault-header:"
+
+// keyCheckPlaintext is sealed into VaultFile.KeyCheck. Successfully opening it
+// proves the derived key is correct without storing anything derived from the
+// master password outside the AEAD.
+var keyCheckPlaintext = []byte("gopassman key check")
+
+var (
+	// ErrInvalidPassword is returned when the master password does not open the vault
+	ErrInvalidPassword = errors.New("invalid master password")
+	// ErrHeaderTampered is returned when the key is correct but the header does not
+	// match the one the payload was encrypted with
+	ErrHeaderTampered = errors.New("vault header tampered")
+)
+
 // VaultFile represents the encrypted vault file structure
 type VaultFile struct {
-	Version       string `json:"version"`
-	PasswordHash  string `json:"password_hash"`
-	Salt          []byte `json:"salt"`
-	EncryptedData []byte `json:"encrypted_data"`
-	CreatedAt     string `json:"created_at"`
-	UpdatedAt     string `json:"updated_at"`
+	Format        int        `json:"format,omitempty"`
+	Version       string     `json:"version"`
+	VaultID       string     `json:"vault_id,omitempty"`
+	PasswordHash  string     `json:"password_hash,omitempty"` // only read from old vaults, never written
+	KDF           *KDFParams `json:"kdf,omitempty"`
+	Salt          []byte     `json:"salt,omitempty"` // PBKDF2 salt of vaults without a KDF header
+	KeyCheck      []byte     `json:"key_check,omitempty"`
+	EncryptedData []byte     `json:"encrypted_data"`
+	CreatedAt     string     `json:"created_at"`
+	UpdatedAt     string     `json:"updated_at"`
 }
 
-// CreateVault creates a new encrypted vault file
-func CreateVault(masterPassword, path string) error {
-	// Check if vault already exists
-	if _, err := os.Stat(path); err == nil {
-		return fmt.Errorf("vault already exists at %s", path)
+// headerAD returns the associated data that binds the plaintext header
+// fields to the encrypted payload
+func (f *VaultFile) headerAD() ([]byte, error) {
+	header := struct {
+		Format    int        `json:"format"`
+		Version   string     `json:"version"`
+		VaultID   string     `json:"vault_id"`
+		KDF       *KDFParams `json:"kdf"`
+		CreatedAt string     `json:"created_at"`
+		UpdatedAt string     `json:"updated_at"`
+	}{
+		Format:    f.Format,
+		Version:   f.Version,
+		VaultID:   f.VaultID,
+		KDF:       f.KDF,
+		CreatedAt: f.CreatedAt,
+		UpdatedAt: f.UpdatedAt,
 	}
 
-	// Hash the master password
-	passwordHash, err := HashMasterPassword(masterPassword)
+	data, err := json.Marshal(header)
 	if err != nil {
-		return fmt.Errorf("failed to hash master password: %w", err)
+		return nil, fmt.Errorf("failed to marshal vault header: %w", err)
+	}
+
+	return append([]byte(headerADPrefix), data...), nil
+}
+
+// kdfParams returns the KDF descriptor of the file, falling back to the
+// legacy PBKDF2 derivation for files written before the descriptor existed
+func (f *VaultFile) kdfParams() *KDFParams {
+	if f.KDF != nil {
+		return f.KDF
+	}
+	return legacyKDFParams(f.Salt)
+}
+
+// CreateVault creates a new encrypted vault file. path is a local path or a
+// storage URL, see storage.Open.
+func CreateVault(masterPassword, path string) error {
+	// Check if vault already exists
+	if _, err := StatVault(path); err == nil {
+		return fmt.Errorf("vault already exists at %s", storage.Redact(path))
 	}
 
 	// Derive encryption key
-	encKey := crypto.DeriveKey(masterPassword, nil)
+	kdf, err := NewKDFParams()
+	if err != nil {
+		return err
+	}
+	key, err := kdf.DeriveKey(masterPassword)
+	if err != nil {
+		return fmt.Errorf("failed to derive key: %w", err)
+	}
+	defer crypto.SecureZero(key)
 
 	// Create new vault structure
 	vault := &models.Vault{
+		ID:        models.NewID(),
 		Version:   VaultVersion,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-		Salt:      encKey.Salt,
 		Entries:   make(map[string]*models.Entry),
 		Metadata:  make(map[string]string),
 	}
 
-	// Save the vault
-	return SaveVault(vault, path, encKey.Key, passwordHash)
+	// Save the vault, unless another process created one in the meantime
+	fileData, err := encodeVaultFile(vault, key, kdf)
+	if err != nil {
+		return err
+	}
+	if _, err := writeVaultData(path, fileData, storage.NoVersion); err != nil {
+		if errors.Is(err, storage.ErrVersionMismatch) {
+			return fmt.Errorf("vault already exists at %s", storage.Redact(path))
+		}
+		return err
+	}
+	return nil
 }
 
 // OpenVault opens and decrypts an existing vault
 func OpenVault(masterPassword, path string) (*models.Vault, error) {
-	// Check if vault exists
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return nil, fmt.Errorf("vault not found at %s", path)
+	session, err := UnlockVault(masterPassword, path)
+	if err != nil {
+		return nil, err
+	}
+
+	vault := session.Vault
+	crypto.SecureZero(session.EncryptionKey)
+
+	return vault, nil
+}
+
+// UnlockVault derives the key from the master password, decrypts the vault and
+// returns a session holding the key. The password is only validated by the
+// key check block and the authenticated encryption of the vault.
+func UnlockVault(masterPassword, path string) (*Session, error) {
+	vaultFile, err := ReadVaultFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Derive decryption key
+	kdf := vaultFile.kdfParams()
+	key, err := kdf.DeriveKey(masterPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
 	}
 
-	// Read vault file
-	data, err := os.ReadFile(path)
+	vault, needsSave, err := loadVault(vaultFile, key)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read vault file: %w", err)
+		crypto.SecureZero(key)
+		return nil, err
 	}
 
-	// Parse vault file
-	var vaultFile VaultFile
-	if err := json.Unmarshal(data, &vaultFile); err != nil {
-		return nil, fmt.Errorf("failed to parse vault file: %w", err)
+	session := NewSession(vault, path, key, kdf)
+
+	// Re-encrypt vaults still using an outdated key derivation
+	if kdf.NeedsUpgrade() {
+		if err := upgradeKDF(session, vaultFile, masterPassword); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("failed to upgrade key derivation: %w", err)
+		}
+	} else if needsSave {
+		if _, err := session.commitUpgrade(vaultFile, key, kdf); err != nil {
+			session.Close()
+			return nil, fmt.Errorf("failed to save migrated vault: %w", err)
+		}
 	}
 
-	// Verify master password
-	match, err := VerifyMasterPassword(masterPassword, vaultFile.PasswordHash)
+	return session, nil
+}
+
+// upgradeKDF re-encrypts the session's vault with a key derived using DefaultKDF
+func upgradeKDF(session *Session, loaded *VaultFile, masterPassword string) error {
+	kdf, err := NewKDFParams()
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify password: %w", err)
+		return err
 	}
-	if !match {
-		return nil, fmt.Errorf("invalid master password")
+	key, err := kdf.DeriveKey(masterPassword)
+	if err != nil {
+		return err
 	}
 
-	// Derive decryption key
-	encKey := crypto.DeriveKey(masterPassword, vaultFile.Salt)
+	// The session keeps the key once the upgrade was written, even if
+	// backing up the replaced file failed
+	written, err := session.commitUpgrade(loaded, key, kdf)
+	if !written {
+		crypto.SecureZero(key)
+	}
+	return err
+}
+
+// OpenSessionWithKey decrypts the vault with an already derived encryption key,
+// such as one handed out by the unlock agent, and returns a session for it
+func OpenSessionWithKey(key []byte, path string) (*Session, error) {
+	vaultFile, err := ReadVaultFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	vault, needsSave, err := loadVault(vaultFile, key)
+	if err != nil {
+		return nil, err
+	}
+
+	session := NewSession(vault, path, key, vaultFile.kdfParams())
+
+	if needsSave {
+		if _, err := session.commitUpgrade(vaultFile, key, session.KDF); err != nil {
+			return nil, fmt.Errorf("failed to save migrated vault: %w", err)
+		}
+	}
+
+	return session, nil
+}
+
+// ReadVaultFile reads and parses the vault file without decrypting it
+func ReadVaultFile(path string) (*VaultFile, error) {
+	vaultFile, _, err := readVaultFile(path)
+	return vaultFile, err
+}
+
+// readVaultFile reads and parses the vault file and returns its storage version
+func readVaultFile(path string) (*VaultFile, string, error) {
+	data, version, err := readVaultData(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	vaultFile, err := ParseVaultFile(data)
+	if err != nil {
+		return nil, "", err
+	}
+	return vaultFile, version, nil
+}
+
+// ReadVaultData returns the serialized vault file at path
+func ReadVaultData(path string) ([]byte, error) {
+	data, _, err := readVaultData(path)
+	return data, err
+}
+
+// readVaultData returns the serialized vault file at path and its storage version
+func readVaultData(path string) ([]byte, string, error) {
+	store, name, err := storage.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	data, version, err := store.Read(name)
+	if errors.Is(err, storage.ErrNotExist) {
+		return nil, "", fmt.Errorf("vault not found at %s", storage.Redact(path))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read vault file: %w", err)
+	}
+	return data, version, nil
+}
+
+// loadVault decrypts a vault file and migrates data written by older versions
+// in memory. It reports whether the result differs from the file and should be saved.
+func loadVault(vaultFile *VaultFile, key []byte) (*models.Vault, bool, error) {
+	if err := verifyKey(vaultFile, key); err != nil {
+		return nil, false, err
+	}
+
+	vault, err := decryptVaultFile(vaultFile, key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Old files carry a password hash instead of a key check block and do not
+	// authenticate their header; re-saving upgrades them to the current format
+	legacyFile := vaultFile.Format < FormatVersion
+
+	migrated := migrateVault(vault)
+	return vault, migrated || legacyFile, nil
+}
+
+// verifyKey checks the key against the file's key check block
+func verifyKey(vaultFile *VaultFile, key []byte) error {
+	// Old vaults have no key check block; decrypting the payload authenticates the key instead
+	if vaultFile.KeyCheck == nil {
+		return nil
+	}
+
+	plaintext, err := crypto.Decrypt(vaultFile.KeyCheck, key)
+	if err != nil || !bytes.Equal(plaintext, keyCheckPlaintext) {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
+
+// decryptVaultFile decrypts and parses the encrypted payload of a vault file
+func decryptVaultFile(vaultFile *VaultFile, key []byte) (*models.Vault, error) {
+	if vaultFile.Format > FormatVersion {
+		return nil, fmt.Errorf("unsupported vault format %d, please upgrade gopassman", vaultFile.Format)
+	}
+
+	// Files before format 2 were encrypted without associated data
+	var additionalData []byte
+	if vaultFile.Format >= FormatVersion {
+		var err error
+		additionalData, err = vaultFile.headerAD()
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// Decrypt vault data
-	decryptedData, err := crypto.Decrypt(vaultFile.EncryptedData, encKey.Key)
+	decryptedData, err := crypto.DecryptWithAD(vaultFile.EncryptedData, key, additionalData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt vault: %w", err)
+		// Without a key check block a failure most likely means a wrong password.
+		// With one, the key is known to be right, so the header must have changed.
+		if vaultFile.KeyCheck == nil {
+			return nil, ErrInvalidPassword
+		}
+		return nil, ErrHeaderTampered
 	}
+	defer crypto.SecureZero(decryptedData)
 
 	// Parse decrypted vault
 	var vault models.Vault
@@ -99,58 +339,89 @@ func OpenVault(masterPassword, path string) (*models.Vault, error) {
 	return &vault, nil
 }
 
-// SaveVault encrypts and saves a vault to disk
-func SaveVault(vault *models.Vault, path string, key []byte, passwordHash string) error {
+// writeVaultData replaces the vault file with data if its storage version
+// matches ifVersion and backs up the previous version. A mismatch is returned
+// as storage.ErrVersionMismatch. A non-empty version means data was written,
+// even if the backup failed and an error is returned too.
+func writeVaultData(path string, data []byte, ifVersion string) (string, error) {
+	version, err := replaceVaultFile(path, data, ifVersion)
+	if errors.Is(err, storage.ErrVersionMismatch) || version != "" {
+		return version, err
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write vault file: %w", err)
+	}
+	return version, nil
+}
+
+// encodeVaultFile encrypts a vault and returns the serialized vault file
+func encodeVaultFile(vault *models.Vault, key []byte, kdf *KDFParams) ([]byte, error) {
 	// Update timestamp
 	vault.UpdatedAt = time.Now()
 
+	return sealVaultFile(vault, key, kdf)
+}
+
+// sealVaultFile encrypts a vault as is and returns the serialized vault file
+func sealVaultFile(vault *models.Vault, key []byte, kdf *KDFParams) ([]byte, error) {
 	// Marshal vault to JSON
 	vaultData, err := json.Marshal(vault)
 	if err != nil {
-		return fmt.Errorf("failed to marshal vault: %w", err)
+		return nil, fmt.Errorf("failed to marshal vault: %w", err)
 	}
+	// Clear sensitive data from memory. The key belongs to the caller and is left intact.
+	defer crypto.SecureZero(vaultData)
 
-	// Encrypt vault data
-	encryptedData, err := crypto.Encrypt(vaultData, key)
+	// Seal the key check block
+	keyCheck, err := crypto.Encrypt(keyCheckPlaintext, key)
 	if err != nil {
-		return fmt.Errorf("failed to encrypt vault: %w", err)
+		return nil, fmt.Errorf("failed to encrypt key check: %w", err)
 	}
 
 	// Create vault file structure
 	vaultFile := VaultFile{
-		Version:       VaultVersion,
-		PasswordHash:  passwordHash,
-		Salt:          vault.Salt,
-		EncryptedData: encryptedData,
-		CreatedAt:     vault.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:     vault.UpdatedAt.Format(time.RFC3339),
+		Format:    FormatVersion,
+		Version:   VaultVersion,
+		VaultID:   vault.ID,
+		KDF:       kdf,
+		KeyCheck:  keyCheck,
+		CreatedAt: vault.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: vault.UpdatedAt.Format(time.RFC3339),
 	}
 
-	// Marshal vault file
-	fileData, err := json.MarshalIndent(vaultFile, "", "  ")
+	// Encrypt vault data, authenticating the header alongside it
+	additionalData, err := vaultFile.headerAD()
 	if err != nil {
-		return fmt.Errorf("failed to marshal vault file: %w", err)
+		return nil, err
 	}
-
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
-		return fmt.Errorf("failed to create vault directory: %w", err)
+	vaultFile.EncryptedData, err = crypto.EncryptWithAD(vaultData, key, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt vault: %w", err)
 	}
 
-	// Write to file with secure permissions
-	if err := os.WriteFile(path, fileData, 0600); err != nil {
-		return fmt.Errorf("failed to write vault file: %w", err)
+	// Marshal vault file
+	fileData, err := json.MarshalIndent(vaultFile, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal vault file: %w", err)
 	}
 
-	// Clear sensitive data from memory
-	crypto.SecureZero(vaultData)
-	crypto.SecureZero(key)
-
-	return nil
+	return fileData, nil
 }
 
-// VaultExists checks if a vault file exists at the given path
+// VaultExists checks if a vault file exists at the given path or storage URL.
+// Storage that cannot be reached counts as existing, so reading the vault
+// reports why.
 func VaultExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
+	_, err := StatVault(path)
+	return !errors.Is(err, storage.ErrNotExist)
+}
+
+// StatVault describes the vault file at the given path or storage URL. It
+// returns storage.ErrNotExist if there is none.
+func StatVault(path string) (storage.ObjectInfo, error) {
+	store, name, err := storage.Open(path)
+	if err != nil {
+		return storage.ObjectInfo{}, err
+	}
+	return store.Stat(name)
 }
@@ -0,0 +1,232 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/egemengunel/Go-Password-Manager/models"
+)
+
+// newTestVault creates a vault protected by masterPassword in a temporary directory
+func newTestVault(t *testing.T, masterPassword string) string {
+	t.Helper()
+	useFastKDF(t)
+
+	path := filepath.Join(t.TempDir(), "vault.json")
+	if err := CreateVault(masterPassword, path); err != nil {
+		t.Fatalf("CreateVault: %v", err)
+	}
+	return path
+}
+
+// readRawVaultFile returns the top-level JSON fields of the vault file
+func readRawVaultFile(t *testing.T, path string) map[string]json.RawMessage {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	return fields
+}
+
+func TestWrongPasswordFailsKeyCheck(t *testing.T) {
+	path := newTestVault(t, "master-password")
+
+	vaultFile, err := ReadVaultFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vaultFile.KeyCheck == nil {
+		t.Fatal("new vault has no key check block")
+	}
+
+	key, err := vaultFile.KDF.DeriveKey("wrong-password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyKey(vaultFile, key); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("verifyKey with wrong key = %v, want ErrInvalidPassword", err)
+	}
+
+	// Break the payload: a wrong password must still be reported by the key
+	// check, before the payload is ever decrypted
+	vaultFile.EncryptedData[0] ^= 0xff
+	data, err := json.Marshal(vaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := UnlockVault("wrong-password", path); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("UnlockVault with wrong password = %v, want ErrInvalidPassword", err)
+	}
+	if _, err := UnlockVault("master-password", path); err == nil || errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("UnlockVault with damaged payload = %v, want a decryption error", err)
+	}
+}
+
+func TestLegacyPasswordHashVaultOpens(t *testing.T) {
+	useFastKDF(t)
+	path := filepath.Join(t.TempDir(), "vault.json")
+	legacy, entry := legacyVaultWithEntry()
+	writeLegacyVault(t, path, "master-password", legacy)
+
+	if _, err := UnlockVault("wrong-password", path); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("UnlockVault with wrong password = %v, want ErrInvalidPassword", err)
+	}
+
+	session, err := UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	defer session.Close()
+
+	if got, ok := session.Vault.Entries[entry.ID]; !ok || got.Password != entry.Password {
+		t.Errorf("entry = %+v, want %+v", got, entry)
+	}
+}
+
+func TestResaveDropsPasswordHash(t *testing.T) {
+	useFastKDF(t)
+	path := filepath.Join(t.TempDir(), "vault.json")
+	legacy, _ := legacyVaultWithEntry()
+	writeLegacyVault(t, path, "master-password", legacy)
+
+	if _, ok := readRawVaultFile(t, path)["password_hash"]; !ok {
+		t.Fatal("legacy vault was written without a password hash")
+	}
+
+	session, err := UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatalf("UnlockVault: %v", err)
+	}
+	session.Close()
+
+	fields := readRawVaultFile(t, path)
+	if _, ok := fields["password_hash"]; ok {
+		t.Error("re-saved vault still carries password_hash")
+	}
+	if _, ok := fields["key_check"]; !ok {
+		t.Error("re-saved vault has no key check block")
+	}
+
+	// The re-saved file opens through the key check alone
+	session, err = UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatalf("UnlockVault after re-save: %v", err)
+	}
+	session.Close()
+	if _, err := UnlockVault("wrong-password", path); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("UnlockVault with wrong password = %v, want ErrInvalidPassword", err)
+	}
+}
+
+// writeVaultFile replaces the vault file at path with vaultFile
+func writeVaultFile(t *testing.T, path string, vaultFile *VaultFile) {
+	t.Helper()
+
+	data, err := json.Marshal(vaultFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHeaderTamperingIsDetected(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(f *VaultFile)
+	}{
+		{"format", func(f *VaultFile) { f.Format = 1 }},
+		{"version", func(f *VaultFile) { f.Version = "9.9.9" }},
+		{"vault_id", func(f *VaultFile) { f.VaultID = models.NewID() }},
+		{"kdf", func(f *VaultFile) { f.KDF.Iterations++ }},
+		{"created_at", func(f *VaultFile) { f.CreatedAt = "2001-01-01T00:00:00Z" }},
+		{"updated_at", func(f *VaultFile) { f.UpdatedAt = "2001-01-01T00:00:00Z" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := newTestVault(t, "master-password")
+			session, err := UnlockVault("master-password", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key := append([]byte(nil), session.EncryptionKey...)
+			session.Close()
+
+			vaultFile, err := ReadVaultFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tt.tamper(vaultFile)
+			writeVaultFile(t, path, vaultFile)
+
+			// The key itself is right, as it would be when handed out by the agent
+			_, err = OpenSessionWithKey(key, path)
+			if !errors.Is(err, ErrHeaderTampered) {
+				t.Errorf("OpenSessionWithKey = %v, want ErrHeaderTampered", err)
+			}
+		})
+	}
+}
+
+func TestEncryptedDataFromAnotherVaultIsRejected(t *testing.T) {
+	path := newTestVault(t, "master-password")
+	session, err := UnlockVault("master-password", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	// A second vault encrypted under the very same key, so only the header binding tells them apart
+	other := &models.Vault{
+		ID:        models.NewID(),
+		Version:   VaultVersion,
+		CreatedAt: session.Vault.CreatedAt,
+		Entries:   map[string]*models.Entry{},
+		Metadata:  map[string]string{},
+	}
+	otherPath := filepath.Join(t.TempDir(), "other.json")
+	otherData, err := encodeVaultFile(other, session.EncryptionKey, session.KDF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(otherPath, otherData, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// And one created independently with the same password
+	independentPath := newTestVault(t, "master-password")
+
+	for _, source := range []string{otherPath, independentPath} {
+		sourceFile, err := ReadVaultFile(source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vaultFile, err := ReadVaultFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		vaultFile.EncryptedData = sourceFile.EncryptedData
+
+		tampered := filepath.Join(t.TempDir(), "tampered.json")
+		writeVaultFile(t, tampered, vaultFile)
+
+		if _, err := UnlockVault("master-password", tampered); !errors.Is(err, ErrHeaderTampered) {
+			t.Errorf("UnlockVault with data from %s = %v, want ErrHeaderTampered", filepath.Base(source), err)
+		}
+	}
+}